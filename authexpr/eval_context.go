@@ -1,9 +1,230 @@
 package authexpr
 
 import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
+// MakeStandardEvaluationContext returns an EvaluationContext evaluating all the Atoms against the
+// given claims with the standard implementations.
+func MakeStandardEvaluationContext(claims map[string]interface{}) EvaluationContext {
+	return EvaluationContext{
+		JWTHasScope:      MakeStandardJWTHasScope(claims),
+		JWTClaimEquals:   MakeStandardJWTClaimEquals(claims),
+		JWTClaimIn:       MakeStandardJWTClaimIn(claims),
+		JWTClaimContains: MakeStandardJWTClaimContains(claims),
+		JWTClaimMatches:  MakeStandardJWTClaimMatches(claims),
+		JWTIssuer:        MakeStandardJWTIssuer(claims),
+	}
+}
+
+// Request holds the attributes of the request being authorized that are available to the request Atoms.
+type Request struct {
+	// Method is the HTTP method of a REST request, or the full method name of a gRPC request,
+	// e.g. /package.Service/Method.
+	Method string
+
+	// Params are the URL path parameters of a REST request.
+	Params map[string]string
+
+	// Header holds the headers of a REST request, or the metadata of a gRPC request.
+	Header http.Header
+}
+
+// MakeStandardRequestEvaluationContext returns an EvaluationContext evaluating all the Atoms against
+// the given claims and request with the standard implementations.
+func MakeStandardRequestEvaluationContext(claims map[string]interface{}, request Request) EvaluationContext {
+	evalCtx := MakeStandardEvaluationContext(claims)
+	evalCtx.RequestParamEqualsClaim = MakeStandardRequestParamEqualsClaim(claims, request)
+	evalCtx.RequestHeaderEqualsClaim = MakeStandardRequestHeaderEqualsClaim(claims, request)
+	evalCtx.RequestMethodIn = MakeStandardRequestMethodIn(request)
+	return evalCtx
+}
+
+// MakeStandardRequestParamEqualsClaim returns a RequestParamEqualsClaim implementation that is true when
+// the URL path parameter is not empty and equal to the claim at the dot separated path, or to one of its
+// elements when it is an array.
+func MakeStandardRequestParamEqualsClaim(claims map[string]interface{}, request Request) func(param string, path string) (bool, error) {
+	return func(param string, path string) (bool, error) {
+		return matchesClaim(request.Params[param], claims, path), nil
+	}
+}
+
+// MakeStandardRequestHeaderEqualsClaim returns a RequestHeaderEqualsClaim implementation that is true
+// when the first value of the header is not empty and equal to the claim at the dot separated path, or
+// to one of its elements when it is an array. Header names are case-insensitive.
+func MakeStandardRequestHeaderEqualsClaim(claims map[string]interface{}, request Request) func(header string, path string) (bool, error) {
+	return func(header string, path string) (bool, error) {
+		return matchesClaim(request.Header.Get(header), claims, path), nil
+	}
+}
+
+// MakeStandardRequestMethodIn returns a RequestMethodIn implementation that is true when the method of
+// the request is one of the methods.
+func MakeStandardRequestMethodIn(request Request) func(methods []string) (bool, error) {
+	return func(methods []string) (bool, error) {
+		for _, method := range methods {
+			if request.Method != "" && request.Method == method {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// matchesClaim returns whether the value is not empty and equal to the claim at the dot separated
+// path, or to one of its elements when it is an array.
+func matchesClaim(value string, claims map[string]interface{}, path string) bool {
+	if value == "" {
+		return false
+	}
+	for _, claim := range claimElements(claims, path) {
+		if claim == value {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeStandardJWTClaimEquals returns a JWTClaimEquals implementation that is true when the claim at
+// the dot separated path is a string, number or boolean equal to the value.
+func MakeStandardJWTClaimEquals(claims map[string]interface{}) func(path string, value string) (bool, error) {
+	return func(path string, value string) (bool, error) {
+		claim, ok := scalarClaim(claims, path)
+		return ok && claim == value, nil
+	}
+}
+
+// MakeStandardJWTClaimIn returns a JWTClaimIn implementation that is true when the claim at the dot
+// separated path, or any of its elements when it is an array, is equal to one of the values.
+func MakeStandardJWTClaimIn(claims map[string]interface{}) func(path string, values []string) (bool, error) {
+	return func(path string, values []string) (bool, error) {
+		for _, claim := range claimElements(claims, path) {
+			for _, value := range values {
+				if claim == value {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+}
+
+// MakeStandardJWTClaimContains returns a JWTClaimContains implementation that is true when the claim
+// at the dot separated path is an array holding the value, or a whitespace separated list holding the
+// value, like the scope claim.
+func MakeStandardJWTClaimContains(claims map[string]interface{}) func(path string, value string) (bool, error) {
+	return func(path string, value string) (bool, error) {
+		claim, ok := lookupClaim(claims, path)
+		if !ok {
+			return false, nil
+		}
+		elements := claimElements(claims, path)
+		if s, ok := claim.(string); ok {
+			elements = strings.Fields(s)
+		}
+		for _, element := range elements {
+			if element == value {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// MakeStandardJWTClaimMatches returns a JWTClaimMatches implementation that is true when the claim at
+// the dot separated path, or any of its elements when it is an array, matches the pattern.
+func MakeStandardJWTClaimMatches(claims map[string]interface{}) func(path string, pattern *regexp.Regexp) (bool, error) {
+	return func(path string, pattern *regexp.Regexp) (bool, error) {
+		for _, claim := range claimElements(claims, path) {
+			if pattern.MatchString(claim) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// MakeStandardJWTIssuer returns a JWTIssuer implementation that is true when the iss claim is one of
+// the issuers.
+func MakeStandardJWTIssuer(claims map[string]interface{}) func(issuers []string) (bool, error) {
+	return func(issuers []string) (bool, error) {
+		iss, ok := claims["iss"].(string)
+		if !ok {
+			return false, nil
+		}
+		for _, issuer := range issuers {
+			if iss == issuer {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// lookupClaim returns the claim at the dot separated path through nested claims objects.
+func lookupClaim(claims map[string]interface{}, path string) (interface{}, bool) {
+	var claim interface{} = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := claim.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if claim, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return claim, true
+}
+
+// scalarClaim returns the claim at the dot separated path as a string, false when it is missing or
+// is not a string, number or boolean.
+func scalarClaim(claims map[string]interface{}, path string) (string, bool) {
+	claim, ok := lookupClaim(claims, path)
+	if !ok {
+		return "", false
+	}
+	return scalarString(claim)
+}
+
+// claimElements returns the elements of the array claim at the dot separated path, or the claim
+// itself when it is not an array, as strings. Elements that are not scalars are left out.
+func claimElements(claims map[string]interface{}, path string) []string {
+	claim, ok := lookupClaim(claims, path)
+	if !ok {
+		return nil
+	}
+	elements, ok := claim.([]interface{})
+	if !ok {
+		elements = []interface{}{claim}
+	}
+	values := make([]string, 0, len(elements))
+	for _, element := range elements {
+		if value, ok := scalarString(element); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
 func MakeStandardJWTHasScope(claims map[string]interface{}) func(scope string) (bool, error) {
 	return func(queryScope string) (bool, error) {
 		// Ref: https://tools.ietf.org/html/rfc8693#section-4.2
@@ -2,6 +2,7 @@ package authexpr
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -39,6 +40,40 @@ type OpExpr struct {
 type Atom struct {
 	Name string     `parser:"@Ident"`
 	Args []*Literal `parser:"\"(\" (@@ (\",\" @@)* )? \",\"? \")\""`
+
+	// pattern is the compiled pattern of a jwtClaimMatches Atom, set by Validate.
+	pattern *regexp.Regexp
+}
+
+// atomArity is the number of string literal arguments an Atom must be called with.
+// A negative maxArgs means there is no maximum.
+type atomArity struct {
+	minArgs int
+	maxArgs int
+}
+
+var atomArities = map[string]atomArity{
+	"jwtHasScope":      {1, 1},
+	"jwtClaimEquals":   {2, 2},
+	"jwtClaimIn":       {2, -1},
+	"jwtClaimContains": {2, 2},
+	"jwtClaimMatches":  {2, 2},
+	"jwtIssuer":        {1, -1},
+
+	"requestParamEqualsClaim":  {2, 2},
+	"requestHeaderEqualsClaim": {2, 2},
+	"requestMethodIn":          {1, -1},
+}
+
+func (a atomArity) describe() string {
+	plural := "s"
+	if a.minArgs == 1 {
+		plural = ""
+	}
+	if a.minArgs == a.maxArgs {
+		return fmt.Sprintf("exactly %s string literal argument%s", strconv.Itoa(a.minArgs), plural)
+	}
+	return fmt.Sprintf("at least %s string literal argument%s", strconv.Itoa(a.minArgs), plural)
 }
 
 type Literal struct {
@@ -77,20 +112,30 @@ func (e *OpExpr) Validate() error {
 }
 
 func (e *Atom) Validate() error {
-	switch e.Name {
-	case "jwtHasScope":
-		if len(e.Args) != 1 || e.Args[0].String == nil {
-			return ValidationFailed("jwtHasScope(...) Atom must be called with exactly one string literal argument")
-		}
-	default:
+	arity, ok := atomArities[e.Name]
+	if !ok {
 		return ValidationFailed("undefined Atom for name: %s", e.Name)
 	}
+	if len(e.Args) < arity.minArgs || (arity.maxArgs >= 0 && len(e.Args) > arity.maxArgs) {
+		return ValidationFailed("%s(...) Atom must be called with %s", e.Name, arity.describe())
+	}
 	for _, arg := range e.Args {
+		if arg.String == nil {
+			return ValidationFailed("%s(...) Atom must be called with %s", e.Name, arity.describe())
+		}
 		err := arg.Validate()
 		if err != nil {
 			return err
 		}
 	}
+	if e.Name == "jwtClaimMatches" {
+		// Patterns must match the whole claim value.
+		pattern, err := regexp.Compile("^(?:" + *e.Args[1].String + ")$")
+		if err != nil {
+			return ValidationFailed("jwtClaimMatches(...) Atom must be called with a valid regular expression").WithCause(err)
+		}
+		e.pattern = pattern
+	}
 	return nil
 }
 
@@ -100,6 +145,31 @@ func (e *Literal) Validate() error {
 
 type EvaluationContext struct {
 	JWTHasScope func(scope string) (bool, error)
+
+	// JWTClaimEquals evaluates jwtClaimEquals("path.to.claim", "value").
+	JWTClaimEquals func(path string, value string) (bool, error)
+
+	// JWTClaimIn evaluates jwtClaimIn("path.to.claim", "value", ...).
+	JWTClaimIn func(path string, values []string) (bool, error)
+
+	// JWTClaimContains evaluates jwtClaimContains("path.to.claim", "value").
+	JWTClaimContains func(path string, value string) (bool, error)
+
+	// JWTClaimMatches evaluates jwtClaimMatches("path.to.claim", "regex"), with the regex anchored
+	// to match the whole claim value.
+	JWTClaimMatches func(path string, pattern *regexp.Regexp) (bool, error)
+
+	// JWTIssuer evaluates jwtIssuer("issuer", ...).
+	JWTIssuer func(issuers []string) (bool, error)
+
+	// RequestParamEqualsClaim evaluates requestParamEqualsClaim("param", "path.to.claim").
+	RequestParamEqualsClaim func(param string, path string) (bool, error)
+
+	// RequestHeaderEqualsClaim evaluates requestHeaderEqualsClaim("header", "path.to.claim").
+	RequestHeaderEqualsClaim func(header string, path string) (bool, error)
+
+	// RequestMethodIn evaluates requestMethodIn("method", ...).
+	RequestMethodIn func(methods []string) (bool, error)
 }
 
 func (e *Expr) Evaluate(evalCtx EvaluationContext) (bool, error) {
@@ -141,9 +211,57 @@ func (e *OpExpr) Evaluate(evalCtx EvaluationContext) (bool, error) {
 }
 
 func (e *Atom) Evaluate(evalCtx EvaluationContext) (bool, error) {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = *arg.String
+	}
+	unsupported := EvalFailed("%s(...) Atom is not supported by the evaluation context", e.Name)
 	switch e.Name {
 	case "jwtHasScope":
-		return evalCtx.JWTHasScope(*(e.Args[0].String))
+		if evalCtx.JWTHasScope == nil {
+			return false, unsupported
+		}
+		return evalCtx.JWTHasScope(args[0])
+	case "jwtClaimEquals":
+		if evalCtx.JWTClaimEquals == nil {
+			return false, unsupported
+		}
+		return evalCtx.JWTClaimEquals(args[0], args[1])
+	case "jwtClaimIn":
+		if evalCtx.JWTClaimIn == nil {
+			return false, unsupported
+		}
+		return evalCtx.JWTClaimIn(args[0], args[1:])
+	case "jwtClaimContains":
+		if evalCtx.JWTClaimContains == nil {
+			return false, unsupported
+		}
+		return evalCtx.JWTClaimContains(args[0], args[1])
+	case "jwtClaimMatches":
+		if evalCtx.JWTClaimMatches == nil || e.pattern == nil {
+			return false, unsupported
+		}
+		return evalCtx.JWTClaimMatches(args[0], e.pattern)
+	case "jwtIssuer":
+		if evalCtx.JWTIssuer == nil {
+			return false, unsupported
+		}
+		return evalCtx.JWTIssuer(args)
+	case "requestParamEqualsClaim":
+		if evalCtx.RequestParamEqualsClaim == nil {
+			return false, unsupported
+		}
+		return evalCtx.RequestParamEqualsClaim(args[0], args[1])
+	case "requestHeaderEqualsClaim":
+		if evalCtx.RequestHeaderEqualsClaim == nil {
+			return false, unsupported
+		}
+		return evalCtx.RequestHeaderEqualsClaim(args[0], args[1])
+	case "requestMethodIn":
+		if evalCtx.RequestMethodIn == nil {
+			return false, unsupported
+		}
+		return evalCtx.RequestMethodIn(args)
 	default:
 		return false, ValidationFailed("undefined Atom for name: %s", e.Name)
 	}
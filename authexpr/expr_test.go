@@ -1,6 +1,7 @@
 package authexpr
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -194,3 +195,133 @@ func TestCompileExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileExpressionValidatesAtomArguments(t *testing.T) {
+	t.Parallel()
+	scenarios := map[string]string{
+		`jwtHasScope()`:                      `jwtHasScope(...) Atom must be called with exactly 1 string literal argument`,
+		`jwtHasScope("a", "b")`:              `jwtHasScope(...) Atom must be called with exactly 1 string literal argument`,
+		`jwtClaimEquals("tenant")`:           `jwtClaimEquals(...) Atom must be called with exactly 2 string literal arguments`,
+		`jwtClaimEquals("tenant", "a", "b")`: `jwtClaimEquals(...) Atom must be called with exactly 2 string literal arguments`,
+		`jwtClaimIn("role")`:                 `jwtClaimIn(...) Atom must be called with at least 2 string literal arguments`,
+		`jwtClaimContains("roles")`:          `jwtClaimContains(...) Atom must be called with exactly 2 string literal arguments`,
+		`jwtClaimMatches("sub")`:             `jwtClaimMatches(...) Atom must be called with exactly 2 string literal arguments`,
+		`jwtIssuer()`:                        `jwtIssuer(...) Atom must be called with at least 1 string literal argument`,
+		`any(jwtIssuer("a"), jwtFoo("b"))`:   `undefined Atom for name: jwtFoo`,
+	}
+	for input, expectedError := range scenarios {
+		input, expectedError := input, expectedError // force capture
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			_, err := CompileExpression(input)
+			require.EqualError(t, err, "auth expression error: expression is invalid: "+expectedError)
+		})
+	}
+
+	_, err := CompileExpression(`jwtClaimMatches("sub", "(")`)
+	require.ErrorContains(t, err, "jwtClaimMatches(...) Atom must be called with a valid regular expression")
+}
+
+func TestAtomArityDescribe(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "exactly 3 string literal arguments", atomArity{minArgs: 3, maxArgs: 3}.describe())
+	require.Equal(t, "at least 1 string literal argument", atomArity{minArgs: 1, maxArgs: -1}.describe())
+}
+
+func TestClaimAtoms(t *testing.T) {
+	t.Parallel()
+	claims := map[string]interface{}{
+		"iss":    "https://issuer.example.com",
+		"sub":    "user-123",
+		"admin":  true,
+		"level":  float64(3),
+		"roles":  []interface{}{"reader", "writer"},
+		"groups": "superadmin  admin",
+		"org": map[string]interface{}{
+			"tenant": "anz",
+			"teams":  []interface{}{"payments", "lending"},
+		},
+	}
+	scenarios := map[string]bool{
+		`jwtClaimEquals("org.tenant", "anz")`:                                              true,
+		`jwtClaimEquals("org.tenant", "other")`:                                            false,
+		`jwtClaimEquals("admin", "true")`:                                                  true,
+		`jwtClaimEquals("level", "3")`:                                                     true,
+		`jwtClaimEquals("roles", "reader")`:                                                false,
+		`jwtClaimEquals("org.missing", "anz")`:                                             false,
+		`jwtClaimEquals("sub.nested", "anz")`:                                              false,
+		`jwtClaimIn("org.tenant", "westpac", "anz")`:                                       true,
+		`jwtClaimIn("roles", "admin", "writer")`:                                           true,
+		`jwtClaimIn("roles", "admin")`:                                                     false,
+		`jwtClaimContains("org.teams", "lending")`:                                         true,
+		`jwtClaimContains("org.teams", "lend")`:                                            false,
+		`jwtClaimContains("groups", "admin")`:                                              true,
+		`jwtClaimContains("groups", "super")`:                                              false,
+		`jwtClaimContains("groups", "not-admin")`:                                          false,
+		`jwtClaimMatches("sub", "user-[0-9]+")`:                                            true,
+		`jwtClaimMatches("sub", "user")`:                                                   false,
+		`jwtClaimMatches("roles", "wri.*")`:                                                true,
+		`jwtIssuer("https://issuer.example.com")`:                                          true,
+		`jwtIssuer("https://other.example.com", "untrusted")`:                              false,
+		`all(jwtIssuer("https://issuer.example.com"), not(jwtClaimIn("roles", "banned")))`: true,
+	}
+	for input, expectedResult := range scenarios {
+		input, expectedResult := input, expectedResult // force capture
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			expr, err := CompileExpression(input)
+			require.NoError(t, err)
+			actualResult, err := expr.Evaluate(MakeStandardEvaluationContext(claims))
+			require.NoError(t, err)
+			require.Equal(t, expectedResult, actualResult)
+		})
+	}
+}
+
+func TestAtomNotSupportedByEvaluationContext(t *testing.T) {
+	t.Parallel()
+	expr, err := CompileExpression(`jwtClaimEquals("tenant", "anz")`)
+	require.NoError(t, err)
+	require.Equal(t, `jwtClaimEquals("tenant","anz")`, expr.Repr())
+	_, err = expr.Evaluate(EvaluationContext{JWTHasScope: demoScopes(nil)})
+	require.EqualError(t, err, "auth expression error: evaluation failure: jwtClaimEquals(...) Atom is not supported by the evaluation context")
+}
+
+func TestRequestAtoms(t *testing.T) {
+	t.Parallel()
+	claims := map[string]interface{}{
+		"sub":     "customer-1",
+		"tenants": []interface{}{"anz", "other"},
+	}
+	request := Request{
+		Method: "GET",
+		Params: map[string]string{"customerId": "customer-1", "accountId": "account-1", "empty": ""},
+		Header: http.Header{"X-Tenant": {"anz"}},
+	}
+	scenarios := map[string]bool{
+		`requestParamEqualsClaim("customerId", "sub")`:                              true,
+		`requestParamEqualsClaim("accountId", "sub")`:                               false,
+		`requestParamEqualsClaim("missing", "sub")`:                                 false,
+		`requestParamEqualsClaim("empty", "missing")`:                               false,
+		`requestHeaderEqualsClaim("x-tenant", "tenants")`:                           true,
+		`requestHeaderEqualsClaim("X-Tenant", "sub")`:                               false,
+		`requestHeaderEqualsClaim("X-Missing", "tenants")`:                          false,
+		`requestMethodIn("GET", "HEAD")`:                                            true,
+		`requestMethodIn("POST")`:                                                   false,
+		`all(requestMethodIn("GET"), requestParamEqualsClaim("customerId", "sub"))`: true,
+	}
+	for input, expectedResult := range scenarios {
+		input, expectedResult := input, expectedResult // force capture
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			expr, err := CompileExpression(input)
+			require.NoError(t, err)
+			actualResult, err := expr.Evaluate(MakeStandardRequestEvaluationContext(claims, request))
+			require.NoError(t, err)
+			require.Equal(t, expectedResult, actualResult)
+		})
+	}
+
+	_, err := CompileExpression(`requestParamEqualsClaim("customerId")`)
+	require.EqualError(t, err, "auth expression error: expression is invalid: requestParamEqualsClaim(...) Atom must be called with exactly 2 string literal arguments")
+}
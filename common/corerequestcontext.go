@@ -3,6 +3,7 @@ package common
 import (
 	"context"
 	"net/http"
+	"net/url"
 
 	"github.com/anz-bank/sysl-go/common/internal"
 	"github.com/anz-bank/sysl-go/log"
@@ -69,6 +70,18 @@ func RequestHeaderFromContext(ctx context.Context) http.Header {
 	return reqHeader.header.Clone()
 }
 
+// RequestQueryToContext creates a new context containing the raw query of the request URL.
+func RequestQueryToContext(ctx context.Context, rawQuery string) context.Context {
+	return context.WithValue(ctx, reqQueryContextKey{}, rawQuery)
+}
+
+// RequestQueryFromContext retrieves the query of the request URL from the context.
+func RequestQueryFromContext(ctx context.Context) url.Values {
+	rawQuery, _ := ctx.Value(reqQueryContextKey{}).(string)
+	query, _ := url.ParseQuery(rawQuery)
+	return query
+}
+
 // RespHeaderAndStatusToContext creates a new context containing the response header and status.
 func RespHeaderAndStatusToContext(ctx context.Context, header http.Header, status int) context.Context {
 	return context.WithValue(ctx, respHeaderAndStatusContextKey{}, &respHeaderAndStatusContext{header.Clone(), status})
@@ -113,7 +126,8 @@ func UpdateResponseStatus(ctx context.Context, status int) error {
 func CoreRequestContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		ctx = log.WithStr(ctx, traceIDLogField, GetTraceIDFromContext(ctx).String())
+		ctx = log.WithStr(ctx, traceIDLogField, GetTraceIDStringFromContext(ctx))
+		ctx = RequestQueryToContext(ctx, r.URL.RawQuery)
 
 		ctx = internal.AddResponseBodyMonitorToContext(ctx)
 		defer internal.CheckForUnclosedResponses(ctx)
@@ -132,6 +146,7 @@ func CoreRequestContextMiddleware(next http.Handler) http.Handler {
 }
 
 type reqHeaderContextKey struct{}
+type reqQueryContextKey struct{}
 type respHeaderAndStatusContextKey struct{}
 
 func getReqHeaderContext(ctx context.Context) *reqHeaderContext {
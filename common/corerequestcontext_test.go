@@ -82,6 +82,13 @@ func TestGetUnsetRequestHeader(t *testing.T) {
 	require.Equal(t, http.Header(nil), out)
 }
 
+func TestSetAndGetRequestQuery(t *testing.T) {
+	out := RequestQueryFromContext(RequestQueryToContext(context.Background(), "key=a&key=b&x=1"))
+	require.Equal(t, []string{"a", "b"}, out["key"])
+	require.Equal(t, "1", out.Get("x"))
+	require.Empty(t, RequestQueryFromContext(context.Background()))
+}
+
 func TestGetUnsetResponseHeaderAndStatus(t *testing.T) {
 	header, status := RespHeaderAndStatusFromContext(context.Background())
 	require.Equal(t, http.Header(nil), header)
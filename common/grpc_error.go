@@ -0,0 +1,195 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/anz-bank/sysl-go/log"
+)
+
+// grpcErrorDomain is the domain reported within the ErrorInfo details of mapped gRPC errors.
+const grpcErrorDomain = "sysl-go"
+
+// DownstreamGRPCError is returned from gRPC downstream clients when the downstream service
+// responds with a non-OK status.
+type DownstreamGRPCError struct {
+	Kind   Kind
+	Status *status.Status
+	Cause  error
+}
+
+func (e *DownstreamGRPCError) ErrorKind() Kind {
+	return e.Kind
+}
+
+func (e *DownstreamGRPCError) Error() string {
+	return fmt.Sprintf("DownstreamGRPCError(Kind=%s, Code=%s, Message=%s)", e.Kind, e.Status.Code(), e.Status.Message())
+}
+
+func (e *DownstreamGRPCError) Unwrap() error {
+	return e.Cause
+}
+
+// CreateDownstreamGRPCError maps an error returned from a gRPC downstream call into an error
+// that carries a Kind, so that it is handled consistently with errors from HTTP downstreams.
+// Errors that are not gRPC status errors are returned unchanged.
+func CreateDownstreamGRPCError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(ErrorKinder); ok {
+		return err
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	var kind Kind
+	switch s.Code() {
+	case codes.Unavailable:
+		kind = DownstreamUnavailableError
+	case codes.DeadlineExceeded:
+		kind = DownstreamTimeoutError
+	case codes.Unauthenticated, codes.PermissionDenied:
+		kind = DownstreamUnauthorizedError
+	case codes.Unknown, codes.Internal, codes.Unimplemented, codes.DataLoss:
+		kind = DownstreamUnexpectedResponseError
+	default:
+		kind = DownstreamResponseError
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		kind = DownstreamTimeoutError
+	}
+
+	return &DownstreamGRPCError{Kind: kind, Status: s, Cause: err}
+}
+
+// MapGRPCError maps an error to a gRPC status. Errors that already carry a gRPC status
+// (e.g. those created with status.Error) are returned unchanged, errors implementing
+// ErrorKinder are mapped based on their Kind and all other errors are mapped to codes.Unknown
+// (or codes.DeadlineExceeded if the context deadline has been exceeded).
+//
+// Mapped statuses include an errdetails.ErrorInfo detail holding the sysl-go error code.
+func MapGRPCError(ctx context.Context, err error) *status.Status {
+	var (
+		grpcCode        codes.Code
+		errorCode, desc string
+		reason          string
+	)
+
+	var kinder ErrorKinder
+	switch {
+	case errors.As(err, &kinder):
+		switch kinder.ErrorKind() {
+		case BadRequestError:
+			grpcCode = codes.InvalidArgument
+			errorCode = "1001"
+			desc = missingParam
+			reason = "BAD_REQUEST"
+		case InternalError:
+			grpcCode = codes.Internal
+			errorCode = "9998"
+			desc = internalServerError
+			reason = "INTERNAL"
+		case UnauthorizedError:
+			grpcCode = codes.Unauthenticated
+			errorCode = "1003"
+			desc = unauthorizedError
+			reason = "UNAUTHORIZED"
+		case DownstreamUnavailableError:
+			grpcCode = codes.Unavailable
+			errorCode = "1013"
+			desc = downstreamUnavailable
+			reason = "DOWNSTREAM_UNAVAILABLE"
+		case DownstreamTimeoutError:
+			grpcCode = codes.DeadlineExceeded
+			errorCode = "1005"
+			desc = timeoutDownstream
+			reason = "DOWNSTREAM_TIMEOUT"
+		default:
+			grpcCode = codes.Unknown
+			errorCode = "9999"
+			desc = unknownError
+			reason = "UNKNOWN"
+		}
+	default:
+		if s, ok := status.FromError(err); ok {
+			return s
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			grpcCode = codes.DeadlineExceeded
+			errorCode = "1005"
+			desc = timeoutDownstream
+			reason = "DOWNSTREAM_TIMEOUT"
+		} else {
+			grpcCode = codes.Unknown
+			errorCode = "9999"
+			desc = unknownError
+			reason = "UNKNOWN"
+		}
+	}
+
+	s := status.New(grpcCode, desc)
+	withDetails, detailsErr := s.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   grpcErrorDomain,
+		Metadata: map[string]string{"code": errorCode},
+	})
+	if detailsErr != nil {
+		log.Error(ctx, detailsErr, "error adding details to gRPC status")
+		return s
+	}
+	return withDetails
+}
+
+// HandleGRPCError converts an error returned from a gRPC handler into a gRPC status error.
+// The mapper may be nil, or may return nil, to perform the default mapping (see MapGRPCError).
+func HandleGRPCError(ctx context.Context, err error, mapper func(context.Context, error) *status.Status) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); !ok {
+		log.Error(ctx, err, "error handled")
+	}
+
+	var s *status.Status
+	if mapper != nil {
+		s = mapper(ctx, err)
+	}
+	if s == nil {
+		s = MapGRPCError(ctx, err)
+	}
+	return s.Err()
+}
+
+// grpcErrorReasons maps the reasons of the ErrorInfo details added by MapGRPCError back to their Kind.
+var grpcErrorReasons = map[string]Kind{
+	"BAD_REQUEST":            BadRequestError,
+	"INTERNAL":               InternalError,
+	"UNAUTHORIZED":           UnauthorizedError,
+	"DOWNSTREAM_UNAVAILABLE": DownstreamUnavailableError,
+	"DOWNSTREAM_TIMEOUT":     DownstreamTimeoutError,
+}
+
+// KindFromGRPCStatus returns the Kind of an error that was mapped to the given status by MapGRPCError,
+// from the reason of its ErrorInfo detail. Returns false when the status was not mapped from a Kind.
+func KindFromGRPCStatus(s *status.Status) (Kind, bool) {
+	for _, detail := range s.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != grpcErrorDomain {
+			continue
+		}
+		kind, ok := grpcErrorReasons[info.GetReason()]
+		if !ok {
+			kind = UnknownError
+		}
+		return kind, true
+	}
+	return UnknownError, false
+}
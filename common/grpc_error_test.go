@@ -0,0 +1,122 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+func TestMapGRPCError(t *testing.T) {
+	t.Parallel()
+
+	deadlineCtx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{"BadRequest", context.Background(), &ServerError{Kind: BadRequestError}, codes.InvalidArgument, "BAD_REQUEST"},
+		{"Internal", context.Background(), &ServerError{Kind: InternalError}, codes.Internal, "INTERNAL"},
+		{"Unauthorized", context.Background(), &ServerError{Kind: UnauthorizedError}, codes.Unauthenticated, "UNAUTHORIZED"},
+		{"DownstreamUnavailable", context.Background(), &ServerError{Kind: DownstreamUnavailableError}, codes.Unavailable, "DOWNSTREAM_UNAVAILABLE"},
+		{"DownstreamTimeout", context.Background(), &DownstreamError{Kind: DownstreamTimeoutError}, codes.DeadlineExceeded, "DOWNSTREAM_TIMEOUT"},
+		{"DownstreamResponse", context.Background(), &DownstreamError{Kind: DownstreamResponseError}, codes.Unknown, "UNKNOWN"},
+		{"Plain", context.Background(), errors.New("plain"), codes.Unknown, "UNKNOWN"},
+		{"PlainDeadline", deadlineCtx, errors.New("plain"), codes.DeadlineExceeded, "DOWNSTREAM_TIMEOUT"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := MapGRPCError(tt.ctx, tt.err)
+			require.Equal(t, tt.code, s.Code())
+			require.Len(t, s.Details(), 1)
+			info, ok := s.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, tt.reason, info.Reason)
+			require.Equal(t, grpcErrorDomain, info.Domain)
+		})
+	}
+}
+
+func TestMapGRPCError_StatusPassthrough(t *testing.T) {
+	t.Parallel()
+
+	err := status.Error(codes.NotFound, "missing")
+	s := MapGRPCError(context.Background(), err)
+	require.Equal(t, codes.NotFound, s.Code())
+	require.Equal(t, "missing", s.Message())
+}
+
+func TestKindFromGRPCStatus(t *testing.T) {
+	t.Parallel()
+
+	for _, kind := range []Kind{BadRequestError, InternalError, UnauthorizedError, DownstreamUnavailableError, DownstreamTimeoutError, UnknownError} {
+		mapped, ok := KindFromGRPCStatus(MapGRPCError(context.Background(), &ServerError{Kind: kind}))
+		require.True(t, ok)
+		require.Equal(t, kind, mapped)
+	}
+
+	_, ok := KindFromGRPCStatus(status.New(codes.NotFound, "missing"))
+	require.False(t, ok)
+}
+
+func TestHandleGRPCError(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.NewTestContext()
+	require.NoError(t, HandleGRPCError(ctx, nil, nil))
+
+	err := HandleGRPCError(ctx, &ServerError{Kind: BadRequestError}, nil)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = HandleGRPCError(ctx, &ServerError{Kind: BadRequestError}, func(context.Context, error) *status.Status {
+		return status.New(codes.FailedPrecondition, "custom")
+	})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	err = HandleGRPCError(ctx, &ServerError{Kind: BadRequestError}, func(context.Context, error) *status.Status {
+		return nil
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCreateDownstreamGRPCError(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, CreateDownstreamGRPCError(context.Background(), nil))
+
+	plain := errors.New("plain")
+	require.Equal(t, plain, CreateDownstreamGRPCError(context.Background(), plain))
+
+	tests := []struct {
+		code codes.Code
+		kind Kind
+	}{
+		{codes.Unavailable, DownstreamUnavailableError},
+		{codes.DeadlineExceeded, DownstreamTimeoutError},
+		{codes.Unauthenticated, DownstreamUnauthorizedError},
+		{codes.PermissionDenied, DownstreamUnauthorizedError},
+		{codes.Internal, DownstreamUnexpectedResponseError},
+		{codes.NotFound, DownstreamResponseError},
+	}
+	for _, tt := range tests {
+		err := CreateDownstreamGRPCError(context.Background(), status.Error(tt.code, "downstream"))
+		var downstreamErr *DownstreamGRPCError
+		require.True(t, errors.As(err, &downstreamErr))
+		require.Equal(t, tt.kind, downstreamErr.ErrorKind())
+		require.Equal(t, tt.code, status.Code(err))
+	}
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/jwtauth"
+)
+
+const redactedValue = "[REDACTED]"
+
+var defaultRedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", jwtauth.DefaultAPIKeyHeader}
+
+// payloadRedactor redacts and truncates the headers and bodies logged by the requestLogger.
+type payloadRedactor struct {
+	headers      map[string]struct{}
+	paths        [][]string
+	maxBodySize  int
+	contentTypes []string
+}
+
+// payloadRedactors holds the redactor built for each configuration, so that it is built once
+// rather than for every logged request.
+var payloadRedactors sync.Map // *config.LibraryConfig -> *payloadRedactor
+
+// getPayloadRedactor returns the redactor for the configuration, building it on first use.
+func getPayloadRedactor(cfg *config.LibraryConfig) *payloadRedactor {
+	if r, ok := payloadRedactors.Load(cfg); ok {
+		return r.(*payloadRedactor)
+	}
+	r, _ := payloadRedactors.LoadOrStore(cfg, newPayloadRedactor(cfg))
+	return r.(*payloadRedactor)
+}
+
+// newPayloadRedactor builds the redactor of the payload log configuration, which also redacts the
+// header holding the API keys of the requests when authentication by API key is configured.
+func newPayloadRedactor(cfg *config.LibraryConfig) *payloadRedactor {
+	payload := cfg.Log.Payload
+	headers := payload.RedactHeaders
+	if headers == nil {
+		headers = defaultRedactHeaders
+	}
+	if auth := cfg.Authentication; auth != nil && auth.JWTAuth != nil && auth.JWTAuth.APIKey != nil {
+		if header := auth.JWTAuth.APIKey.KeyHeader(); header != "" {
+			headers = append(headers[:len(headers):len(headers)], header)
+		}
+	}
+	r := &payloadRedactor{
+		headers:      make(map[string]struct{}, len(headers)),
+		maxBodySize:  payload.MaxBodySize,
+		contentTypes: payload.ContentTypes,
+	}
+	for _, h := range headers {
+		r.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	for _, p := range payload.RedactJSONPaths {
+		r.paths = append(r.paths, parseJSONPath(p))
+	}
+	return r
+}
+
+// header returns a copy of the header with the values of the deny-listed headers redacted.
+func (r *payloadRedactor) header(header http.Header) http.Header {
+	result := header.Clone()
+	for key, values := range result {
+		if _, has := r.headers[http.CanonicalHeaderKey(key)]; has {
+			redacted := make([]string, len(values))
+			for i := range redacted {
+				redacted[i] = redactedValue
+			}
+			result[key] = redacted
+		}
+	}
+	return result
+}
+
+// body returns the body to log: omitted for filtered content types, with the configured JSON
+// fields redacted and truncated to the maximum size.
+func (r *payloadRedactor) body(header http.Header, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if contentType := header.Get("Content-Type"); !r.allowContentType(contentType) {
+		return fmt.Sprintf("[body omitted, content type %s]", contentType)
+	}
+	if len(r.paths) > 0 {
+		body = r.redactJSON(body)
+	}
+	if r.maxBodySize > 0 && len(body) > r.maxBodySize {
+		return fmt.Sprintf("%s...[truncated %d bytes]", body[:r.maxBodySize], len(body)-r.maxBodySize)
+	}
+	return string(body)
+}
+
+func (r *payloadRedactor) allowContentType(contentType string) bool {
+	if len(r.contentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range r.contentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType || allowed == "*/*" ||
+			(strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON redacts the fields at the configured paths. Bodies that are not JSON are returned as is.
+func (r *payloadRedactor) redactJSON(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return body
+	}
+	for _, path := range r.paths {
+		value = redactPath(value, path)
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return b
+}
+
+// parseJSONPath splits a path such as $.accounts[*].id into its segments: accounts, *, id.
+func parseJSONPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	var segments []string
+	for _, s := range strings.Split(path, ".") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// redactPath replaces the values at the path within value, where * matches any key or element.
+func redactPath(value interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return redactedValue
+	}
+	segment, rest := path[0], path[1:]
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if segment == "*" || segment == key {
+				v[key] = redactPath(child, rest)
+			}
+		}
+	case []interface{}:
+		if segment == "*" {
+			for i, child := range v {
+				v[i] = redactPath(child, rest)
+			}
+		} else if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(v) {
+			v[i] = redactPath(v[i], rest)
+		}
+	}
+	return value
+}
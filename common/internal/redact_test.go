@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+func payloadLogConfig(payload config.PayloadLogConfig) *config.LibraryConfig {
+	return &config.LibraryConfig{Log: config.LogConfig{Payload: payload}}
+}
+
+func TestPayloadRedactor_Header(t *testing.T) {
+	r := newPayloadRedactor(payloadLogConfig(config.PayloadLogConfig{}))
+	header := http.Header{"Authorization": {"Bearer x"}, "Set-Cookie": {"a", "b"}, "Accept": {"*/*"}, "X-Api-Key": {"secret"}}
+	require.Equal(t, http.Header{
+		"Authorization": {redactedValue},
+		"Set-Cookie":    {redactedValue, redactedValue},
+		"Accept":        {"*/*"},
+		"X-Api-Key":     {redactedValue},
+	}, r.header(header))
+	require.Equal(t, "Bearer x", header.Get("Authorization"))
+
+	r = newPayloadRedactor(payloadLogConfig(config.PayloadLogConfig{RedactHeaders: []string{"x-api-key"}}))
+	require.Equal(t, http.Header{"Authorization": {"Bearer x"}, "X-Api-Key": {redactedValue}},
+		r.header(http.Header{"Authorization": {"Bearer x"}, "X-Api-Key": {"secret"}}))
+}
+
+func TestPayloadRedactor_APIKeyHeader(t *testing.T) {
+	cfg := payloadLogConfig(config.PayloadLogConfig{RedactHeaders: []string{"Authorization"}})
+	cfg.Authentication = &config.AuthenticationConfig{JWTAuth: &jwtauth.Config{APIKey: &jwtauth.APIKeyConfig{Header: "X-Client-Key"}}}
+	r := newPayloadRedactor(cfg)
+	require.Equal(t, http.Header{"Authorization": {redactedValue}, "X-Client-Key": {redactedValue}, "Accept": {"*/*"}},
+		r.header(http.Header{"Authorization": {"Bearer x"}, "X-Client-Key": {"secret"}, "Accept": {"*/*"}}))
+	require.Equal(t, []string{"Authorization"}, cfg.Log.Payload.RedactHeaders)
+
+	cfg.Authentication.JWTAuth.APIKey = &jwtauth.APIKeyConfig{}
+	r = newPayloadRedactor(cfg)
+	require.Equal(t, http.Header{"X-Api-Key": {redactedValue}}, r.header(http.Header{"X-Api-Key": {"secret"}}))
+}
+
+func TestPayloadRedactor_Body(t *testing.T) {
+	json := http.Header{"Content-Type": {"application/json; charset=utf-8"}}
+	r := newPayloadRedactor(payloadLogConfig(config.PayloadLogConfig{
+		RedactJSONPaths: []string{"$.card.number", "$.accounts[*].id", "items[1]"},
+	}))
+	require.JSONEq(t,
+		`{"card":{"number":"[REDACTED]","name":"a"},"accounts":[{"id":"[REDACTED]"},{"id":"[REDACTED]","n":1}],"items":[1,"[REDACTED]"]}`,
+		r.body(json, []byte(`{"card":{"number":"4111","name":"a"},"accounts":[{"id":1},{"id":2,"n":1}],"items":[1,2]}`)))
+	require.Equal(t, "not json", r.body(json, []byte("not json")))
+
+	r = newPayloadRedactor(payloadLogConfig(config.PayloadLogConfig{MaxBodySize: 4, ContentTypes: []string{"application/json", "text/*"}}))
+	require.Equal(t, "hell...[truncated 1 bytes]", r.body(http.Header{"Content-Type": {"text/plain"}}, []byte("hello")))
+	require.Equal(t, "[body omitted, content type image/png]", r.body(http.Header{"Content-Type": {"image/png"}}, []byte("png")))
+	require.Equal(t, "[body omitted, content type ]", r.body(http.Header{}, []byte("?")))
+	require.Equal(t, "", r.body(http.Header{}, nil))
+}
+
+func TestGetPayloadRedactor(t *testing.T) {
+	cfg := &config.LibraryConfig{}
+	require.Same(t, getPayloadRedactor(cfg), getPayloadRedactor(cfg))
+	require.NotSame(t, getPayloadRedactor(cfg), getPayloadRedactor(&config.LibraryConfig{}))
+}
+
+func TestRequestLogger_Redaction(t *testing.T) {
+	cfg := &config.DefaultConfig{}
+	cfg.Library.Log.LogPayload = true
+	cfg.Library.Log.Payload.RedactJSONPaths = []string{"$.password"}
+	ctx, logger := testutil.NewTestContextWithLogger(testutil.WithLogLevel(log.DebugLevel), testutil.WithConfig(cfg))
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/login", bytes.NewBufferString(`{"password":"secret"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+
+	l, _ := NewRequestLogger(ctx, req)
+	l.LogResponse(&http.Response{Header: http.Header{"Set-Cookie": {"session=1"}}, Body: io.NopCloser(&bytes.Buffer{})})
+
+	entries := logger.Entries()
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		require.NotContains(t, e.Message, "secret")
+		require.NotContains(t, e.Message, "token")
+		require.NotContains(t, e.Message, "session=1")
+	}
+	require.Contains(t, entries[0].Message, `{"password":"[REDACTED]"}`)
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, `{"password":"secret"}`, string(body))
+}
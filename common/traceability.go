@@ -2,7 +2,9 @@ package common
 
 import (
 	"context"
+	"encoding/hex"
 	"net/http"
+	"strings"
 
 	"github.com/anz-bank/sysl-go/config"
 	"github.com/anz-bank/sysl-go/log"
@@ -16,22 +18,52 @@ type traceabilityContextKey struct{}
 
 type requestID struct {
 	id          uuid.UUID
+	raw         string
 	wasProvided bool
+
+	// propagation holds the incoming W3C or B3 trace headers that are forwarded to downstreams.
+	propagation http.Header
 }
 
 const traceIDLogField = "traceid"
 const defaultIncomingHeaderForID = "RequestID"
 
+// Trace context headers supported in addition to the ID header.
+const (
+	traceparentHeader  = "traceparent"
+	tracestateHeader   = "tracestate"
+	b3Header           = "b3"
+	b3TraceIDHeader    = "X-B3-TraceId"
+	b3SpanIDHeader     = "X-B3-SpanId"
+	b3ParentSpanHeader = "X-B3-ParentSpanId"
+	b3SampledHeader    = "X-B3-Sampled"
+	b3FlagsHeader      = "X-B3-Flags"
+)
+
+// traceIDNamespace is the namespace of the UUIDs derived from incoming IDs that are not UUIDs.
+var traceIDNamespace = uuid.MustParse("1b4e28ba-2fa1-11d2-883f-0016d3cca427")
+
 // Injects a traceId UUID into the request context.
+//
+// The trace ID is read from the RequestID header (or library.trace.incomingHeaderForID), then the
+// W3C traceparent header, then the B3 headers. IDs that are not UUIDs are preserved as received,
+// see GetTraceIDStringFromContext. A new UUID is generated when none of the headers are present.
+// The trace ID is echoed in the library.trace.responseHeaderForID response header when configured.
 func TraceabilityMiddleware(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		val, err := uuid.Parse(r.Header.Get(getIncomingHeaderForID(ctx)))
-		if err != nil {
-			log.Info(internal.InitFieldsFromRequest(ctx, r), "Incoming request with invalid or missing RequestID header, filled traceid with new UUID instead")
-			r = r.WithContext(AddTraceIDToContext(r.Context(), uuid.New(), false))
-		} else {
-			r = r.WithContext(AddTraceIDToContext(r.Context(), val, true))
+		idHeader := getIncomingHeaderForID(ctx)
+		val, ok := traceIDFromHeader(r.Header, idHeader)
+		if !ok {
+			log.Infof(internal.InitFieldsFromRequest(ctx, r),
+				"Incoming request with invalid or missing %s, traceparent and B3 headers, filled traceid with new UUID instead", idHeader)
+			id := uuid.New()
+			val = &requestID{id: id, raw: id.String()}
+		}
+		r = r.WithContext(context.WithValue(ctx, traceabilityContextKey{}, val))
+
+		if header := getResponseHeaderForID(ctx); header != "" {
+			w.Header().Set(header, val.raw)
 		}
 
 		next.ServeHTTP(w, r)
@@ -53,8 +85,148 @@ func TryGetTraceIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	return uuid.New(), false
 }
 
+// GetTraceIDStringFromContext returns the trace ID as it was received, which may not be a UUID.
+// Like GetTraceIDFromContext, a new UUID is returned when the context holds no trace ID.
+func GetTraceIDStringFromContext(ctx context.Context) string {
+	val, ok := ctx.Value(traceabilityContextKey{}).(*requestID)
+	if !ok {
+		return uuid.New().String()
+	}
+	return val.raw
+}
+
+// TryGetTraceIDStringFromContext returns the trace ID as it was received, false when the context
+// holds no trace ID.
+func TryGetTraceIDStringFromContext(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(traceabilityContextKey{}).(*requestID)
+	if !ok {
+		return "", false
+	}
+	return val.raw, true
+}
+
 func AddTraceIDToContext(ctx context.Context, id uuid.UUID, wasProvided bool) context.Context {
-	return context.WithValue(ctx, traceabilityContextKey{}, &requestID{id, wasProvided})
+	return context.WithValue(ctx, traceabilityContextKey{}, &requestID{id: id, raw: id.String(), wasProvided: wasProvided})
+}
+
+// AddTraceIDStringToContext adds a trace ID that may not be a UUID to the context. The UUID returned
+// by GetTraceIDFromContext is derived from the ID.
+func AddTraceIDStringToContext(ctx context.Context, id string, wasProvided bool) context.Context {
+	return context.WithValue(ctx, traceabilityContextKey{}, &requestID{id: uuidFromTraceID(id), raw: id, wasProvided: wasProvided})
+}
+
+// PropagateTraceHeaders sets the headers that carry the trace ID of the context to a downstream:
+// the ID header and any W3C or B3 headers of the incoming request. Headers already present are
+// left untouched.
+func PropagateTraceHeaders(ctx context.Context, header http.Header) {
+	val, ok := ctx.Value(traceabilityContextKey{}).(*requestID)
+	if !ok {
+		return
+	}
+	setIfAbsent := func(key, value string) {
+		if header.Get(key) == "" {
+			header.Set(key, value)
+		}
+	}
+	setIfAbsent(getIncomingHeaderForID(ctx), val.raw)
+	for key, values := range val.propagation {
+		setIfAbsent(key, values[0])
+	}
+}
+
+// TraceIDFromHeader returns the trace ID found in the headers as TraceabilityMiddleware would, or
+// an empty string when there is none. It allows the trace ID of gRPC requests to be read from their metadata.
+func TraceIDFromHeader(ctx context.Context, header http.Header) string {
+	val, ok := traceIDFromHeader(header, getIncomingHeaderForID(ctx))
+	if !ok {
+		return ""
+	}
+	return val.raw
+}
+
+// traceIDFromHeader reads the trace ID from the ID header, the W3C traceparent header or the B3 headers.
+func traceIDFromHeader(header http.Header, idHeader string) (*requestID, bool) {
+	propagation := http.Header{}
+	if v := header.Get(traceparentHeader); v != "" {
+		propagation.Set(traceparentHeader, v)
+		if state := header.Get(tracestateHeader); state != "" {
+			propagation.Set(tracestateHeader, state)
+		}
+	}
+	if v := header.Get(b3Header); v != "" {
+		propagation.Set(b3Header, v)
+	}
+	for _, key := range []string{b3TraceIDHeader, b3SpanIDHeader, b3ParentSpanHeader, b3SampledHeader, b3FlagsHeader} {
+		if v := header.Get(key); v != "" {
+			propagation.Set(key, v)
+		}
+	}
+
+	if v := header.Get(idHeader); v != "" {
+		if id, err := uuid.Parse(v); err == nil {
+			return &requestID{id: id, raw: id.String(), wasProvided: true, propagation: propagation}, true
+		}
+		return &requestID{id: uuidFromTraceID(v), raw: v, wasProvided: true, propagation: propagation}, true
+	}
+	for _, traceID := range []string{
+		parseTraceparent(header.Get(traceparentHeader)),
+		parseB3TraceID(header.Get(b3Header)),
+		header.Get(b3TraceIDHeader),
+	} {
+		if traceID = strings.ToLower(traceID); isHexTraceID(traceID) {
+			return &requestID{id: uuidFromTraceID(traceID), raw: traceID, wasProvided: true, propagation: propagation}, true
+		}
+	}
+	return nil, false
+}
+
+// parseTraceparent returns the trace ID of a W3C traceparent header: version-traceid-parentid-flags.
+func parseTraceparent(v string) string {
+	parts := strings.Split(v, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ""
+	}
+	return parts[1]
+}
+
+// parseB3TraceID returns the trace ID of a single b3 header: traceid-spanid-sampled-parentspanid.
+func parseB3TraceID(v string) string {
+	parts := strings.Split(v, "-")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+// isHexTraceID returns whether v is a 64 or 128-bit lowercase hex trace ID that is not all zeros.
+func isHexTraceID(v string) bool {
+	if len(v) != 16 && len(v) != 32 {
+		return false
+	}
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		return false
+	}
+	for _, c := range b {
+		if c != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// uuidFromTraceID returns the UUID corresponding to a trace ID. UUIDs and hex trace IDs map to the
+// same bytes (64-bit IDs are left-padded with zeros), other IDs map to a name-based UUID.
+func uuidFromTraceID(v string) uuid.UUID {
+	if id, err := uuid.Parse(v); err == nil {
+		return id
+	}
+	if b, err := hex.DecodeString(v); err == nil && (len(b) == 8 || len(b) == 16) {
+		var id uuid.UUID
+		copy(id[len(id)-len(b):], b)
+		return id
+	}
+	return uuid.NewSHA1(traceIDNamespace, []byte(v))
 }
 
 func getIncomingHeaderForID(ctx context.Context) string {
@@ -66,3 +238,11 @@ func getIncomingHeaderForID(ctx context.Context) string {
 
 	return ret
 }
+
+func getResponseHeaderForID(ctx context.Context) string {
+	cfg := config.GetDefaultConfig(ctx)
+	if cfg == nil {
+		return ""
+	}
+	return cfg.Library.Trace.ResponseHeaderForID
+}
@@ -2,13 +2,16 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/anz-bank/sysl-go/config"
 	"github.com/anz-bank/sysl-go/testutil"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -56,6 +59,7 @@ func TestTraceabilityMiddleware(t *testing.T) {
 			fn := mware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
 				if tt.expectWarning {
 					require.NotZero(t, logger.EntryCount())
+					require.Contains(t, logger.Entries()[0].Message, "missing "+getIncomingHeaderForID(ctx)+", traceparent and B3 headers")
 				} else {
 					require.Zero(t, logger.EntryCount())
 					require.Equal(t, strings.ToLower(*tt.reqid), strings.ToLower(GetTraceIDFromContext(r.Context()).String()))
@@ -65,3 +69,65 @@ func TestTraceabilityMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestTraceabilityMiddleware_TraceHeaders(t *testing.T) {
+	cases := []struct {
+		name   string
+		header http.Header
+		raw    string
+		id     string
+	}{
+		{"non-UUID ID header", http.Header{"Requestid": {"abc-123"}}, "abc-123", uuid.NewSHA1(traceIDNamespace, []byte("abc-123")).String()},
+		{"traceparent", http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}, "4bf92f3577b34da6a3ce929d0e0e4736", "4bf92f35-77b3-4da6-a3ce-929d0e0e4736"},
+		{"b3 multi", http.Header{"X-B3-Traceid": {"A3CE929D0E0E4736"}, "X-B3-Spanid": {"00f067aa0ba902b7"}}, "a3ce929d0e0e4736", "00000000-0000-0000-a3ce-929d0e0e4736"},
+		{"b3 single", http.Header{"B3": {"80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1"}}, "80f198ee56343ba864fe8b2a57d3eff7", "80f198ee-5634-3ba8-64fe-8b2a57d3eff7"},
+		{"ID header wins", http.Header{"Requestid": {"652817bc-ee0c-40e3-936c-fa74aea0ad49"}, "Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}, "652817bc-ee0c-40e3-936c-fa74aea0ad49", "652817bc-ee0c-40e3-936c-fa74aea0ad49"},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			ctx, logger := testutil.NewTestContextWithLogger()
+			cfg := config.DefaultConfig{}
+			cfg.Library.Trace.ResponseHeaderForID = "X-Trace-Id"
+			ctx = config.PutDefaultConfig(ctx, &cfg)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+			req.Header = c.header
+			w := httptest.NewRecorder()
+			TraceabilityMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				require.Equal(t, c.raw, GetTraceIDStringFromContext(r.Context()))
+				raw, ok := TryGetTraceIDStringFromContext(r.Context())
+				require.True(t, ok)
+				require.Equal(t, c.raw, raw)
+				id, provided := TryGetTraceIDFromContext(r.Context())
+				require.True(t, provided)
+				require.Equal(t, c.id, id.String())
+			})).ServeHTTP(w, req)
+
+			require.Zero(t, logger.EntryCount())
+			require.Equal(t, c.raw, w.Header().Get("X-Trace-Id"))
+		})
+	}
+}
+
+func TestPropagateTraceHeaders(t *testing.T) {
+	header := http.Header{}
+	PropagateTraceHeaders(context.Background(), header)
+	require.Empty(t, header)
+
+	ctx := testutil.NewTestContext()
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	TraceabilityMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	header = http.Header{"Requestid": {"explicit"}}
+	PropagateTraceHeaders(ctx, header)
+	require.Equal(t, "explicit", header.Get("RequestID"))
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", header.Get("traceparent"))
+
+	header = http.Header{}
+	PropagateTraceHeaders(AddTraceIDStringToContext(context.Background(), "abc", true), header)
+	require.Equal(t, http.Header{"Requestid": {"abc"}}, header)
+}
@@ -137,7 +137,7 @@ func makeDefaultDecodeHook() mapstructure.DecodeHookFunc {
 			case "error":
 				return log.ErrorLevel, nil
 			case "warn":
-				return deprecated(str, log.InfoLevel)
+				return log.WarnLevel, nil
 			case "info":
 				return log.InfoLevel, nil
 			case "debug":
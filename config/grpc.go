@@ -28,6 +28,9 @@ type CommonGRPCDownstreamData struct {
 	ServiceAddress string     `yaml:"serviceAddress" mapstructure:"serviceAddress"`
 	TLS            *TLSConfig `yaml:"tls" mapstructure:"tls"`
 	WithBlock      bool       `yaml:"withBlock" mapstructure:"withBlock"`
+
+	// HealthCheck configures the check of the connection reported by the health endpoints.
+	HealthCheck DependencyCheckConfig `yaml:"healthCheck" mapstructure:"healthCheck"`
 }
 
 func NewDefaultCommonGRPCDownstreamData() *CommonGRPCDownstreamData {
@@ -35,6 +35,24 @@ type CommonDownstreamData struct {
 	ClientTransport Transport           `yaml:"clientTransport" mapstructure:"clientTransport"`
 	ClientTimeout   time.Duration       `yaml:"clientTimeout" mapstructure:"clientTimeout" validate:"timeout=1ms:60s"`
 	Headers         map[string][]string `yaml:"headers" mapstructure:"headers"`
+
+	// HealthCheck configures the check of the service reported by the health endpoints.
+	HealthCheck DependencyCheckConfig `yaml:"healthCheck" mapstructure:"healthCheck"`
+}
+
+// DependencyCheckConfig configures the health check of a downstream dependency.
+type DependencyCheckConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Critical makes the application not ready while the check fails.
+	Critical bool `yaml:"critical" mapstructure:"critical"`
+
+	// Timeout is the time allowed for the check, defaulting to 5s.
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"`
+
+	// Path is the path requested with GET relative to the service URL of an HTTP dependency, which
+	// passes with any status below 400. The check only connects to the service when not set.
+	Path string `yaml:"path" mapstructure:"path"`
 }
 
 // Transport is used to initialise DefaultHTTPTransport.
@@ -91,6 +109,16 @@ type CommonHTTPServerConfig struct {
 type GRPCServerConfig struct {
 	CommonServerConfig `yaml:",inline" mapstructure:",squash"`
 	EnableReflection   bool `yaml:"enableReflection" mapstructure:"enableReflection"`
+
+	// EnableJSONTranscoding exposes the unary methods of the gRPC server as REST/JSON endpoints on
+	// the public HTTP server. Methods annotated with google.api.http are served on the annotated
+	// routes, all others are served on POST /package.Service/Method.
+	EnableJSONTranscoding bool `yaml:"enableJSONTranscoding" mapstructure:"enableJSONTranscoding"`
+
+	// JSONTranscodingMaxBodySize limits the size in bytes of the body of a transcoded request.
+	// Larger requests are rejected with 413 Request Entity Too Large. Defaults to 4 MiB, the
+	// default maximum size of a message received by a gRPC server.
+	JSONTranscodingMaxBodySize int64 `yaml:"jsonTranscodingMaxBodySize" mapstructure:"jsonTranscodingMaxBodySize"`
 }
 
 func (c *CommonHTTPServerConfig) Validate() error {
@@ -6,6 +6,7 @@ import (
 	"github.com/anz-bank/sysl-go/log"
 
 	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/metrics"
 	"github.com/anz-bank/sysl-go/validator"
 )
 
@@ -16,11 +17,60 @@ type LibraryConfig struct {
 	Health         bool                  `yaml:"health" mapstructure:"health"`
 	Authentication *AuthenticationConfig `yaml:"authentication" mapstructure:"authentication"`
 	Trace          TraceConfig           `yaml:"trace" mapstructure:"trace"`
+	Metrics        metrics.Config        `yaml:"metrics" mapstructure:"metrics"`
 }
 
 type AdminConfig struct {
 	ContextTimeout time.Duration          `yaml:"contextTimeout" mapstructure:"contextTimeout" validate:"nonnil"`
 	HTTP           CommonHTTPServerConfig `yaml:"http" mapstructure:"http"`
+
+	// Auth protects the routes of the admin server, which are unprotected when not set.
+	Auth *AdminAuthConfig `yaml:"auth" mapstructure:"auth"`
+}
+
+// AdminAuthConfig struct.
+type AdminAuthConfig struct {
+	// Default is the policy of the admin routes not matched by any of the Routes.
+	Default AdminAuthPolicy `yaml:"default" mapstructure:"default"`
+
+	// Routes are the policies of specific admin routes, the first route matching a request applies.
+	Routes []AdminRoutePolicy `yaml:"routes" mapstructure:"routes" validate:"dive"`
+}
+
+// AdminRoutePolicy struct.
+type AdminRoutePolicy struct {
+	// Path is the pattern of the paths the policy applies to, relative to the base path of the admin
+	// server, for example /-/metrics or /-/heap (the profiling handlers are served directly under /-,
+	// see library.profiling). Patterns use the syntax of path.Match and are matched against the
+	// cleaned path of the request, without any trailing slash.
+	Path string `yaml:"path" mapstructure:"path" validate:"startswith=/"`
+
+	AdminAuthPolicy `yaml:",inline" mapstructure:",squash"`
+}
+
+// AdminAuthPolicy is the policy a request to an admin route must satisfy. Requests are granted access
+// when the policy is open or when they satisfy any of the configured mechanisms, and are otherwise denied.
+type AdminAuthPolicy struct {
+	// Open grants access to all requests.
+	Open bool `yaml:"open" mapstructure:"open"`
+
+	// ClientCert grants access to requests presenting a verified client certificate. It requires the
+	// admin server TLS configuration to request client certificates (see clientAuth).
+	ClientCert *AdminClientCertConfig `yaml:"clientCert" mapstructure:"clientCert"`
+
+	// BearerTokens grants access to requests with any of the given static bearer tokens.
+	BearerTokens []SensitiveString `yaml:"bearerTokens" mapstructure:"bearerTokens"`
+
+	// AuthorizationRule grants access to requests with a JWT satisfying the given authorization rule
+	// expression, validated with library.authentication.jwtauth.
+	AuthorizationRule string `yaml:"authorizationRule" mapstructure:"authorizationRule"`
+}
+
+// AdminClientCertConfig struct.
+type AdminClientCertConfig struct {
+	// AllowedSubjects are the common names or DNS names of the client certificates granted access,
+	// all verified client certificates are granted access when not set.
+	AllowedSubjects []string `yaml:"allowedSubjects" mapstructure:"allowedSubjects"`
 }
 
 // LogConfig struct.
@@ -31,16 +81,131 @@ type LogConfig struct {
 
 	// LogPayload logs the contents of request and response objects.
 	LogPayload bool `yaml:"logPayload" mapstructure:"logPayload"`
+
+	// Payload configures the redaction of the payloads logged when LogPayload is set.
+	Payload PayloadLogConfig `yaml:"payload" mapstructure:"payload"`
+
+	// LevelOverride configures the per-request override of the log level through a request header.
+	LevelOverride LogLevelOverrideConfig `yaml:"levelOverride" mapstructure:"levelOverride"`
+
+	// Sampling configures the sampling and rate limiting of log messages.
+	Sampling log.SamplingConfig `yaml:"sampling" mapstructure:"sampling"`
+
+	// AccessLog configures the logging of a summary of each inbound request.
+	AccessLog AccessLogConfig `yaml:"accessLog" mapstructure:"accessLog"`
+}
+
+// LogLevelOverrideConfig struct.
+type LogLevelOverrideConfig struct {
+	// Header is the request header holding the level of the request, defaulting to X-Log-Level.
+	Header string `yaml:"header" mapstructure:"header"`
+
+	// AuthorizationRule is the authorization rule expression the JWT of a request must satisfy for
+	// the header to apply. Overrides are disabled when not set.
+	AuthorizationRule string `yaml:"authorizationRule" mapstructure:"authorizationRule"`
+}
+
+// AccessLogConfig struct.
+type AccessLogConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// SampleRatio is the fraction of requests logged, defaulting to 1. Zero is treated as unset and
+	// also logs every request. Requests that fail with a server error are always logged.
+	SampleRatio float64 `yaml:"sampleRatio" mapstructure:"sampleRatio" validate:"min=0,max=1"`
+
+	// ExcludePaths are the HTTP paths, route patterns or gRPC methods not logged, for example
+	// /health or /grpc.health.v1.Health/Check. Patterns use the syntax of path.Match.
+	ExcludePaths []string `yaml:"excludePaths" mapstructure:"excludePaths"`
+
+	// SubjectClaim is the JWT claim logged as the subject, defaulting to sub.
+	SubjectClaim string `yaml:"subjectClaim" mapstructure:"subjectClaim"`
+}
+
+// PayloadLogConfig struct.
+type PayloadLogConfig struct {
+	// RedactHeaders are the headers whose values are redacted, defaulting to Authorization, Cookie,
+	// Set-Cookie and X-API-Key when not set. The header of library.authentication.jwtauth.apiKey is
+	// always redacted.
+	RedactHeaders []string `yaml:"redactHeaders" mapstructure:"redactHeaders"`
+
+	// RedactJSONPaths are the paths of the JSON body fields whose values are redacted, for example
+	// $.card.number or $.accounts[*].id.
+	RedactJSONPaths []string `yaml:"redactJSONPaths" mapstructure:"redactJSONPaths"`
+
+	// MaxBodySize is the number of bytes of a body logged before it is truncated, unlimited when zero.
+	MaxBodySize int `yaml:"maxBodySize" mapstructure:"maxBodySize" validate:"min=0"`
+
+	// ContentTypes are the media types of the bodies logged (for example application/json or text/*),
+	// all bodies are logged when not set.
+	ContentTypes []string `yaml:"contentTypes" mapstructure:"contentTypes"`
 }
 
 // AuthenticationConfig struct.
 type AuthenticationConfig struct {
 	JWTAuth *jwtauth.Config `yaml:"jwtauth" mapstructure:"jwtauth"`
+
+	// Audit configures the logging of the decisions of the authorization rules.
+	Audit AuthorizationAuditConfig `yaml:"audit" mapstructure:"audit"`
+
+	// RuleFile loads authorization rules of the endpoints from a file, at startup and on reload.
+	// It requires admin.auth when the admin server is enabled, to protect the reload endpoint.
+	RuleFile *AuthorizationRuleFileConfig `yaml:"ruleFile" mapstructure:"ruleFile"`
+}
+
+// AuthorizationRuleFileConfig struct.
+type AuthorizationRuleFileConfig struct {
+	// Path is the YAML file mapping endpoint names to authorization rule expressions. The file can only
+	// replace or extend the rules of endpoints with an @authorization_rule annotation in the
+	// specification: endpoints without one are generated without any authorization rule to resolve,
+	// so the server fails to start when the file references them.
+	Path string `yaml:"path" mapstructure:"path" validate:"required"`
+
+	// Mode is override (the default) to replace the rule of the specification with the rule of the
+	// file, or extend to require both rules to grant access.
+	Mode string `yaml:"mode" mapstructure:"mode" validate:"omitempty,oneof=override extend"`
+}
+
+// AuthorizationAuditConfig struct.
+type AuthorizationAuditConfig struct {
+	// Enabled logs an entry at the info level for each authorization decision. The entries are
+	// neither sampled nor filtered by the log level.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// OnlyFailures restricts the entries to the requests that are denied access or fail authorization.
+	OnlyFailures bool `yaml:"onlyFailures" mapstructure:"onlyFailures"`
 }
 
 // TraceConfig struct.
 type TraceConfig struct {
 	IncomingHeaderForID string `yaml:"incomingHeaderForID" mapstructure:"incomingHeaderForID"`
+
+	// ResponseHeaderForID is the response header the trace ID is echoed in, not set when empty.
+	ResponseHeaderForID string `yaml:"responseHeaderForID" mapstructure:"responseHeaderForID"`
+
+	// OpenTelemetry configures the optional OpenTelemetry tracing.
+	OpenTelemetry OpenTelemetryConfig `yaml:"openTelemetry" mapstructure:"openTelemetry"`
+}
+
+// OpenTelemetryConfig struct.
+type OpenTelemetryConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// ServiceName is recorded against each span, defaulting to the OTEL_SERVICE_NAME environment variable.
+	ServiceName string `yaml:"serviceName" mapstructure:"serviceName"`
+
+	// SampleRatio is the fraction of traces sampled when no sampling decision has been made upstream.
+	SampleRatio float64 `yaml:"sampleRatio" mapstructure:"sampleRatio" validate:"min=0,max=1"`
+
+	Exporters []TraceExporterConfig `yaml:"exporters" mapstructure:"exporters" validate:"dive"`
+}
+
+// TraceExporterConfig struct.
+type TraceExporterConfig struct {
+	// Type is one of otlpgrpc, otlphttp or stdout.
+	Type     string                     `yaml:"type" mapstructure:"type" validate:"oneof=otlpgrpc otlphttp stdout"`
+	Endpoint string                     `yaml:"endpoint" mapstructure:"endpoint"`
+	Insecure bool                       `yaml:"insecure" mapstructure:"insecure"`
+	Headers  map[string]SensitiveString `yaml:"headers" mapstructure:"headers"`
 }
 
 func (c *LibraryConfig) Validate() error {
@@ -55,4 +220,6 @@ func (c *LibraryConfig) Validate() error {
 func SetLibraryConfigDefaults(prefix string, set func(key string, value interface{})) {
 	set(prefix+"Log.Format", "text")
 	set(prefix+"Log.Level", log.InfoLevel)
+	set(prefix+"Trace.OpenTelemetry.SampleRatio", 1.0)
+	set(prefix+"Log.AccessLog.SampleRatio", 1.0)
 }
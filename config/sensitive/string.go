@@ -0,0 +1,61 @@
+// Package sensitive holds values that must not be revealed when printed or marshalled.
+//
+// The package has no dependencies on the rest of sysl-go such that configuration of any package can
+// hold sensitive values, see config.SensitiveString.
+package sensitive
+
+import (
+	"encoding/json"
+)
+
+const DefaultReplacementText = "****************"
+
+// String is a string whose value is replaced with DefaultReplacementText when printed or marshalled.
+type String struct {
+	s           string
+	replacement *string
+}
+
+func New(from string) String {
+	r := DefaultReplacementText
+	return String{from, &r}
+}
+
+func (s String) String() string {
+	if s.replacement == nil {
+		r := DefaultReplacementText
+		s.replacement = &r
+	}
+	return *s.replacement
+}
+
+func (s *String) Value() string {
+	return s.s
+}
+
+func (s *String) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var val string
+	if err := unmarshal(&val); err != nil {
+		return err
+	}
+	s.s = val
+	return nil
+}
+
+// Note, this one needs to be an object receiver NOT a pointer receiver.
+func (s String) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+func (s *String) UnmarshalJSON(data []byte) error {
+	var val string
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+	s.s = val
+	return nil
+}
+
+func (s *String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
@@ -4,6 +4,9 @@ type CommonTemporalDownstreamData struct {
 	HostPort  string `yaml:"hostPort" mapstructure:"hostPort"`
 	Identity  string `yaml:"identity" mapstructure:"identity"`
 	Namespace string `yaml:"namespace" mapstructure:"namespace"`
+
+	// HealthCheck configures the check of the Temporal server reported by the health endpoints.
+	HealthCheck DependencyCheckConfig `yaml:"healthCheck" mapstructure:"healthCheck"`
 }
 
 type TemporalServerConfig struct {
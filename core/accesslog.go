@@ -0,0 +1,225 @@
+package core
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/log"
+)
+
+const defaultSubjectClaim = "sub"
+
+// accessLogger logs a summary of each inbound request, as configured by library.log.accessLog.
+type accessLogger struct {
+	cfg          config.AccessLogConfig
+	subjectClaim string
+}
+
+// newAccessLogger returns nil when access logging is not enabled.
+func newAccessLogger(ctx context.Context) *accessLogger {
+	cfg := config.GetDefaultConfig(ctx)
+	if cfg == nil || !cfg.Library.Log.AccessLog.Enabled {
+		return nil
+	}
+	subjectClaim := cfg.Library.Log.AccessLog.SubjectClaim
+	if subjectClaim == "" {
+		subjectClaim = defaultSubjectClaim
+	}
+	accessLogConfig := cfg.Library.Log.AccessLog
+	if accessLogConfig.SampleRatio == 0 {
+		// Unset, as in configurations built in code rather than loaded with the defaults.
+		accessLogConfig.SampleRatio = 1
+	}
+	return &accessLogger{cfg: accessLogConfig, subjectClaim: subjectClaim}
+}
+
+// excluded returns whether any of the names (a path, route pattern or gRPC method) are excluded.
+func (a *accessLogger) excluded(names ...string) bool {
+	for _, pattern := range a.cfg.ExcludePaths {
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sampled returns whether a request is logged, requests failing with a server error always being logged.
+func (a *accessLogger) sampled(status int) bool {
+	//nolint:gosec // Sampling does not need a secure random number.
+	return status >= http.StatusInternalServerError || rand.Float64() < a.cfg.SampleRatio
+}
+
+func (a *accessLogger) subject(recorder *jwtauth.ClaimsRecorder) string {
+	claims, ok := recorder.Claims()
+	if !ok {
+		return ""
+	}
+	subject, _ := claims[a.subjectClaim].(string)
+	return subject
+}
+
+// middleware returns the HTTP middleware that logs the requests. It must be installed after
+// common.CoreRequestContextMiddleware so that the logs include the trace ID.
+func (a *accessLogger) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, recorder := jwtauth.WithClaimsRecorder(r.Context())
+		body := &countingBody{ReadCloser: r.Body}
+		if r.Body != nil {
+			r.Body = body
+		}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		route := ""
+		if rctx := chi.RouteContext(ctx); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if a.excluded(r.URL.Path, route) || !a.sampled(status) {
+			return
+		}
+		bytesIn := int64(body.n)
+		if r.ContentLength > bytesIn {
+			bytesIn = r.ContentLength
+		}
+
+		ctx = log.WithFields(r.Context(), map[string]interface{}{
+			"method":     r.Method,
+			"route":      route,
+			"status":     status,
+			"latency":    time.Since(start),
+			"bytes_in":   bytesIn,
+			"bytes_out":  ww.BytesWritten(),
+			"remote":     r.RemoteAddr,
+			"user_agent": r.UserAgent(),
+			"subject":    a.subject(recorder),
+		})
+		log.Info(ctx, "HTTP request handled")
+	})
+}
+
+// serverOptions returns the gRPC server options that log the requests. The logger of the context
+// is used when none has been put into the context of the request.
+func (a *accessLogger) serverOptions(ctx context.Context) []grpc.ServerOption {
+	logger := log.GetLogger(ctx)
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.unaryInterceptor(logger)),
+		grpc.ChainStreamInterceptor(a.streamInterceptor(logger)),
+	}
+}
+
+func (a *accessLogger) unaryInterceptor(logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, recorder := jwtauth.WithClaimsRecorder(ctx)
+		resp, err := handler(ctx, req)
+		a.logGRPC(ctx, logger, info.FullMethod, start, err, protoSize(req), protoSize(resp), recorder)
+		return resp, err
+	}
+}
+
+func (a *accessLogger) streamInterceptor(logger log.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, recorder := jwtauth.WithClaimsRecorder(ss.Context())
+		cs := &countingServerStream{ServerStream: ss, ctx: ctx}
+		err := handler(srv, cs)
+		a.logGRPC(ctx, logger, info.FullMethod, start, err, cs.in, cs.out, recorder)
+		return err
+	}
+}
+
+func (a *accessLogger) logGRPC(ctx context.Context, logger log.Logger, method string, start time.Time, err error, bytesIn, bytesOut int, recorder *jwtauth.ClaimsRecorder) {
+	code := status.Code(err)
+	if a.excluded(method) || !a.sampled(httpStatusFromGrpcCode(code)) {
+		return
+	}
+	if log.GetLogger(ctx) == nil {
+		ctx = log.PutLogger(ctx, logger)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := http.Header{}
+	for k, v := range md {
+		header[http.CanonicalHeaderKey(k)] = v
+	}
+	remote := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remote = p.Addr.String()
+	}
+	ctx = log.WithFields(ctx, map[string]interface{}{
+		"method":     method,
+		"code":       code.String(),
+		"latency":    time.Since(start),
+		"bytes_in":   bytesIn,
+		"bytes_out":  bytesOut,
+		"remote":     remote,
+		"user_agent": header.Get("User-Agent"),
+		"traceid":    common.TraceIDFromHeader(ctx, header),
+		"subject":    a.subject(recorder),
+	})
+	log.Info(ctx, "gRPC request handled")
+}
+
+func protoSize(m interface{}) int {
+	if msg, ok := m.(proto.Message); ok {
+		return proto.Size(msg)
+	}
+	return 0
+}
+
+type countingBody struct {
+	io.ReadCloser
+	n int
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += n
+	return n, err
+}
+
+type countingServerStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	in, out int
+}
+
+func (s *countingServerStream) Context() context.Context { return s.ctx }
+
+func (s *countingServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.in += protoSize(m)
+	}
+	return err
+}
+
+func (s *countingServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.out += protoSize(m)
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+func newTestAccessLogger(t *testing.T, cfg config.AccessLogConfig) (context.Context, *testutil.TestLogger, *accessLogger) {
+	t.Helper()
+	cfg.Enabled = true
+	defaultConfig := &config.DefaultConfig{}
+	defaultConfig.Library.Log.AccessLog = cfg
+	ctx, logger := testutil.NewTestContextWithLogger(testutil.WithConfig(defaultConfig))
+	a := newAccessLogger(ctx)
+	require.NotNil(t, a)
+	return ctx, logger, a
+}
+
+func TestAccessLogger_Disabled(t *testing.T) {
+	require.Nil(t, newAccessLogger(testutil.NewTestContext()))
+}
+
+func TestAccessLogger_HTTP(t *testing.T) {
+	ctx, logger, a := newTestAccessLogger(t, config.AccessLogConfig{SampleRatio: 1, ExcludePaths: []string{"/-/*"}})
+
+	r := chi.NewRouter()
+	r.Use(prepareMiddleware("test", nil, nil, nil, a, nil, defaultContextTimeout).public...)
+	r.Post("/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_ = jwtauth.AddClaimsToContext(r.Context(), jwtauth.Claims{"sub": "alice"})
+		_, _ = w.Write([]byte("hello"))
+	})
+	r.Get("/-/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodPost, "/accounts/1", strings.NewReader("body")).WithContext(ctx)
+	req.Header.Set("RequestID", "abc")
+	req.Header.Set("User-Agent", "test-agent")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/-/health", nil).WithContext(ctx))
+
+	var entries []testutil.TestLogEntry
+	for _, e := range logger.Entries() {
+		if e.Message == "HTTP request handled" {
+			entries = append(entries, e)
+		}
+	}
+	require.Len(t, entries, 1)
+	fields := entries[0].Fields
+	require.Equal(t, "POST", fields["method"])
+	require.Equal(t, "/accounts/{id}", fields["route"])
+	require.Equal(t, http.StatusOK, fields["status"])
+	require.Equal(t, int64(4), fields["bytes_in"])
+	require.Equal(t, 5, fields["bytes_out"])
+	require.Equal(t, "test-agent", fields["user_agent"])
+	require.Equal(t, "abc", fields["traceid"])
+	require.Equal(t, "alice", fields["subject"])
+	require.Contains(t, fields, "latency")
+}
+
+func TestAccessLogger_Sampling(t *testing.T) {
+	ctx, logger, a := newTestAccessLogger(t, config.AccessLogConfig{SampleRatio: math.SmallestNonzeroFloat64})
+
+	handler := a.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil).WithContext(ctx))
+	require.Zero(t, logger.EntryCount())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil).WithContext(ctx))
+	require.Equal(t, 1, logger.EntryCount())
+	require.Equal(t, http.StatusBadGateway, logger.LastEntry().Fields["status"])
+}
+
+func TestAccessLogger_SampleRatioUnset(t *testing.T) {
+	ctx, logger, a := newTestAccessLogger(t, config.AccessLogConfig{})
+	a.middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil).WithContext(ctx))
+	require.Equal(t, 1, logger.EntryCount())
+}
+
+func TestAccessLogger_GRPC(t *testing.T) {
+	_, logger, a := newTestAccessLogger(t, config.AccessLogConfig{SampleRatio: 1, SubjectClaim: "client_id", ExcludePaths: []string{"/grpc.health.v1.Health/*"}})
+	interceptor := a.unaryInterceptor(logger)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-agent", "grpc-go", "requestid", "abc"))
+	_, err := interceptor(ctx, wrapperspb.String("hello"), &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Method"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			_ = jwtauth.AddClaimsToContext(ctx, jwtauth.Claims{"client_id": "svc"})
+			return nil, status.Error(codes.NotFound, "missing")
+		})
+	require.Error(t, err)
+	_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+
+	require.Equal(t, 1, logger.EntryCount())
+	fields := logger.LastEntry().Fields
+	require.Equal(t, "gRPC request handled", logger.LastEntry().Message)
+	require.Equal(t, "/pkg.Service/Method", fields["method"])
+	require.Equal(t, "NotFound", fields["code"])
+	require.Equal(t, 7, fields["bytes_in"])
+	require.Equal(t, 0, fields["bytes_out"])
+	require.Equal(t, "grpc-go", fields["user_agent"])
+	require.Equal(t, "abc", fields["traceid"])
+	require.Equal(t, "svc", fields["subject"])
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/core/authrules"
+	"github.com/anz-bank/sysl-go/log"
+)
+
+// adminAuthPolicy is the compiled form of a config.AdminAuthPolicy.
+type adminAuthPolicy struct {
+	pattern         string
+	open            bool
+	clientCert      bool
+	allowedSubjects map[string]bool
+	bearerTokens    [][]byte
+	rule            authrules.Rule
+}
+
+// newAdminAuthMiddleware returns the middleware enforcing the admin.auth configuration on the routes
+// of the admin server with the given base path. Returns nil when no admin.auth has been configured.
+func newAdminAuthMiddleware(ctx context.Context, hooks *Hooks, basePath string) (func(http.Handler) http.Handler, error) {
+	cfg := config.GetDefaultConfig(ctx)
+	if cfg == nil || cfg.Admin == nil || cfg.Admin.Auth == nil {
+		return nil, nil
+	}
+	if hooks == nil {
+		hooks = &Hooks{}
+	}
+
+	policies := make([]*adminAuthPolicy, 0, len(cfg.Admin.Auth.Routes)+1)
+	for _, route := range cfg.Admin.Auth.Routes {
+		if _, err := path.Match(route.Path, ""); err != nil {
+			return nil, fmt.Errorf("invalid admin.auth route path %s: %w", route.Path, err)
+		}
+		p, err := compileAdminAuthPolicy(ctx, hooks, route.Path, route.AdminAuthPolicy)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	defaultPolicy, err := compileAdminAuthPolicy(ctx, hooks, "", cfg.Admin.Auth.Default)
+	if err != nil {
+		return nil, err
+	}
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := defaultPolicy
+			routePath := adminRoutePath(r.URL.Path, basePath)
+			for _, policy := range policies {
+				if ok, _ := path.Match(policy.pattern, routePath); ok {
+					p = policy
+					break
+				}
+			}
+			if status, err := p.authorize(r); err != nil {
+				log.Debugf(r.Context(), "admin request to %s denied: %v", r.URL.Path, err)
+				if status == http.StatusUnauthorized {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+				}
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// adminRoutePath returns the path of the request relative to the base path, cleaned of empty and
+// dot segments and of any trailing slash, so that all the spellings of a path match the same policy.
+func adminRoutePath(requestPath, basePath string) string {
+	routePath := strings.TrimPrefix(path.Clean("/"+requestPath), basePath)
+	if routePath == "" {
+		return "/"
+	}
+	return path.Clean("/" + routePath)
+}
+
+func compileAdminAuthPolicy(ctx context.Context, hooks *Hooks, pattern string, policy config.AdminAuthPolicy) (*adminAuthPolicy, error) {
+	p := &adminAuthPolicy{pattern: pattern, open: policy.Open}
+	if policy.ClientCert != nil {
+		p.clientCert = true
+		if len(policy.ClientCert.AllowedSubjects) > 0 {
+			p.allowedSubjects = make(map[string]bool, len(policy.ClientCert.AllowedSubjects))
+			for _, subject := range policy.ClientCert.AllowedSubjects {
+				p.allowedSubjects[subject] = true
+			}
+		}
+	}
+	for i := range policy.BearerTokens {
+		token := policy.BearerTokens[i].Value()
+		if token == "" {
+			return nil, fmt.Errorf("empty bearer token in admin.auth policy %s", pattern)
+		}
+		p.bearerTokens = append(p.bearerTokens, []byte(token))
+	}
+	if policy.AuthorizationRule != "" {
+		name := "admin route " + pattern
+		if pattern == "" {
+			name = "admin routes"
+		}
+		rule, err := resolveAuthorizationRule(ctx, hooks, name, policy.AuthorizationRule, authrules.MakeRESTJWTAuthorizationRule)
+		if err != nil {
+			return nil, err
+		}
+		p.rule = rule
+	}
+	return p, nil
+}
+
+// authorize returns nil when the request satisfies the policy, otherwise the error and the status
+// of the response: unauthorized when the request holds no credentials, forbidden otherwise.
+func (p *adminAuthPolicy) authorize(r *http.Request) (int, error) {
+	if p.open {
+		return 0, nil
+	}
+	status := http.StatusUnauthorized
+	var errs []string
+
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		status = http.StatusForbidden
+		if p.clientCert {
+			cert := r.TLS.VerifiedChains[0][0]
+			if p.allowedSubjects == nil || subjectAllowed(p.allowedSubjects, cert) {
+				return 0, nil
+			}
+			errs = append(errs, fmt.Sprintf("client certificate %s not allowed", cert.Subject.CommonName))
+		}
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		errs = append(errs, "no bearer token")
+		return status, fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	status = http.StatusForbidden
+	if token := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer ")); token != authorization {
+		for _, t := range p.bearerTokens {
+			if subtle.ConstantTimeCompare([]byte(token), t) == 1 {
+				return 0, nil
+			}
+		}
+	}
+	if p.rule != nil {
+		_, err := p.rule(common.RequestHeaderToContext(r.Context(), r.Header))
+		if err == nil {
+			return 0, nil
+		}
+		errs = append(errs, err.Error())
+	} else {
+		errs = append(errs, "bearer token not allowed")
+	}
+	return status, fmt.Errorf("%s", strings.Join(errs, ", "))
+}
+
+// subjectAllowed returns whether the common name or any of the DNS names of the certificate is allowed.
+func subjectAllowed(allowed map[string]bool, cert *x509.Certificate) bool {
+	if allowed[cert.Subject.CommonName] {
+		return true
+	}
+	for _, name := range cert.DNSNames {
+		if allowed[name] {
+			return true
+		}
+	}
+	return false
+}
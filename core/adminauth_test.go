@@ -0,0 +1,132 @@
+package core
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	ctx := testutil.NewTestContext()
+	cfg := &config.DefaultConfig{Admin: &config.AdminConfig{}}
+	ctx = config.PutDefaultConfig(ctx, cfg)
+
+	m, err := newAdminAuthMiddleware(ctx, nil, "/admin")
+	require.NoError(t, err)
+	require.Nil(t, m)
+
+	cfg.Admin.Auth = &config.AdminAuthConfig{
+		Default: config.AdminAuthPolicy{
+			ClientCert:   &config.AdminClientCertConfig{AllowedSubjects: []string{"ops", "ops.example.com"}},
+			BearerTokens: []config.SensitiveString{config.NewSensitiveString("secret")},
+		},
+		Routes: []config.AdminRoutePolicy{
+			{Path: "/-/metrics", AdminAuthPolicy: config.AdminAuthPolicy{Open: true}},
+			{Path: "/-/heap", AdminAuthPolicy: config.AdminAuthPolicy{
+				ClientCert: &config.AdminClientCertConfig{},
+			}},
+		},
+	}
+	m, err = newAdminAuthMiddleware(ctx, nil, "/admin")
+	require.NoError(t, err)
+	handler := m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	withCert := func(r *http.Request, cert *x509.Certificate) *http.Request {
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		return r
+	}
+	withToken := func(r *http.Request, token string) *http.Request {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+	get := func(path string) *http.Request {
+		return httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+	}
+
+	for name, tt := range map[string]struct {
+		req    *http.Request
+		status int
+	}{
+		"open route":               {get("/admin/-/metrics"), http.StatusOK},
+		"no credentials":           {get("/admin/-/status"), http.StatusUnauthorized},
+		"valid token":              {withToken(get("/admin/-/status"), "secret"), http.StatusOK},
+		"invalid token":            {withToken(get("/admin/-/status"), "guess"), http.StatusForbidden},
+		"allowed common name":      {withCert(get("/admin/-/status"), &x509.Certificate{Subject: pkix.Name{CommonName: "ops"}}), http.StatusOK},
+		"allowed dns name":         {withCert(get("/admin/-/status"), &x509.Certificate{DNSNames: []string{"ops.example.com"}}), http.StatusOK},
+		"disallowed subject":       {withCert(get("/admin/-/status"), &x509.Certificate{Subject: pkix.Name{CommonName: "dev"}}), http.StatusForbidden},
+		"route any verified cert":  {withCert(get("/admin/-/heap"), &x509.Certificate{Subject: pkix.Name{CommonName: "dev"}}), http.StatusOK},
+		"route token not accepted": {withToken(get("/admin/-/heap"), "secret"), http.StatusForbidden},
+		"trailing slash":           {withToken(get("/admin/-/heap/"), "secret"), http.StatusForbidden},
+		"dot segment":              {withToken(get("/admin/-/./heap"), "secret"), http.StatusForbidden},
+		"empty segment":            {withToken(get("/admin//-/heap"), "secret"), http.StatusForbidden},
+		"dot dot segment":          {withToken(get("/admin/-/status/../heap"), "secret"), http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.req)
+			require.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusUnauthorized {
+				require.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAdminAuthMiddlewareProfiling(t *testing.T) {
+	ctx := testutil.NewTestContext()
+	ctx = config.PutDefaultConfig(ctx, &config.DefaultConfig{Admin: &config.AdminConfig{Auth: &config.AdminAuthConfig{
+		Default: config.AdminAuthPolicy{Open: true},
+		Routes: []config.AdminRoutePolicy{
+			{Path: "/-/heap", AdminAuthPolicy: config.AdminAuthPolicy{
+				BearerTokens: []config.SensitiveString{config.NewSensitiveString("secret")},
+			}},
+		},
+	}}})
+	m, err := newAdminAuthMiddleware(ctx, nil, "/admin")
+	require.NoError(t, err)
+
+	rootRouter, router := configureRouters("/admin", []func(http.Handler) http.Handler{m})
+	router.Route("/-", func(r chi.Router) {
+		registerProfilingHandler(ctx, &config.LibraryConfig{Profiling: true}, r)
+	})
+
+	for _, p := range []string{"/admin/-/heap", "/admin/-/heap/", "/admin/-/./heap", "/admin//-/heap"} {
+		w := httptest.NewRecorder()
+		rootRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil).WithContext(ctx))
+		require.Equal(t, http.StatusUnauthorized, w.Code, p)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/-/heap", nil).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	rootRouter.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	rootRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/-/goroutine", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAdminAuthMiddlewareInvalidConfig(t *testing.T) {
+	ctx := testutil.NewTestContext()
+	cfg := &config.DefaultConfig{Admin: &config.AdminConfig{Auth: &config.AdminAuthConfig{
+		Routes: []config.AdminRoutePolicy{{Path: "/-/[", AdminAuthPolicy: config.AdminAuthPolicy{Open: true}}},
+	}}}
+	ctx = config.PutDefaultConfig(ctx, cfg)
+	_, err := newAdminAuthMiddleware(ctx, nil, "")
+	require.Error(t, err)
+
+	cfg.Admin.Auth.Routes = nil
+	cfg.Admin.Auth.Default.AuthorizationRule = `jwtHasScope("admin")`
+	_, err = newAdminAuthMiddleware(ctx, nil, "")
+	require.Error(t, err) // no jwtauth configured
+}
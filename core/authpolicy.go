@@ -0,0 +1,212 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-chi/chi"
+	"gopkg.in/yaml.v2"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/core/authrules"
+	"github.com/anz-bank/sysl-go/log"
+)
+
+const (
+	authorizationRuleFileModeOverride = "override"
+	authorizationRuleFileModeExtend   = "extend"
+)
+
+type authorizationPolicyKey struct{}
+
+// authorizationPolicy holds the authorization rules of the endpoints loaded from the file of the
+// library.authentication.ruleFile config, which override or extend the rules of the specification.
+//
+// Endpoints add the rule of their specification to the policy when their authorization rule is
+// resolved. The rules of the endpoints are rebuilt whenever the file is reloaded.
+type authorizationPolicy struct {
+	path string
+	mode string
+
+	mu        sync.Mutex
+	rules     map[string]string
+	endpoints map[string][]*authorizationPolicyEndpoint
+}
+
+// authorizationPolicyEndpoint is an endpoint with an authorization rule, whose rule is swapped when the
+// rule file is reloaded.
+type authorizationPolicyEndpoint struct {
+	expression string
+	build      func(expression string) (authrules.Rule, error)
+	rule       atomic.Pointer[authrules.Rule]
+}
+
+// withAuthorizationPolicy loads the authorization rule file when configured and puts the policy into
+// the context, such that the authorization rules resolved with the returned context apply it.
+// Returns a nil policy when there is no rule file.
+func withAuthorizationPolicy(ctx context.Context) (context.Context, *authorizationPolicy, error) {
+	cfg := config.GetDefaultConfig(ctx)
+	if cfg == nil || cfg.Library.Authentication == nil || cfg.Library.Authentication.RuleFile == nil {
+		return ctx, nil, nil
+	}
+	ruleFile := cfg.Library.Authentication.RuleFile
+	mode := ruleFile.Mode
+	switch mode {
+	case "":
+		mode = authorizationRuleFileModeOverride
+	case authorizationRuleFileModeOverride, authorizationRuleFileModeExtend:
+	default:
+		return nil, nil, fmt.Errorf("library.authentication.ruleFile.mode must be %s or %s, got %q",
+			authorizationRuleFileModeOverride, authorizationRuleFileModeExtend, mode)
+	}
+	p := &authorizationPolicy{
+		path:      ruleFile.Path,
+		mode:      mode,
+		endpoints: map[string][]*authorizationPolicyEndpoint{},
+	}
+	rules, err := p.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	p.rules = rules
+	log.Infof(ctx, "loaded %d authorization rules from %s (mode: %s)", len(rules), p.path, p.mode)
+	return context.WithValue(ctx, authorizationPolicyKey{}, p), p, nil
+}
+
+func getAuthorizationPolicy(ctx context.Context) *authorizationPolicy {
+	p, _ := ctx.Value(authorizationPolicyKey{}).(*authorizationPolicy)
+	return p
+}
+
+// load reads the rule file, a YAML map of endpoint names to authorization rule expressions.
+func (p *authorizationPolicy) load() (map[string]string, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authorization rule file: %w", err)
+	}
+	rules := map[string]string{}
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse authorization rule file %s: %w", p.path, err)
+	}
+	for endpoint, expression := range rules {
+		if strings.TrimSpace(expression) == "" {
+			return nil, fmt.Errorf("authorization rule file %s has an empty rule for endpoint %s", p.path, endpoint)
+		}
+	}
+	return rules, nil
+}
+
+// expression returns the effective authorization rule expression of the endpoint with the given name
+// and rule expression in the specification.
+func (p *authorizationPolicy) expression(rules map[string]string, endpointName, expression string) string {
+	rule, ok := rules[endpointName]
+	switch {
+	case !ok:
+		return expression
+	case p.mode == authorizationRuleFileModeExtend:
+		return fmt.Sprintf("all(%s, %s)", expression, rule)
+	default:
+		return rule
+	}
+}
+
+// addEndpoint adds the endpoint with the given name and rule expression in the specification to the
+// policy, returning a rule that delegates to the rule of the endpoint at the time of each request.
+func (p *authorizationPolicy) addEndpoint(endpointName, expression string, build func(expression string) (authrules.Rule, error)) (authrules.Rule, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := &authorizationPolicyEndpoint{expression: expression, build: build}
+	rule, err := build(p.expression(p.rules, endpointName, expression))
+	if err != nil {
+		return nil, fmt.Errorf("authorization rule of endpoint %s: %w", endpointName, err)
+	}
+	e.rule.Store(&rule)
+	p.endpoints[endpointName] = append(p.endpoints[endpointName], e)
+	return func(ctx context.Context) (context.Context, error) {
+		return (*e.rule.Load())(ctx)
+	}, nil
+}
+
+// validate returns an error when the given rules reference endpoints that have not been added.
+func (p *authorizationPolicy) validate(rules map[string]string) error {
+	var unknown []string
+	for endpoint := range rules {
+		if _, ok := p.endpoints[endpoint]; !ok {
+			unknown = append(unknown, endpoint)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("authorization rule file %s references unknown endpoints or endpoints without an authorization rule: %s",
+			p.path, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// validateEndpoints checks that every endpoint referenced by the rule file has been added.
+func (p *authorizationPolicy) validateEndpoints() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.validate(p.rules)
+}
+
+// reloadRules reads the rule file and rebuilds the rules of the endpoints. The rules are left
+// unchanged when the file cannot be read, references unknown endpoints or holds an invalid rule.
+func (p *authorizationPolicy) reloadRules(ctx context.Context) error {
+	rules, err := p.load()
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.validate(rules); err != nil {
+		return err
+	}
+	built := map[*authorizationPolicyEndpoint]authrules.Rule{}
+	for name, endpoints := range p.endpoints {
+		for _, e := range endpoints {
+			rule, err := e.build(p.expression(rules, name, e.expression))
+			if err != nil {
+				return fmt.Errorf("authorization rule of endpoint %s: %w", name, err)
+			}
+			built[e] = rule
+		}
+	}
+	for e, rule := range built {
+		rule := rule
+		e.rule.Store(&rule)
+	}
+	p.rules = rules
+	log.Infof(ctx, "reloaded %d authorization rules from %s (mode: %s)", len(rules), p.path, p.mode)
+	return nil
+}
+
+type authorizationReloadResponse struct {
+	Path  string `json:"path"`
+	Mode  string `json:"mode"`
+	Rules int    `json:"rules"`
+}
+
+func (p *authorizationPolicy) register(r chi.Router) {
+	r.Post("/authorization/reload", p.reload)
+}
+
+func (p *authorizationPolicy) reload(w http.ResponseWriter, r *http.Request) {
+	if err := p.reloadRules(r.Context()); err != nil {
+		log.Error(r.Context(), err, "failed to reload authorization rules")
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	p.mu.Lock()
+	resp := authorizationReloadResponse{Path: p.path, Mode: p.mode, Rules: len(p.rules)}
+	p.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
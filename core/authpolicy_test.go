@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/core/authrules"
+	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+// scopeAuthenticator authenticates every token, with the token as the scope claim.
+type scopeAuthenticator struct{}
+
+func (scopeAuthenticator) Authenticate(_ context.Context, token string) (jwtauth.Claims, error) {
+	return jwtauth.Claims{"scope": token}, nil
+}
+
+var scopeAuthenticatorHooks = &Hooks{
+	JWTAuthenticator: func(ctx context.Context) (jwtauth.Authenticator, error) {
+		return scopeAuthenticator{}, nil
+	},
+}
+
+func writeRuleFile(t *testing.T, path, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func authorizationPolicyContext(ruleFile config.AuthorizationRuleFileConfig) (context.Context, *authorizationPolicy, error) {
+	cfg := &config.DefaultConfig{}
+	cfg.Library.Authentication = &config.AuthenticationConfig{RuleFile: &ruleFile}
+	return withAuthorizationPolicy(config.PutDefaultConfig(testutil.NewTestContext(), cfg))
+}
+
+func requireAuthorized(t *testing.T, ctx context.Context, rule authrules.Rule, scope string, authorized bool) {
+	_, err := rule(common.RequestHeaderToContext(ctx, http.Header{"Authorization": {"Bearer " + scope}}))
+	if authorized {
+		require.NoError(t, err, "scope %q", scope)
+	} else {
+		require.Error(t, err, "scope %q", scope)
+	}
+}
+
+func TestAuthorizationPolicyOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeRuleFile(t, path, `GetA: jwtHasScope("admin")`)
+	ctx, policy, err := authorizationPolicyContext(config.AuthorizationRuleFileConfig{Path: path})
+	require.NoError(t, err)
+
+	ruleA, err := ResolveRESTAuthorizationRule(ctx, scopeAuthenticatorHooks, "GetA", `jwtHasScope("read")`)
+	require.NoError(t, err)
+	ruleB, err := ResolveGRPCAuthorizationRule(ctx, scopeAuthenticatorHooks, "GetB", `jwtHasScope("write")`)
+	require.NoError(t, err)
+	require.NoError(t, policy.validateEndpoints())
+
+	requireAuthorized(t, ctx, ruleA, "admin", true)
+	requireAuthorized(t, ctx, ruleA, "read", false)
+	_, err = ruleB(ctx)
+	require.Error(t, err, "gRPC rules without a bearer token are denied")
+
+	// Reloading swaps the rules of the endpoints already resolved.
+	writeRuleFile(t, path, "GetA: jwtHasScope(\"read\")\nGetB: jwtHasScope(\"admin\")\n")
+	require.NoError(t, policy.reloadRules(ctx))
+	requireAuthorized(t, ctx, ruleA, "read", true)
+	requireAuthorized(t, ctx, ruleA, "admin", false)
+}
+
+func TestAuthorizationPolicyExtend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeRuleFile(t, path, `GetA: jwtHasScope("admin")`)
+	ctx, _, err := authorizationPolicyContext(config.AuthorizationRuleFileConfig{Path: path, Mode: "extend"})
+	require.NoError(t, err)
+
+	rule, err := ResolveRESTAuthorizationRule(ctx, scopeAuthenticatorHooks, "GetA", `jwtHasScope("read")`)
+	require.NoError(t, err)
+	requireAuthorized(t, ctx, rule, "read admin", true)
+	requireAuthorized(t, ctx, rule, "read", false)
+	requireAuthorized(t, ctx, rule, "admin", false)
+}
+
+func TestAuthorizationPolicyUnknownEndpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeRuleFile(t, path, "GetA: jwtHasScope(\"read\")\nGetZ: jwtHasScope(\"z\")\nGetY: jwtHasScope(\"y\")\n")
+	ctx, policy, err := authorizationPolicyContext(config.AuthorizationRuleFileConfig{Path: path})
+	require.NoError(t, err)
+	_, err = ResolveRESTAuthorizationRule(ctx, scopeAuthenticatorHooks, "GetA", `jwtHasScope("read")`)
+	require.NoError(t, err)
+	require.EqualError(t, policy.validateEndpoints(),
+		"authorization rule file "+path+" references unknown endpoints or endpoints without an authorization rule: GetY, GetZ")
+}
+
+func TestAuthorizationPolicyReloadFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeRuleFile(t, path, `GetA: jwtHasScope("admin")`)
+	ctx, policy, err := authorizationPolicyContext(config.AuthorizationRuleFileConfig{Path: path})
+	require.NoError(t, err)
+	rule, err := ResolveRESTAuthorizationRule(ctx, scopeAuthenticatorHooks, "GetA", `jwtHasScope("read")`)
+	require.NoError(t, err)
+
+	for _, content := range []string{
+		`GetA: jwtHasScope(`,
+		`GetA: ""`,
+		`GetB: jwtHasScope("write")`,
+		`- GetA`,
+	} {
+		writeRuleFile(t, path, content)
+		require.Error(t, policy.reloadRules(ctx), content)
+		requireAuthorized(t, ctx, rule, "admin", true)
+	}
+	require.NoError(t, os.Remove(path))
+	require.Error(t, policy.reloadRules(ctx))
+	requireAuthorized(t, ctx, rule, "admin", true)
+}
+
+func TestAuthorizationPolicyConfig(t *testing.T) {
+	ctx, policy, err := withAuthorizationPolicy(config.PutDefaultConfig(testutil.NewTestContext(), &config.DefaultConfig{}))
+	require.NoError(t, err)
+	require.Nil(t, policy)
+	require.Nil(t, getAuthorizationPolicy(ctx))
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeRuleFile(t, path, `GetA: jwtHasScope(`)
+	ctx, _, err = authorizationPolicyContext(config.AuthorizationRuleFileConfig{Path: path})
+	require.NoError(t, err, "rules are compiled when the endpoints are resolved")
+	_, err = ResolveRESTAuthorizationRule(ctx, scopeAuthenticatorHooks, "GetA", `jwtHasScope("read")`)
+	require.Error(t, err)
+
+	_, _, err = authorizationPolicyContext(config.AuthorizationRuleFileConfig{Path: path, Mode: "replace"})
+	require.EqualError(t, err, `library.authentication.ruleFile.mode must be override or extend, got "replace"`)
+	_, _, err = authorizationPolicyContext(config.AuthorizationRuleFileConfig{Path: filepath.Join(t.TempDir(), "missing.yaml")})
+	require.Error(t, err)
+}
+
+func TestAuthorizationPolicyReloadEndpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeRuleFile(t, path, `GetA: jwtHasScope("admin")`)
+	ctx, policy, err := authorizationPolicyContext(config.AuthorizationRuleFileConfig{Path: path})
+	require.NoError(t, err)
+	_, err = ResolveRESTAuthorizationRule(ctx, scopeAuthenticatorHooks, "GetA", `jwtHasScope("read")`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	policy.register(r)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/authorization/reload", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"path":"`+path+`","mode":"override","rules":1}`, w.Body.String())
+
+	writeRuleFile(t, path, `GetB: jwtHasScope("write")`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/authorization/reload", nil).WithContext(ctx))
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Contains(t, w.Body.String(), "GetB")
+}
@@ -0,0 +1,188 @@
+package authrules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/jwtauth/jwtgrpc"
+	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/metrics"
+)
+
+// Authorization decisions of AuditEvents.
+const (
+	DecisionAllow = "allow"
+	DecisionDeny  = "deny"
+	DecisionError = "error"
+)
+
+// Failure reasons of AuditEvents that are not named after a jwtauth.AuthError code.
+const (
+	// ReasonRuleDenied is the reason of requests that were authenticated but did not satisfy the rule.
+	ReasonRuleDenied = "rule_denied"
+
+	// ReasonRuleError is the reason of requests for which the rule could not be evaluated.
+	ReasonRuleError = "rule_error"
+
+	// ReasonUnauthenticated is the reason of gRPC requests without valid credentials in their
+	// authorization metadata, named like the missing or malformed bearer tokens of REST requests.
+	ReasonUnauthenticated = "invalid_jwt"
+)
+
+// AuditEvent describes an authorization decision.
+type AuditEvent struct {
+	// Endpoint is the name of the method or endpoint the rule authorizes.
+	Endpoint string
+
+	// Expression is the authorization rule expression.
+	Expression string
+
+	// Decision is one of DecisionAllow, DecisionDeny or DecisionError.
+	Decision string
+
+	// Reason is the reason of a denial or error: the name of the jwtauth.AuthError code (see
+	// jwtauth.AuthErrCodeName), ReasonRuleDenied or ReasonRuleError. Empty when access is allowed.
+	Reason string
+
+	// Issuer and Subject are the iss and sub claims of the token, empty when the request could not
+	// be authenticated.
+	Issuer  string
+	Subject string
+
+	// TraceID is the trace ID of the request.
+	TraceID string
+}
+
+// AuditSink records authorization decisions.
+type AuditSink interface {
+	RecordAuthorizationDecision(ctx context.Context, event AuditEvent)
+}
+
+// AuditSinks is an AuditSink that records decisions in each of its sinks.
+type AuditSinks []AuditSink
+
+// RecordAuthorizationDecision implements AuditSink.
+func (s AuditSinks) RecordAuthorizationDecision(ctx context.Context, event AuditEvent) {
+	for _, sink := range s {
+		sink.RecordAuthorizationDecision(ctx, event)
+	}
+}
+
+// LogAuditSink is an AuditSink that logs decisions at the info level, one entry per decision.
+//
+// The entries are audit records, so they should be written to a Logger that is neither sampled nor
+// filtered above the info level, or decisions may be dropped.
+type LogAuditSink struct {
+	// OnlyFailures restricts the entries to decisions that do not allow access.
+	OnlyFailures bool
+
+	// Logger is the logger the entries are written to, the logger of the context when nil.
+	Logger log.Logger
+}
+
+// RecordAuthorizationDecision implements AuditSink.
+func (s LogAuditSink) RecordAuthorizationDecision(ctx context.Context, event AuditEvent) {
+	if s.OnlyFailures && event.Decision == DecisionAllow {
+		return
+	}
+	logger := s.Logger
+	if logger == nil {
+		logger = log.GetLogger(ctx)
+	}
+	logger.WithFields(map[string]interface{}{
+		"endpoint":   event.Endpoint,
+		"expression": event.Expression,
+		"decision":   event.Decision,
+		"reason":     event.Reason,
+		"issuer":     event.Issuer,
+		"subject":    event.Subject,
+		"trace_id":   event.TraceID,
+	}).Info("authorization decision")
+}
+
+// MetricsAuditSink is an AuditSink that counts decisions by endpoint, decision and reason.
+type MetricsAuditSink struct {
+	Metrics *metrics.AuthorizationMetrics
+}
+
+// RecordAuthorizationDecision implements AuditSink.
+func (s MetricsAuditSink) RecordAuthorizationDecision(_ context.Context, event AuditEvent) {
+	s.Metrics.RecordDecision(event.Endpoint, event.Decision, event.Reason)
+}
+
+type auditClaimsKey struct{}
+
+// auditClaims captures the claims of a request authenticated by a rule, including those denied access.
+type auditClaims struct {
+	claims jwtauth.Claims
+}
+
+// recordAuditClaims records the authenticated claims of a request for its audit event.
+func recordAuditClaims(ctx context.Context, claims jwtauth.Claims) {
+	if audit, ok := ctx.Value(auditClaimsKey{}).(*auditClaims); ok {
+		audit.claims = claims
+	}
+}
+
+// WithAudit returns a Rule that records each decision of the given rule in the sink.
+func WithAudit(rule Rule, sink AuditSink, endpoint string, expression string) Rule {
+	return func(ctx context.Context) (context.Context, error) {
+		audit := &auditClaims{}
+		ruleCtx, err := rule(context.WithValue(ctx, auditClaimsKey{}, audit))
+		event := AuditEvent{
+			Endpoint:   endpoint,
+			Expression: expression,
+			Issuer:     claimString(audit.claims, "iss"),
+			Subject:    claimString(audit.claims, "sub"),
+			TraceID:    traceID(ctx),
+		}
+		event.Decision, event.Reason = decision(err)
+		sink.RecordAuthorizationDecision(ctx, event)
+		return ruleCtx, err
+	}
+}
+
+// decision returns the decision and failure reason of the error returned by a rule.
+func decision(err error) (string, string) {
+	var authErr *jwtauth.AuthError
+	switch {
+	case err == nil:
+		return DecisionAllow, ""
+	case errors.Is(err, jwtgrpc.ErrClaimsValidationFailed):
+		return DecisionDeny, ReasonRuleDenied
+	case errors.As(err, &authErr) && authErr.Code == jwtauth.AuthErrCodeUnknown:
+		return DecisionError, jwtauth.AuthErrCodeName(authErr.Code)
+	case authErr != nil:
+		return DecisionDeny, jwtauth.AuthErrCodeName(authErr.Code)
+	case status.Code(err) == codes.Unauthenticated:
+		return DecisionDeny, ReasonUnauthenticated
+	default:
+		return DecisionError, ReasonRuleError
+	}
+}
+
+func claimString(claims jwtauth.Claims, name string) string {
+	value, ok := claims[name]
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// traceID returns the trace ID of the request, empty when there is none.
+func traceID(ctx context.Context) string {
+	if id, ok := common.TryGetTraceIDStringFromContext(ctx); ok {
+		return id
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package authrules
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+type testAuthenticator struct {
+	claims jwtauth.Claims
+	err    error
+}
+
+func (a testAuthenticator) Authenticate(context.Context, string) (jwtauth.Claims, error) {
+	return a.claims, a.err
+}
+
+type recordingAuditSink struct {
+	events []AuditEvent
+}
+
+func (s *recordingAuditSink) RecordAuthorizationDecision(_ context.Context, event AuditEvent) {
+	s.events = append(s.events, event)
+}
+
+func TestWithAudit(t *testing.T) {
+	expression := `jwtClaimEquals("tenant", "anz")`
+	claimsRule, err := MakeDefaultJWTClaimsBasedAuthorizationRule(expression)
+	require.NoError(t, err)
+	failingRule := func(context.Context, jwtauth.Claims) (bool, error) { return false, errors.New("failed") }
+	claims := jwtauth.Claims{"iss": "issuer", "sub": "user", "tenant": "anz"}
+
+	ctx := testutil.NewTestContext()
+	ctx = common.AddTraceIDStringToContext(ctx, "trace-1", true)
+	ctx = common.RequestHeaderToContext(ctx, http.Header{"Authorization": {"Bearer token"}})
+
+	for name, c := range map[string]struct {
+		rule          JWTClaimsBasedAuthorizationRule
+		authenticator testAuthenticator
+		expected      AuditEvent
+	}{
+		"allowed": {claimsRule, testAuthenticator{claims: claims},
+			AuditEvent{Decision: DecisionAllow, Issuer: "issuer", Subject: "user"}},
+		"denied by rule": {claimsRule, testAuthenticator{claims: jwtauth.Claims{"iss": "issuer", "sub": "user", "tenant": "other"}},
+			AuditEvent{Decision: DecisionDeny, Reason: ReasonRuleDenied, Issuer: "issuer", Subject: "user"}},
+		"expired": {claimsRule, testAuthenticator{err: &jwtauth.AuthError{Code: jwtauth.AuthErrCodeExpiredJWT}},
+			AuditEvent{Decision: DecisionDeny, Reason: "expired_jwt"}},
+		"authentication error": {claimsRule, testAuthenticator{err: &jwtauth.AuthError{Code: jwtauth.AuthErrCodeUnknown}},
+			AuditEvent{Decision: DecisionError, Reason: "unknown"}},
+		"rule error": {failingRule, testAuthenticator{claims: claims},
+			AuditEvent{Decision: DecisionError, Reason: ReasonRuleError, Issuer: "issuer", Subject: "user"}},
+	} {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			rule, err := MakeRESTJWTAuthorizationRule(c.rule, c.authenticator)
+			require.NoError(t, err)
+			sink := &recordingAuditSink{}
+			_, ruleErr := WithAudit(rule, sink, "GET /accounts", expression)(ctx)
+			require.Equal(t, c.expected.Decision == DecisionAllow, ruleErr == nil)
+
+			c.expected.Endpoint = "GET /accounts"
+			c.expected.Expression = expression
+			c.expected.TraceID = "trace-1"
+			require.Equal(t, []AuditEvent{c.expected}, sink.events)
+		})
+	}
+}
+
+func TestWithAuditGRPC(t *testing.T) {
+	expression := `jwtClaimEquals("tenant", "anz")`
+	claimsRule, err := MakeDefaultJWTClaimsBasedAuthorizationRule(expression)
+	require.NoError(t, err)
+	rule, err := MakeGRPCJWTAuthorizationRule(claimsRule, testAuthenticator{claims: jwtauth.Claims{"tenant": "anz"}})
+	require.NoError(t, err)
+	ctx := common.AddTraceIDStringToContext(testutil.NewTestContext(), "trace-1", true)
+
+	for name, c := range map[string]struct {
+		md       metadata.MD
+		expected AuditEvent
+	}{
+		"allowed":          {metadata.Pairs("authorization", "Bearer token"), AuditEvent{Decision: DecisionAllow}},
+		"no metadata":      {nil, AuditEvent{Decision: DecisionDeny, Reason: ReasonUnauthenticated}},
+		"no authorization": {metadata.Pairs("other", "value"), AuditEvent{Decision: DecisionDeny, Reason: ReasonUnauthenticated}},
+		"not a bearer":     {metadata.Pairs("authorization", "Token abc"), AuditEvent{Decision: DecisionDeny, Reason: ReasonUnauthenticated}},
+	} {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			callCtx := ctx
+			if c.md != nil {
+				callCtx = metadata.NewIncomingContext(ctx, c.md)
+			}
+			sink := &recordingAuditSink{}
+			_, ruleErr := WithAudit(rule, sink, "Service.Method", expression)(callCtx)
+			require.Equal(t, c.expected.Decision == DecisionAllow, ruleErr == nil)
+
+			c.expected.Endpoint = "Service.Method"
+			c.expected.Expression = expression
+			c.expected.TraceID = "trace-1"
+			require.Equal(t, []AuditEvent{c.expected}, sink.events)
+		})
+	}
+}
+
+func TestLogAuditSink(t *testing.T) {
+	ctx, logger := testutil.NewTestContextWithLogger()
+	event := AuditEvent{Endpoint: "GET /accounts", Decision: DecisionDeny, Reason: "expired_jwt", TraceID: "trace-1"}
+
+	LogAuditSink{}.RecordAuthorizationDecision(ctx, event)
+	require.Equal(t, 1, logger.EntryCount())
+	entry := logger.Entries()[0]
+	require.Equal(t, log.InfoLevel, entry.Level)
+	require.Equal(t, "authorization decision", entry.Message)
+	require.Equal(t, "deny", entry.Fields["decision"])
+	require.Equal(t, "expired_jwt", entry.Fields["reason"])
+	require.Equal(t, "trace-1", entry.Fields["trace_id"])
+
+	event.Decision, event.Reason = DecisionAllow, ""
+	LogAuditSink{OnlyFailures: true}.RecordAuthorizationDecision(ctx, event)
+	require.Equal(t, 1, logger.EntryCount())
+
+	// Entries are written to the given logger rather than the logger of the context.
+	_, auditLogger := testutil.NewTestContextWithLogger()
+	LogAuditSink{Logger: auditLogger}.RecordAuthorizationDecision(log.WithLevel(ctx, log.ErrorLevel), event)
+	require.Equal(t, 1, logger.EntryCount())
+	require.Equal(t, 1, auditLogger.EntryCount())
+	require.Equal(t, "allow", auditLogger.LastEntry().Fields["decision"])
+}
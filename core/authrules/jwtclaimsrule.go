@@ -11,6 +11,7 @@ import (
 	"github.com/anz-bank/sysl-go/common"
 	"github.com/anz-bank/sysl-go/jwtauth"
 	"github.com/anz-bank/sysl-go/jwtauth/jwtgrpc"
+	"github.com/anz-bank/sysl-go/metrics"
 )
 
 // ClaimsBasedAuthorizationRule decides if access is approved or denied based on the given claims.
@@ -26,19 +27,20 @@ func MakeDefaultJWTClaimsBasedAuthorizationRule(authorizationRuleExpression stri
 		return nil, err
 	}
 	return func(ctx context.Context, claims jwtauth.Claims) (bool, error) {
-		evalCtx := authexpr.EvaluationContext{
-			JWTHasScope: authexpr.MakeStandardJWTHasScope(claims),
-		}
-		return rootExpr.Evaluate(evalCtx)
+		return rootExpr.Evaluate(authexpr.MakeStandardRequestEvaluationContext(claims, RequestFromContext(ctx)))
 	}, nil
 }
 
 // MakeGRPCAuthorizationRule creates an authorization Rule from a claims-based authorization Rule
-// and a jwtauth Authenticator.
+// and a jwtauth Authenticator. Requests without a bearer token are authenticated by their other
+// credentials when the authenticator is a jwtauth.CredentialsAuthenticator.
 func MakeGRPCJWTAuthorizationRule(authRule JWTClaimsBasedAuthorizationRule, authenticator jwtauth.Authenticator) (Rule, error) {
 	return func(ctx context.Context) (context.Context, error) {
 		rawToken, err := jwtgrpc.GetBearerFromIncomingContext(ctx)
 		if err != nil {
+			if claims, found, credErr := jwtauth.AuthenticateRequestCredentials(ctx, authenticator, incomingMetadataHeader(ctx), nil); found {
+				return authorizeClaims(ctx, claims, credErr, authRule)
+			}
 			log.Debugf(ctx, "auth: error extracting jwt from context: %v", err)
 			return nil, err
 		}
@@ -47,11 +49,16 @@ func MakeGRPCJWTAuthorizationRule(authRule JWTClaimsBasedAuthorizationRule, auth
 }
 
 // MakeRESTJWTAuthorizationRule creates an authorization Rule from a claims-based authorization Rule
-// and a jwtauth Authenticator.
+// and a jwtauth Authenticator. Requests without a bearer token are authenticated by their other
+// credentials when the authenticator is a jwtauth.CredentialsAuthenticator.
 func MakeRESTJWTAuthorizationRule(authRule JWTClaimsBasedAuthorizationRule, authenticator jwtauth.Authenticator) (Rule, error) {
 	return func(ctx context.Context) (context.Context, error) {
 		rawToken, err := getBearerTokenFromIncomingRESTContext(ctx)
 		if err != nil {
+			header, query := common.RequestHeaderFromContext(ctx), common.RequestQueryFromContext(ctx)
+			if claims, found, credErr := jwtauth.AuthenticateRequestCredentials(ctx, authenticator, header, query); found {
+				return authorizeClaims(ctx, claims, credErr, authRule)
+			}
 			log.Debugf(ctx, "auth: error extracting jwt from context: %v", err)
 			return nil, err
 		}
@@ -61,10 +68,17 @@ func MakeRESTJWTAuthorizationRule(authRule JWTClaimsBasedAuthorizationRule, auth
 
 func authorize(ctx context.Context, rawToken string, authRule JWTClaimsBasedAuthorizationRule, authenticator jwtauth.Authenticator) (context.Context, error) {
 	claims, err := authenticator.Authenticate(ctx, rawToken)
+	return authorizeClaims(ctx, claims, err, authRule)
+}
+
+// authorizeClaims evaluates the authorization rule with the claims of the credentials of a request,
+// unless their authentication failed with the given error.
+func authorizeClaims(ctx context.Context, claims jwtauth.Claims, err error, authRule JWTClaimsBasedAuthorizationRule) (context.Context, error) {
 	if err != nil {
-		log.Debugf(ctx, "auth: jwt authentication failed, access denied: %v", err)
+		log.Debugf(ctx, "auth: authentication failed, access denied: %v", err)
 		return ctx, err
 	}
+	recordAuditClaims(ctx, claims)
 	isAuthorised, err := authRule(ctx, claims)
 	if err != nil {
 		log.Debugf(ctx, "auth: error evaluating authorization rule: %v", err)
@@ -77,6 +91,7 @@ func authorize(ctx context.Context, rawToken string, authRule JWTClaimsBasedAuth
 
 	log.Debugf(ctx, "auth: request authenticated and authorized successfully")
 	ctx = jwtauth.AddClaimsToContext(ctx, claims)
+	metrics.RecordClaims(ctx, claims)
 	return ctx, nil
 }
 
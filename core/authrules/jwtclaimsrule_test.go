@@ -0,0 +1,81 @@
+package authrules
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+// testCredentialsAuthenticator authenticates the API key key-one with the scope read and the team
+// payments, and rejects every bearer token.
+func testCredentialsAuthenticator(t *testing.T) jwtauth.Authenticator {
+	auth, err := jwtauth.AuthFromConfig(testutil.NewTestContext(), &jwtauth.Config{
+		APIKey: &jwtauth.APIKeyConfig{Query: "api_key", Header: "X-Api-Key", Keys: []jwtauth.APIKey{{
+			Name:   "one",
+			Hash:   "9b346041bc9a49574eb2665b2ad2a0a3f9f9cce4e42f5d1f26deb8a256b5966a",
+			Scopes: []string{"read"},
+			Labels: map[string]string{"team": "payments"},
+		}}},
+	}, func(string) *http.Client { return nil })
+	require.NoError(t, err)
+	return auth
+}
+
+func TestRESTRuleCredentials(t *testing.T) {
+	claimsRule, err := MakeDefaultJWTClaimsBasedAuthorizationRule(`all(jwtHasScope("read"), jwtClaimEquals("labels.team", "payments"))`)
+	require.NoError(t, err)
+	rule, err := MakeRESTJWTAuthorizationRule(claimsRule, testCredentialsAuthenticator(t))
+	require.NoError(t, err)
+
+	ctx, err := rule(common.RequestHeaderToContext(testutil.NewTestContext(), http.Header{"X-Api-Key": {"key-one"}}))
+	require.NoError(t, err)
+	claims, ok := jwtauth.GetClaimsFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, "one", claims["sub"])
+
+	ctx = common.RequestQueryToContext(common.RequestHeaderToContext(testutil.NewTestContext(), http.Header{}), "api_key=key-one")
+	_, err = rule(ctx)
+	require.NoError(t, err)
+
+	_, err = rule(common.RequestHeaderToContext(testutil.NewTestContext(), http.Header{"X-Api-Key": {"key-two"}}))
+	var authErr *jwtauth.AuthError
+	require.ErrorAs(t, err, &authErr)
+	require.Equal(t, jwtauth.AuthErrCodeInvalidCredentials, authErr.Code)
+
+	// Requests with neither a bearer token nor other credentials are rejected as before.
+	_, err = rule(common.RequestHeaderToContext(testutil.NewTestContext(), http.Header{}))
+	require.ErrorAs(t, err, &authErr)
+	require.Equal(t, jwtauth.AuthErrCodeInvalidJWT, authErr.Code)
+
+	// Bearer tokens take precedence over other credentials.
+	_, err = rule(common.RequestHeaderToContext(testutil.NewTestContext(), http.Header{
+		"Authorization": {"Bearer not-a-jwt"},
+		"X-Api-Key":     {"key-one"},
+	}))
+	require.ErrorAs(t, err, &authErr)
+	require.Equal(t, jwtauth.AuthErrCodeInvalidJWT, authErr.Code)
+}
+
+func TestGRPCRuleCredentials(t *testing.T) {
+	claimsRule, err := MakeDefaultJWTClaimsBasedAuthorizationRule(`jwtHasScope("read")`)
+	require.NoError(t, err)
+	rule, err := MakeGRPCJWTAuthorizationRule(claimsRule, testCredentialsAuthenticator(t))
+	require.NoError(t, err)
+
+	ctx, err := rule(metadata.NewIncomingContext(testutil.NewTestContext(), metadata.Pairs("x-api-key", "key-one")))
+	require.NoError(t, err)
+	claims, ok := jwtauth.GetClaimsFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, "apikey", claims["iss"])
+
+	_, err = rule(metadata.NewIncomingContext(testutil.NewTestContext(), metadata.Pairs("x-api-key", "key-two")))
+	var authErr *jwtauth.AuthError
+	require.ErrorAs(t, err, &authErr)
+	require.Equal(t, jwtauth.AuthErrCodeInvalidCredentials, authErr.Code)
+}
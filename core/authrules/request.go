@@ -0,0 +1,44 @@
+package authrules
+
+import (
+	"context"
+	"net/http"
+	"net/textproto"
+
+	"github.com/go-chi/chi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/anz-bank/sysl-go/authexpr"
+	"github.com/anz-bank/sysl-go/common"
+)
+
+// RequestFromContext returns the attributes of the request being authorized with the given context.
+//
+// For gRPC requests these are the full method name and the incoming metadata. For REST requests these
+// are the HTTP method and URL path parameters of the chi route, and the headers of the request.
+func RequestFromContext(ctx context.Context) authexpr.Request {
+	if method, ok := grpc.Method(ctx); ok {
+		return authexpr.Request{Method: method, Header: incomingMetadataHeader(ctx)}
+	}
+
+	request := authexpr.Request{Header: common.RequestHeaderFromContext(ctx)}
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		request.Method = rctx.RouteMethod
+		request.Params = make(map[string]string, len(rctx.URLParams.Keys))
+		for i, key := range rctx.URLParams.Keys {
+			request.Params[key] = rctx.URLParams.Values[i]
+		}
+	}
+	return request
+}
+
+// incomingMetadataHeader returns the incoming gRPC metadata of the context as a header.
+func incomingMetadataHeader(ctx context.Context) http.Header {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := make(http.Header, len(md))
+	for key, values := range md {
+		header[textproto.CanonicalMIMEHeaderKey(key)] = values
+	}
+	return header
+}
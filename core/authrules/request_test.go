@@ -0,0 +1,71 @@
+package authrules
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/anz-bank/sysl-go/authexpr"
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/jwtauth"
+)
+
+func TestRESTRequestAwareRule(t *testing.T) {
+	rule, err := MakeDefaultJWTClaimsBasedAuthorizationRule(
+		`all(requestMethodIn("GET"), requestParamEqualsClaim("customerId", "sub"), requestHeaderEqualsClaim("X-Tenant", "tenant"))`)
+	require.NoError(t, err)
+
+	var request authexpr.Request
+	var allowed bool
+	r := chi.NewRouter()
+	r.Get("/customers/{customerId}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := common.RequestHeaderToContext(r.Context(), r.Header)
+		request = RequestFromContext(ctx)
+		allowed, err = rule(ctx, jwtauth.Claims{"sub": "c1", "tenant": "anz"})
+		require.NoError(t, err)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/customers/c1", nil)
+	req.Header.Set("X-Tenant", "anz")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	require.True(t, allowed)
+	require.Equal(t, "GET", request.Method)
+	require.Equal(t, map[string]string{"customerId": "c1"}, request.Params)
+
+	req = httptest.NewRequest(http.MethodGet, "/customers/c2", nil)
+	req.Header.Set("X-Tenant", "anz")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	require.False(t, allowed)
+}
+
+type testServerTransportStream struct {
+	grpc.ServerTransportStream
+	method string
+}
+
+func (s testServerTransportStream) Method() string { return s.method }
+
+func TestGRPCRequestAwareRule(t *testing.T) {
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), testServerTransportStream{method: "/pkg.Service/GetCustomer"})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-tenant", "anz"))
+
+	request := RequestFromContext(ctx)
+	require.Equal(t, "/pkg.Service/GetCustomer", request.Method)
+	require.Equal(t, "anz", request.Header.Get("X-Tenant"))
+
+	rule, err := MakeDefaultJWTClaimsBasedAuthorizationRule(
+		`all(requestMethodIn("/pkg.Service/GetCustomer"), requestHeaderEqualsClaim("x-tenant", "tenant"))`)
+	require.NoError(t, err)
+	allowed, err := rule(ctx, jwtauth.Claims{"tenant": "anz"})
+	require.NoError(t, err)
+	require.True(t, allowed)
+	allowed, err = rule(ctx, jwtauth.Claims{"tenant": "other"})
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
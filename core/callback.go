@@ -14,8 +14,11 @@ import (
 	"github.com/anz-bank/sysl-go/config"
 	"github.com/anz-bank/sysl-go/core/authrules"
 	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/metrics"
 	"github.com/go-chi/chi"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 )
 
 // RestGenCallback is used by `sysl-go` to call hand-crafted code.
@@ -66,6 +69,13 @@ type Hooks struct {
 	// If not supplied it will use httpError.WriteError as the default.
 	WriteError func(ctx context.Context, w http.ResponseWriter, httpError *common.HTTPError)
 
+	// MapGRPCError maps an error returned from a gRPC handler to a gRPC status in instances where
+	// custom error mapping is required.
+	// Return nil to perform default error mapping; defined as:
+	// 1. The status of the error if the error is a gRPC status error, otherwise
+	// 2. common.MapGRPCError
+	MapGRPCError func(ctx context.Context, err error) *status.Status
+
 	// AdditionalGrpcDialOptions can be used to append to the default grpc.DialOption configuration used by
 	// an autogenerated service when it calls grpc.Dial when using a grpc.Client to connect to a gRPC server.
 	// If given, AdditionalGrpcDialOptions will be appended to the list of default options created by
@@ -123,6 +133,16 @@ type Hooks struct {
 	// hook is nil, then authrules.MakeDefaultJWTClaimsBasedAuthorizationRule is used.
 	OverrideMakeJWTClaimsBasedAuthorizationRule func(authorizationRuleExpression string) (authrules.JWTClaimsBasedAuthorizationRule, error)
 
+	// JWTAuthenticator can be used to provide the authenticator of the JWTs of all the REST and gRPC
+	// authorization rules. It is called once, when the first rule is resolved. By default, if this hook
+	// is nil, a single authenticator is built from library.authentication.jwtauth and shared by all rules.
+	JWTAuthenticator func(ctx context.Context) (jwtauth.Authenticator, error)
+
+	// AuthorizationAuditSink can be used to record the decisions of all the authorization rules, in
+	// addition to the authorization_decisions_total metric and the log entries enabled by
+	// library.authentication.audit.
+	AuthorizationAuditSink authrules.AuditSink
+
 	// AddHTTPMiddleware can be used to install additional HTTP middleware into the chi.Router
 	// used to serve all (non-admin) HTTP endpoints. By default, sysl-go installs a number of
 	// HTTP middleware -- refer to prepareMiddleware inside sysl-go/core. This hook can only
@@ -165,8 +185,17 @@ type Hooks struct {
 	// ExperimentalTemporalWorkerBuilder can be used to build a custom temporal worker.
 	ExperimentalTemporalWorkerBuilder func(client client.Client, taskQueue string, options worker.Options) worker.Worker
 
+	// TracerProvider can be used to provide the OpenTelemetry TracerProvider used to record spans,
+	// e.g. one exporting to an in-memory exporter within tests. When the hook returns a provider,
+	// tracing is enabled regardless of the library.trace.openTelemetry configuration. The provider
+	// is neither installed as the global provider nor shut down by sysl-go.
+	TracerProvider func(ctx context.Context) trace.TracerProvider
+
 	// HealthCheck can be used to provide custom health check endpoints for your service.
 	// Currently only gRPC service is supported by implementing grpc.health.v1 when this field is set.
+	// Otherwise, when library.health is set, grpc.health.v1 and the HTTP /readyz and /livez admin
+	// endpoints report the checks registered with the health.Registry of the context passed to the
+	// create-service callback (see health.GetRegistry).
 	HealthCheck HealthCheck
 }
 
@@ -225,11 +254,23 @@ func ResolveGrpcServerOptions(ctx context.Context, h *Hooks, grpcPublicServerCon
 }
 
 func ResolveGRPCAuthorizationRule(ctx context.Context, h *Hooks, endpointName string, authRuleExpression string) (authrules.Rule, error) {
-	return resolveAuthorizationRule(ctx, h, endpointName, authRuleExpression, authrules.MakeGRPCJWTAuthorizationRule)
+	return resolveEndpointAuthorizationRule(ctx, h, endpointName, authRuleExpression, authrules.MakeGRPCJWTAuthorizationRule)
 }
 
 func ResolveRESTAuthorizationRule(ctx context.Context, h *Hooks, endpointName string, authRuleExpression string) (authrules.Rule, error) {
-	return resolveAuthorizationRule(ctx, h, endpointName, authRuleExpression, authrules.MakeRESTJWTAuthorizationRule)
+	return resolveEndpointAuthorizationRule(ctx, h, endpointName, authRuleExpression, authrules.MakeRESTJWTAuthorizationRule)
+}
+
+// resolveEndpointAuthorizationRule resolves the authorization rule of an endpoint of the specification,
+// applying the rule file of the authorization policy in the context, if any.
+func resolveEndpointAuthorizationRule(ctx context.Context, h *Hooks, endpointName string, authRuleExpression string, ruleFactory func(authRule authrules.JWTClaimsBasedAuthorizationRule, authenticator jwtauth.Authenticator) (authrules.Rule, error)) (authrules.Rule, error) {
+	policy := getAuthorizationPolicy(ctx)
+	if policy == nil {
+		return resolveAuthorizationRule(ctx, h, endpointName, authRuleExpression, ruleFactory)
+	}
+	return policy.addEndpoint(endpointName, authRuleExpression, func(expression string) (authrules.Rule, error) {
+		return resolveAuthorizationRule(ctx, h, endpointName, expression, ruleFactory)
+	})
 }
 
 func resolveAuthorizationRule(ctx context.Context, h *Hooks, endpointName string, authRuleExpression string, ruleFactory func(authRule authrules.JWTClaimsBasedAuthorizationRule, authenticator jwtauth.Authenticator) (authrules.Rule, error)) (authrules.Rule, error) {
@@ -250,22 +291,50 @@ func resolveAuthorizationRule(ctx context.Context, h *Hooks, endpointName string
 		return nil, err
 	}
 
-	// TODO(fletcher) inject custom http client instrumented with monitoring
-	httpClient, err := config.DefaultHTTPClient(ctx, nil)
+	authenticator, err := getJWTAuthenticator(ctx, h, endpointName)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := ruleFactory(claimsBasedAuthRule, authenticator)
 	if err != nil {
 		return nil, err
 	}
-	httpClientFactory := func(_ string) *http.Client {
-		return httpClient
+	if sink := authorizationAuditSink(ctx, h); len(sink) > 0 {
+		rule = authrules.WithAudit(rule, sink, endpointName, authRuleExpression)
 	}
+	return rule, nil
+}
+
+type auditLoggerKey struct{}
+
+// withAuditLogger puts the logger of the context into the context as the logger of the authorization
+// audit entries. It must be called before the logger is sampled or its level is set, such that audit
+// entries are never dropped.
+func withAuditLogger(ctx context.Context) context.Context {
+	return context.WithValue(ctx, auditLoggerKey{}, log.GetLogger(ctx).WithLevel(log.InfoLevel))
+}
 
-	// Note: this will start a new jwtauth.Authenticator with its own cache & threads running for each of our service's endpoints, we usually want a shared one.
-	if cfg == nil || cfg.Library.Authentication == nil || cfg.Library.Authentication.JWTAuth == nil {
-		return nil, fmt.Errorf("method/endpoint %s requires a JWT-based authorization rule, but there is no config for library.authentication.jwtauth", endpointName)
+// getAuditLogger returns the logger of the authorization audit entries, nil when there is none.
+func getAuditLogger(ctx context.Context) log.Logger {
+	logger, _ := ctx.Value(auditLoggerKey{}).(log.Logger)
+	return logger
+}
+
+// authorizationAuditSink returns the sinks of the decisions of the authorization rules: the metrics
+// when there is a registry, the log when enabled in config, and the hook.
+func authorizationAuditSink(ctx context.Context, h *Hooks) authrules.AuditSinks {
+	var sinks authrules.AuditSinks
+	if registry := metrics.GetRegistry(ctx); registry != nil {
+		sinks = append(sinks, authrules.MetricsAuditSink{Metrics: metrics.NewAuthorizationMetrics(registry)})
 	}
-	authenticator, err := jwtauth.AuthFromConfig(ctx, cfg.Library.Authentication.JWTAuth, httpClientFactory)
-	if err != nil {
-		return nil, err
+	if cfg := config.GetDefaultConfig(ctx); cfg != nil && cfg.Library.Authentication != nil && cfg.Library.Authentication.Audit.Enabled {
+		sinks = append(sinks, authrules.LogAuditSink{
+			OnlyFailures: cfg.Library.Authentication.Audit.OnlyFailures,
+			Logger:       getAuditLogger(ctx),
+		})
+	}
+	if h.AuthorizationAuditSink != nil {
+		sinks = append(sinks, h.AuthorizationAuditSink)
 	}
-	return ruleFactory(claimsBasedAuthRule, authenticator)
+	return sinks
 }
@@ -3,11 +3,20 @@ package core
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/anz-bank/sysl-go/log"
 
+	"github.com/anz-bank/sysl-go/common"
 	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/core/authrules"
+	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/metrics"
+	"github.com/anz-bank/sysl-go/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 )
@@ -150,3 +159,43 @@ func TestResolveGrpcDialOptionsCanOverrideDialOptionsPerTargetService(t *testing
 	require.NoError(t, err)
 	require.Equal(t, customOptionsForBarr, actualBarrOpts)
 }
+
+type recordingAuditSink struct {
+	events []authrules.AuditEvent
+}
+
+func (s *recordingAuditSink) RecordAuthorizationDecision(_ context.Context, event authrules.AuditEvent) {
+	s.events = append(s.events, event)
+}
+
+func TestAuthorizationRuleAudit(t *testing.T) {
+	sink := &recordingAuditSink{}
+	hooks := &Hooks{
+		JWTAuthenticator: func(ctx context.Context) (jwtauth.Authenticator, error) {
+			return jwtauth.InsecureAuthenticator{}, nil
+		},
+		AuthorizationAuditSink: sink,
+	}
+	// The audit entries are logged regardless of the level of the logger.
+	ctx, logger := testutil.NewTestContextWithLogger()
+	ctx = log.WithLevel(withAuditLogger(ctx), log.ErrorLevel)
+	cfg := &config.DefaultConfig{}
+	cfg.Library.Authentication = &config.AuthenticationConfig{Audit: config.AuthorizationAuditConfig{Enabled: true, OnlyFailures: true}}
+	registry := prometheus.NewRegistry()
+	ctx = metrics.PutRegistry(config.PutDefaultConfig(ctx, cfg), registry)
+
+	rule, err := ResolveRESTAuthorizationRule(ctx, hooks, "GET /a", `jwtHasScope("a")`)
+	require.NoError(t, err)
+	_, err = rule(common.RequestHeaderToContext(ctx, http.Header{"Authorization": {"Bearer not-a-jwt"}}))
+	require.Error(t, err)
+
+	require.Len(t, sink.events, 1)
+	require.Equal(t, authrules.DecisionDeny, sink.events[0].Decision)
+	require.Equal(t, "invalid_jwt", sink.events[0].Reason)
+	require.Equal(t, 1, logger.EntryCount())
+	require.NoError(t, promtestutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP authorization_decisions_total Authorization decisions made for requests, by endpoint, decision and failure reason
+# TYPE authorization_decisions_total counter
+authorization_decisions_total{decision="deny",endpoint="GET /a",reason="invalid_jwt"} 1
+`), "authorization_decisions_total"))
+}
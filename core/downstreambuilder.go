@@ -2,13 +2,21 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"go.temporal.io/sdk/client"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 
 	"github.com/anz-bank/sysl-go/common"
 	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/health"
+	"github.com/anz-bank/sysl-go/metrics"
+	"github.com/anz-bank/sysl-go/tracing"
 )
 
 func BuildDownstreamHTTPClient(ctx context.Context, serviceName string, hooks *Hooks, cfg *config.CommonDownstreamData) (client *http.Client, serviceURL string, err error) {
@@ -25,23 +33,57 @@ func BuildDownstreamHTTPClient(ctx context.Context, serviceName string, hooks *H
 		return nil, "", err
 	}
 
+	// The health checks probe with the uninstrumented client, so that they don't pollute the logs,
+	// metrics and traces of the downstream.
+	probeClient := *client
 	client.Transport = common.NewLoggingRoundTripper(serviceName, client.Transport)
+	if registry := metrics.GetRegistry(ctx); registry != nil {
+		client.Transport = metrics.NewHTTPClientMetrics(registry, serviceName).RoundTripper(client.Transport)
+	}
+	if tp := tracing.GetTracerProvider(ctx); tp != nil {
+		client.Transport = tracing.NewHTTPClientRoundTripper(tp, client.Transport)
+	}
 	if hooks != nil && hooks.DownstreamRoundTripper != nil {
 		client.Transport = hooks.DownstreamRoundTripper(serviceName, serviceURL, client.Transport)
 	}
 
+	if cfg != nil {
+		if err = registerDependencyCheck(ctx, serviceName, cfg.HealthCheck, httpDependencyProbe(&probeClient, serviceURL, cfg.HealthCheck.Path)); err != nil {
+			return nil, "", err
+		}
+	}
+
 	return
 }
 
 // BuildDownstreamGRPCClient creates a grpc client connection to the target indicated by cfg.ServiceAddress.
 // The dial options can be customised by cfg or by hooks, see ResolveGrpcDialOptions for details. The
 // serviceName is the name of the target service. This function is intended to be called from generated code.
+// Errors returned from calls made through the connection are mapped by GrpcDownstreamErrorInterceptor
+// and GrpcDownstreamErrorStreamInterceptor.
+// When metrics are collected, calls are recorded in the registry under the serviceName.
 func BuildDownstreamGRPCClient(ctx context.Context, serviceName string, hooks *Hooks, cfg *config.CommonGRPCDownstreamData) (*grpc.ClientConn, error) {
 	opts, err := ResolveGrpcDialOptions(ctx, serviceName, hooks, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return grpc.Dial(cfg.ServiceAddress, opts...)
+	if registry := metrics.GetRegistry(ctx); registry != nil {
+		opts = append(opts, metrics.NewGRPCClientMetrics(registry, serviceName).DialOptions()...)
+	}
+	if tp := tracing.GetTracerProvider(ctx); tp != nil {
+		opts = append(opts, tracing.GRPCDialOptions(tp)...)
+	}
+	opts = append(opts,
+		grpc.WithChainUnaryInterceptor(GrpcDownstreamErrorInterceptor),
+		grpc.WithChainStreamInterceptor(GrpcDownstreamErrorStreamInterceptor))
+	conn, err := grpc.Dial(cfg.ServiceAddress, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = registerDependencyCheck(ctx, serviceName, cfg.HealthCheck, grpcDependencyProbe(conn)); err != nil {
+		return nil, err
+	}
+	return conn, nil
 }
 
 // BuildDownstreamTemporalClient creates a temporal client connection to the target indicated by cfg.HostPort.
@@ -60,6 +102,9 @@ func BuildDownstreamTemporalClient(
 		// TODO: add sysl-go logging solution
 		// Logger:             nil,
 	}
+	if err := addTemporalTracingInterceptor(ctx, &clientOptions); err != nil {
+		return nil, err
+	}
 
 	if hooks.ExperimentalValidateTemporalClientOptions != nil {
 		if err := hooks.ExperimentalValidateTemporalClientOptions(ctx, &clientOptions); err != nil {
@@ -67,8 +112,112 @@ func BuildDownstreamTemporalClient(
 		}
 	}
 
+	var c client.Client
+	var err error
 	if hooks.ExperimentalTemporalClientBuilder != nil {
-		return hooks.ExperimentalTemporalClientBuilder(ctx, serviceName, &clientOptions)
+		c, err = hooks.ExperimentalTemporalClientBuilder(ctx, serviceName, &clientOptions)
+	} else {
+		c, err = client.Dial(clientOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+	probe := func(ctx context.Context) error {
+		_, err := c.CheckHealth(ctx, &client.CheckHealthRequest{})
+		return err
+	}
+	if err = registerDependencyCheck(ctx, serviceName, cfg.HealthCheck, probe); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// addTemporalTracingInterceptor adds the tracing interceptor to the client options when tracing is enabled.
+// The interceptor also applies to the workers created from the client.
+func addTemporalTracingInterceptor(ctx context.Context, clientOptions *client.Options) error {
+	tp := tracing.GetTracerProvider(ctx)
+	if tp == nil {
+		return nil
+	}
+	i, err := tracing.NewTemporalInterceptor(tp)
+	if err != nil {
+		return err
+	}
+	clientOptions.Interceptors = append(clientOptions.Interceptors, i)
+	return nil
+}
+
+// registerDependencyCheck registers the check of a downstream service with the health registry in the
+// context, when the check is enabled.
+func registerDependencyCheck(ctx context.Context, serviceName string, cfg config.DependencyCheckConfig, probe func(ctx context.Context) error) error {
+	registry := health.GetRegistry(ctx)
+	if !cfg.Enabled || registry == nil {
+		return nil
+	}
+	return registry.Register(health.Check{
+		Name:     serviceName,
+		Probe:    probe,
+		Timeout:  cfg.Timeout,
+		Critical: cfg.Critical,
+	})
+}
+
+// httpDependencyProbe requests the path relative to the service URL with GET, or only connects to
+// the service when the path is empty.
+func httpDependencyProbe(client *http.Client, serviceURL, path string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		u, err := url.Parse(serviceURL)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			host := u.Host
+			if u.Port() == "" {
+				port := "80"
+				if u.Scheme == "https" {
+					port = "443"
+				}
+				host = net.JoinHostPort(u.Hostname(), port)
+			}
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", host)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(serviceURL, "/")+"/"+strings.TrimPrefix(path, "/"), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// grpcDependencyProbe waits for the connection to be ready.
+func grpcDependencyProbe(conn *grpc.ClientConn) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for {
+			state := conn.GetState()
+			switch state {
+			case connectivity.Ready:
+				return nil
+			case connectivity.Idle:
+				conn.Connect()
+			case connectivity.Shutdown:
+				return fmt.Errorf("connection shut down")
+			}
+			if !conn.WaitForStateChange(ctx, state) {
+				return fmt.Errorf("connection %s", strings.ToLower(state.String()))
+			}
+		}
 	}
-	return client.Dial(clientOptions)
 }
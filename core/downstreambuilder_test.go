@@ -2,9 +2,14 @@ package core
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/health"
+	"github.com/anz-bank/sysl-go/testutil"
 )
 
 type roundTripper struct {
@@ -28,3 +33,39 @@ func TestDownstreamRoundTripper(t *testing.T) {
 	require.NotNil(t, client)
 	require.IsType(t, roundTripper{}, client.Transport)
 }
+
+func TestDownstreamHTTPClientHealthCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	registry := health.NewRegistry()
+	ctx := health.PutRegistry(testutil.NewTestContext(), registry)
+	// The probes bypass the round trippers of the client, so this one must not be used.
+	hooks := &Hooks{
+		DownstreamRoundTripper: func(string, string, http.RoundTripper) http.RoundTripper {
+			return roundTripper{}
+		},
+	}
+	for name, path := range map[string]string{"connect": "", "path": "/health", "failing": "/other"} {
+		_, _, err := BuildDownstreamHTTPClient(ctx, name, hooks, &config.CommonDownstreamData{
+			ServiceURL:  srv.URL,
+			HealthCheck: config.DependencyCheckConfig{Enabled: true, Critical: true, Path: path},
+		})
+		require.NoError(t, err)
+	}
+	_, _, err := BuildDownstreamHTTPClient(ctx, "disabled", nil, &config.CommonDownstreamData{ServiceURL: srv.URL})
+	require.NoError(t, err)
+
+	report := registry.Readiness(ctx)
+	require.Equal(t, health.StatusFail, report.Status)
+	require.Len(t, report.Checks, 3)
+	statuses := map[string]health.Status{}
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status
+	}
+	require.Equal(t, map[string]health.Status{"connect": health.StatusPass, "path": health.StatusPass, "failing": health.StatusFail}, statuses)
+}
@@ -89,7 +89,17 @@ func extractGrpcServerOptionsFromGrpcManager(ctx context.Context, hl GrpcManager
 }
 
 func configurePublicGrpcServerListener(ctx context.Context, m GrpcServerManager, hooks *Hooks) StoppableServer {
-	server := grpc.NewServer(m.GrpcServerOptions...)
+	return preparePublicGrpcServerListener(ctx, newPublicGrpcServer(ctx, m, hooks), m, hooks)
+}
+
+func newPublicGrpcServer(ctx context.Context, m GrpcServerManager, hooks *Hooks) *grpc.Server {
+	opts := make([]grpc.ServerOption, 0, len(m.GrpcServerOptions)+2)
+	opts = append(opts, m.GrpcServerOptions...)
+	if accessLog := newAccessLogger(ctx); accessLog != nil {
+		opts = append(opts, accessLog.serverOptions(ctx)...)
+	}
+	opts = append(opts, grpcErrorMappingServerOptions(hooks)...)
+	server := grpc.NewServer(opts...)
 	cfg := config.GetDefaultConfig(ctx)
 	if cfg != nil && cfg.GenCode.Upstream.GRPC.EnableReflection {
 		reflection.Register(server)
@@ -108,6 +118,10 @@ func configurePublicGrpcServerListener(ctx context.Context, m GrpcServerManager,
 		setLogger(ctx)
 	}
 
+	return server
+}
+
+func preparePublicGrpcServerListener(ctx context.Context, server *grpc.Server, m GrpcServerManager, hooks *Hooks) StoppableServer {
 	prepareGrpcServerListenerFn := prepareGrpcServerListener
 	if hooks != nil && hooks.StoppableGrpcServerBuilder != nil {
 		prepareGrpcServerListenerFn = hooks.StoppableGrpcServerBuilder
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/anz-bank/sysl-go/common"
+)
+
+// GrpcErrorMappingUnaryInterceptor returns a grpc.UnaryServerInterceptor that converts errors
+// returned from handlers into gRPC status errors. See common.HandleGRPCError for details.
+func GrpcErrorMappingUnaryInterceptor(mapError func(context.Context, error) *status.Status) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, common.HandleGRPCError(ctx, err, mapError)
+		}
+		return resp, nil
+	}
+}
+
+// GrpcErrorMappingStreamInterceptor returns a grpc.StreamServerInterceptor that converts errors
+// returned from handlers into gRPC status errors. See common.HandleGRPCError for details.
+func GrpcErrorMappingStreamInterceptor(mapError func(context.Context, error) *status.Status) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return common.HandleGRPCError(ss.Context(), handler(srv, ss), mapError)
+	}
+}
+
+// GrpcDownstreamErrorInterceptor is a grpc.UnaryClientInterceptor that converts gRPC status
+// errors returned from downstream services into errors carrying a common.Kind.
+// See common.CreateDownstreamGRPCError for details.
+func GrpcDownstreamErrorInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return common.CreateDownstreamGRPCError(ctx, invoker(ctx, method, req, reply, cc, opts...))
+}
+
+// GrpcDownstreamErrorStreamInterceptor is a grpc.StreamClientInterceptor that converts gRPC status
+// errors returned from downstream streams into errors carrying a common.Kind, like
+// GrpcDownstreamErrorInterceptor does for unary calls.
+func GrpcDownstreamErrorStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, common.CreateDownstreamGRPCError(ctx, err)
+	}
+	return &downstreamErrorClientStream{ClientStream: stream}, nil
+}
+
+// downstreamErrorClientStream maps the errors of a grpc.ClientStream with common.CreateDownstreamGRPCError.
+// io.EOF, which ends a stream, is returned unchanged.
+type downstreamErrorClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *downstreamErrorClientStream) Header() (metadata.MD, error) {
+	md, err := s.ClientStream.Header()
+	return md, common.CreateDownstreamGRPCError(s.Context(), err)
+}
+
+func (s *downstreamErrorClientStream) CloseSend() error {
+	return common.CreateDownstreamGRPCError(s.Context(), s.ClientStream.CloseSend())
+}
+
+func (s *downstreamErrorClientStream) SendMsg(m interface{}) error {
+	return common.CreateDownstreamGRPCError(s.Context(), s.ClientStream.SendMsg(m))
+}
+
+func (s *downstreamErrorClientStream) RecvMsg(m interface{}) error {
+	return common.CreateDownstreamGRPCError(s.Context(), s.ClientStream.RecvMsg(m))
+}
+
+// grpcErrorMappingServerOptions returns the server options used to install the error mapping
+// interceptors, using the MapGRPCError hook when one is given.
+func grpcErrorMappingServerOptions(hooks *Hooks) []grpc.ServerOption {
+	var mapError func(context.Context, error) *status.Status
+	if hooks != nil {
+		mapError = hooks.MapGRPCError
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(GrpcErrorMappingUnaryInterceptor(mapError)),
+		grpc.ChainStreamInterceptor(GrpcErrorMappingStreamInterceptor(mapError)),
+	}
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+func TestGrpcErrorMappingUnaryInterceptor(t *testing.T) {
+	ctx := testutil.NewTestContext()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.TestService/Test"}
+
+	interceptor := GrpcErrorMappingUnaryInterceptor(nil)
+	_, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, &common.ServerError{Kind: common.DownstreamUnavailableError}
+	})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	resp, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+
+	interceptor = GrpcErrorMappingUnaryInterceptor(func(ctx context.Context, err error) *status.Status {
+		return status.New(codes.Aborted, err.Error())
+	})
+	_, err = interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("aborted")
+	})
+	require.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestGrpcDownstreamErrorInterceptor(t *testing.T) {
+	ctx := testutil.NewTestContext()
+
+	err := GrpcDownstreamErrorInterceptor(ctx, "/test.TestService/Test", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return status.Error(codes.DeadlineExceeded, "too slow")
+		})
+
+	var kinder common.ErrorKinder
+	require.True(t, errors.As(err, &kinder))
+	require.Equal(t, common.DownstreamTimeoutError, kinder.ErrorKind())
+}
+
+type errorClientStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (s errorClientStream) Context() context.Context  { return context.Background() }
+func (s errorClientStream) RecvMsg(interface{}) error { return s.err }
+
+func TestGrpcDownstreamErrorStreamInterceptor(t *testing.T) {
+	ctx := testutil.NewTestContext()
+	desc := &grpc.StreamDesc{ServerStreams: true}
+
+	_, err := GrpcDownstreamErrorStreamInterceptor(ctx, desc, nil, "/test.TestService/Test",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, status.Error(codes.Unavailable, "down")
+		})
+	var kinder common.ErrorKinder
+	require.True(t, errors.As(err, &kinder))
+	require.Equal(t, common.DownstreamUnavailableError, kinder.ErrorKind())
+
+	newStream := func(recvErr error) grpc.ClientStream {
+		stream, err := GrpcDownstreamErrorStreamInterceptor(ctx, desc, nil, "/test.TestService/Test",
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return errorClientStream{err: recvErr}, nil
+			})
+		require.NoError(t, err)
+		return stream
+	}
+	err = newStream(status.Error(codes.PermissionDenied, "denied")).RecvMsg(nil)
+	require.True(t, errors.As(err, &kinder))
+	require.Equal(t, common.DownstreamUnauthorizedError, kinder.ErrorKind())
+	require.Equal(t, io.EOF, newStream(io.EOF).RecvMsg(nil), "the end of the stream is not mapped")
+}
@@ -0,0 +1,512 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/log"
+)
+
+const (
+	transcoderBufferSize = 1024 * 1024
+
+	// defaultTranscodedBodySize matches the default maximum size of a message received by a gRPC server.
+	defaultTranscodedBodySize = 4 * 1024 * 1024
+)
+
+// grpcJSONTranscoder exposes the unary methods of a gRPC server as REST/JSON endpoints.
+// Requests are translated into gRPC calls made over an in-process connection to the server,
+// so all gRPC interceptors (including authorization rules) apply to transcoded requests.
+// The server is dedicated to the transcoder: it is only served on the in-process listener.
+type grpcJSONTranscoder struct {
+	ctx         context.Context
+	server      *grpc.Server
+	lis         *bufconn.Listener
+	conn        *grpc.ClientConn
+	routes      []transcodedRoute
+	hooks       *Hooks
+	maxBodySize int64
+}
+
+// transcodedRoute describes a single REST binding of a gRPC method.
+type transcodedRoute struct {
+	httpMethod string
+	pattern    string            // chi route pattern
+	pathParams map[string]string // chi param name -> request field path
+	body       string            // "*", "" or a request field name
+	fullMethod string
+	input      protoreflect.MessageType
+	output     protoreflect.MessageType
+}
+
+func newGrpcJSONTranscoder(ctx context.Context, server *grpc.Server, cfg *config.GRPCServerConfig, hooks *Hooks) (*grpcJSONTranscoder, error) {
+	lis := bufconn.Listen(transcoderBufferSize)
+
+	// The connection never leaves the process so it is not encrypted, the server must be
+	// built with insecure credentials (see newTranscoderGrpcServer).
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	maxBodySize := cfg.JSONTranscodingMaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultTranscodedBodySize
+	}
+
+	return &grpcJSONTranscoder{
+		ctx:         ctx,
+		server:      server,
+		lis:         lis,
+		conn:        conn,
+		routes:      transcodedRoutes(ctx, server),
+		hooks:       hooks,
+		maxBodySize: maxBodySize,
+	}, nil
+}
+
+// newTranscoderGrpcServer builds the gRPC server called by the transcoder. It is built as the public
+// gRPC server but with insecure credentials, as it is only served on the in-process listener.
+func newTranscoderGrpcServer(ctx context.Context, m GrpcServerManager, hooks *Hooks) *grpc.Server {
+	opts := make([]grpc.ServerOption, 0, len(m.GrpcServerOptions)+1)
+	opts = append(opts, m.GrpcServerOptions...)
+	// The last credentials option wins over any TLS credentials of the public server.
+	m.GrpcServerOptions = append(opts, grpc.Creds(insecure.NewCredentials()))
+	return newPublicGrpcServer(ctx, m, hooks)
+}
+
+// transcodedRoutes collects the REST bindings for every unary method registered with the server.
+func transcodedRoutes(ctx context.Context, server *grpc.Server) []transcodedRoute {
+	info := server.GetServiceInfo()
+	names := make([]string, 0, len(info))
+	for name := range info {
+		// Skip the health and reflection services.
+		if !strings.HasPrefix(name, "grpc.") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var routes []transcodedRoute
+	for _, name := range names {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			log.Infof(ctx, "gRPC JSON transcoding: no descriptor found for service %s, skipping", name)
+			continue
+		}
+		sd, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			md := methods.Get(i)
+			if md.IsStreamingClient() || md.IsStreamingServer() {
+				log.Infof(ctx, "gRPC JSON transcoding: streaming method %s is not supported, skipping", md.FullName())
+				continue
+			}
+			routes = append(routes, methodRoutes(ctx, md)...)
+		}
+	}
+	return routes
+}
+
+// methodRoutes returns the REST bindings for the given method, using the google.api.http
+// annotation when present or a default POST /package.Service/Method binding otherwise.
+func methodRoutes(ctx context.Context, md protoreflect.MethodDescriptor) []transcodedRoute {
+	fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	base := transcodedRoute{
+		fullMethod: fullMethod,
+		input:      messageType(md.Input()),
+		output:     messageType(md.Output()),
+	}
+	defaultRoute := base
+	defaultRoute.httpMethod = http.MethodPost
+	defaultRoute.pattern = fullMethod
+	defaultRoute.body = "*"
+
+	rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+	if !ok || rule == nil || rule.GetPattern() == nil {
+		return []transcodedRoute{defaultRoute}
+	}
+
+	var routes []transcodedRoute
+	for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		route, err := routeFromHTTPRule(base, r)
+		if err != nil {
+			log.Infof(ctx, "gRPC JSON transcoding: unsupported google.api.http rule for %s: %s", fullMethod, err)
+			continue
+		}
+		routes = append(routes, route)
+	}
+	if len(routes) == 0 {
+		return []transcodedRoute{defaultRoute}
+	}
+	return routes
+}
+
+func routeFromHTTPRule(base transcodedRoute, rule *annotations.HttpRule) (transcodedRoute, error) {
+	var template string
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		base.httpMethod, template = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		base.httpMethod, template = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		base.httpMethod, template = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		base.httpMethod, template = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		base.httpMethod, template = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		base.httpMethod, template = strings.ToUpper(p.Custom.GetKind()), p.Custom.GetPath()
+	default:
+		return base, fmt.Errorf("unknown pattern")
+	}
+	pattern, params, err := parsePathTemplate(template)
+	if err != nil {
+		return base, err
+	}
+	base.pattern = pattern
+	base.pathParams = params
+	base.body = rule.GetBody()
+	return base, nil
+}
+
+// parsePathTemplate converts a google.api.http path template into a chi route pattern.
+// Supported variables are {field}, {field=*} and a trailing {field=**}.
+func parsePathTemplate(template string) (string, map[string]string, error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", nil, fmt.Errorf("path template %q must start with /", template)
+	}
+	segments := strings.Split(template[1:], "/")
+	params := map[string]string{}
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, "{") {
+			if strings.ContainsAny(seg, "{}:*") {
+				return "", nil, fmt.Errorf("unsupported path segment %q", seg)
+			}
+			continue
+		}
+		if !strings.HasSuffix(seg, "}") {
+			return "", nil, fmt.Errorf("unsupported path segment %q", seg)
+		}
+		field, sub, _ := strings.Cut(seg[1:len(seg)-1], "=")
+		name := fmt.Sprintf("p%d", len(params))
+		switch {
+		case sub == "" || sub == "*":
+			segments[i] = "{" + name + "}"
+		case sub == "**" && i == len(segments)-1:
+			segments[i] = "*"
+			name = "*"
+		default:
+			return "", nil, fmt.Errorf("unsupported path variable %q", seg)
+		}
+		params[name] = field
+	}
+	return "/" + strings.Join(segments, "/"), params, nil
+}
+
+func messageType(md protoreflect.MessageDescriptor) protoreflect.MessageType {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName()); err == nil {
+		return mt
+	}
+	return dynamicpb.NewMessageType(md)
+}
+
+// WireRoutes registers the transcoded routes with the given router.
+func (t *grpcJSONTranscoder) WireRoutes(ctx context.Context, r chi.Router) {
+	for _, route := range t.routes {
+		log.Infof(ctx, "gRPC JSON transcoding: %s %s -> %s", route.httpMethod, route.pattern, route.fullMethod)
+		r.Method(route.httpMethod, route.pattern, t.handler(route))
+	}
+}
+
+func (t *grpcJSONTranscoder) handler(route transcodedRoute) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		r.Body = http.MaxBytesReader(w, r.Body, t.maxBodySize)
+		req, err := buildTranscodedRequest(route, r)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				t.writeHTTPError(ctx, w, &common.HTTPError{
+					HTTPCode:    http.StatusRequestEntityTooLarge,
+					Code:        "RequestEntityTooLarge",
+					Description: fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit),
+				})
+				return
+			}
+			t.writeError(ctx, w, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+
+		ctx = metadata.NewOutgoingContext(ctx, metadataFromHeader(r.Header))
+		resp := route.output.New().Interface()
+		if err := t.conn.Invoke(ctx, route.fullMethod, req, resp); err != nil {
+			t.writeError(ctx, w, err)
+			return
+		}
+
+		b, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(resp)
+		if err != nil {
+			t.writeError(ctx, w, status.Error(codes.Internal, err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(b)
+	}
+}
+
+func buildTranscodedRequest(route transcodedRoute, r *http.Request) (proto.Message, error) {
+	msg := route.input.New()
+
+	if route.body != "" {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
+			if route.body != "*" {
+				// Wrap the body so that the named field is unmarshalled from it.
+				fd := msg.Descriptor().Fields().ByName(protoreflect.Name(route.body))
+				if fd == nil {
+					return nil, fmt.Errorf("unknown body field %s", route.body)
+				}
+				body = []byte(fmt.Sprintf("{%q:%s}", fd.JSONName(), body))
+			}
+			if err := (protojson.UnmarshalOptions{}).Unmarshal(body, msg.Interface()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for name, field := range route.pathParams {
+		if err := setFieldPath(msg, field, chi.URLParam(r, name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if route.body != "*" {
+		for key, values := range r.URL.Query() {
+			for _, value := range values {
+				// Query parameters that do not name a field are ignored, as they are by grpc-gateway.
+				if err := setFieldPath(msg, key, value); err != nil && !errors.Is(err, errUnknownField) {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return msg.Interface(), nil
+}
+
+// errUnknownField is returned from setFieldPath when the path does not name a field of the message.
+var errUnknownField = errors.New("unknown field")
+
+// setFieldPath sets the (possibly nested, dot-separated) field of msg from its string representation.
+func setFieldPath(msg protoreflect.Message, path, value string) error {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fields := msg.Descriptor().Fields()
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = fields.ByJSONName(name)
+		}
+		if fd == nil {
+			return fmt.Errorf("%w %s", errUnknownField, path)
+		}
+		if i < len(names)-1 {
+			if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+				return fmt.Errorf("field %s is not a message", path)
+			}
+			msg = msg.Mutable(fd).Message()
+			continue
+		}
+		v, err := parseScalar(fd, value)
+		if err != nil {
+			return fmt.Errorf("invalid value for field %s: %w", path, err)
+		}
+		if fd.IsList() {
+			msg.Mutable(fd).List().Append(v)
+		} else {
+			msg.Set(fd, v)
+		}
+	}
+	return nil
+}
+
+func parseScalar(fd protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(value)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(value, 10, 64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		i, err := strconv.ParseUint(value, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(i)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		i, err := strconv.ParseUint(value, 10, 64)
+		return protoreflect.ValueOfUint64(i), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(value, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(value, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.BytesKind:
+		b, err := base64.URLEncoding.DecodeString(value)
+		if err != nil {
+			b, err = base64.StdEncoding.DecodeString(value)
+		}
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(value)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		i, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), err
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+	}
+}
+
+// metadataFromHeader converts the HTTP request headers into outgoing gRPC metadata.
+func metadataFromHeader(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		key = strings.ToLower(key)
+		switch {
+		case strings.HasPrefix(key, "grpc-"):
+		case key == "connection", key == "content-length", key == "content-type", key == "host",
+			key == "keep-alive", key == "proxy-connection", key == "te", key == "transfer-encoding", key == "upgrade":
+		default:
+			md.Append(key, values...)
+		}
+	}
+	return md
+}
+
+func (t *grpcJSONTranscoder) writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+
+	// Statuses mapped from an error Kind (see common.MapGRPCError) are written as the REST handlers
+	// write errors of the same Kind, so that both transports report the same error codes.
+	kind, kinded := common.KindFromGRPCStatus(s)
+	if kinded {
+		err = common.CreateError(ctx, kind, s.Message(), err)
+	}
+
+	var httpError *common.HTTPError
+	if t.hooks != nil && t.hooks.MapError != nil {
+		httpError = t.hooks.MapError(ctx, err)
+	}
+	if httpError == nil {
+		if kinded {
+			e := common.MapError(ctx, err)
+			httpError = &e
+		} else {
+			httpError = &common.HTTPError{
+				HTTPCode:    httpStatusFromGrpcCode(s.Code()),
+				Code:        s.Code().String(),
+				Description: s.Message(),
+			}
+		}
+	}
+	t.writeHTTPError(ctx, w, httpError)
+}
+
+func (t *grpcJSONTranscoder) writeHTTPError(ctx context.Context, w http.ResponseWriter, httpError *common.HTTPError) {
+	if t.hooks != nil && t.hooks.WriteError != nil {
+		t.hooks.WriteError(ctx, w, httpError)
+	} else {
+		httpError.WriteError(ctx, w)
+	}
+}
+
+// httpStatusFromGrpcCode maps gRPC codes to HTTP statuses as per
+// https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto.
+func httpStatusFromGrpcCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Start serves the gRPC server on the in-process listener used by the transcoder.
+// The listener is closed when the gRPC server is stopped.
+func (t *grpcJSONTranscoder) Start() error {
+	return t.server.Serve(t.lis)
+}
+
+func (t *grpcJSONTranscoder) Stop() error {
+	err := t.conn.Close()
+	t.server.Stop()
+	return err
+}
+
+func (t *grpcJSONTranscoder) GracefulStop() error {
+	t.server.GracefulStop()
+	return t.conn.Close()
+}
+
+func (t *grpcJSONTranscoder) GetName() string {
+	return "gRPC JSON transcoder"
+}
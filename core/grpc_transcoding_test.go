@@ -0,0 +1,240 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/config"
+	test "github.com/anz-bank/sysl-go/core/testdata/proto"
+	"github.com/anz-bank/sysl-go/handlerinitialiser"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+type failingTestServer struct {
+	test.UnimplementedTestServiceServer
+}
+
+func (*failingTestServer) Test(ctx context.Context, req *test.TestRequest) (*test.TestReply, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return nil, status.Errorf(codes.NotFound, "%s not found for %s", req.GetField1(), md.Get("x-customer"))
+}
+
+func startTranscoder(t *testing.T, srv test.TestServiceServer) http.Handler {
+	server := grpc.NewServer()
+	test.RegisterTestServiceServer(server, srv)
+	return startTranscoderServer(t, server, &config.GRPCServerConfig{EnableJSONTranscoding: true})
+}
+
+func startTranscoderServer(t *testing.T, server *grpc.Server, cfg *config.GRPCServerConfig) http.Handler {
+	ctx := testutil.NewTestContext()
+
+	transcoder, err := newGrpcJSONTranscoder(ctx, server, cfg, nil)
+	require.NoError(t, err)
+	go func() { _ = transcoder.Start() }()
+	t.Cleanup(func() { _ = transcoder.Stop() })
+
+	r := chi.NewRouter()
+	transcoder.WireRoutes(ctx, r)
+	return r
+}
+
+func TestGrpcJSONTranscoder_DefaultRoute(t *testing.T) {
+	r := startTranscoder(t, &testServer{})
+
+	req := httptest.NewRequest(http.MethodPost, "/test.TestService/Test", strings.NewReader(`{"field1":"hello"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"field1":"hello"}`, w.Body.String())
+}
+
+func TestGrpcJSONTranscoder_Error(t *testing.T) {
+	r := startTranscoder(t, &failingTestServer{})
+
+	req := httptest.NewRequest(http.MethodPost, "/test.TestService/Test", strings.NewReader(`{"field1":"thing"}`))
+	req.Header.Set("X-Customer", "abc")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.JSONEq(t, `{"status":{"code":"NotFound","description":"thing not found for [abc]"}}`, w.Body.String())
+}
+
+func TestGrpcJSONTranscoder_BadRequest(t *testing.T) {
+	r := startTranscoder(t, &testServer{})
+
+	req := httptest.NewRequest(http.MethodPost, "/test.TestService/Test", strings.NewReader(`{"unknown":1}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGrpcJSONTranscoder_BodyTooLarge(t *testing.T) {
+	server := grpc.NewServer()
+	test.RegisterTestServiceServer(server, &testServer{})
+	r := startTranscoderServer(t, server, &config.GRPCServerConfig{EnableJSONTranscoding: true, JSONTranscodingMaxBodySize: 16})
+
+	req := httptest.NewRequest(http.MethodPost, "/test.TestService/Test", strings.NewReader(`{"field1":"hello"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	require.JSONEq(t, `{"status":{"code":"RequestEntityTooLarge","description":"request body exceeds 16 bytes"}}`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/test.TestService/Test", strings.NewReader(`{"field1":"hi"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGrpcJSONTranscoder_MutualTLS(t *testing.T) {
+	ctx := testutil.NewTestContext()
+
+	// The public server requires client certificates, which the in-process connection can't present.
+	m := GrpcServerManager{
+		GrpcServerOptions: []grpc.ServerOption{
+			grpc.Creds(credentials.NewTLS(&tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, MinVersion: tls.VersionTLS12})),
+		},
+		EnabledGrpcHandlers: []handlerinitialiser.GrpcHandlerInitialiser{&serverReg{methodsCalled: map[string]bool{}}},
+	}
+	server := newTranscoderGrpcServer(ctx, m, &Hooks{ShouldSetGrpcGlobalLogger: func() bool { return false }})
+	r := startTranscoderServer(t, server, &config.GRPCServerConfig{EnableJSONTranscoding: true})
+
+	req := httptest.NewRequest(http.MethodPost, "/test.TestService/Test", strings.NewReader(`{"field1":"hello"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"field1":"hello"}`, w.Body.String())
+}
+
+func TestParsePathTemplate(t *testing.T) {
+	pattern, params, err := parsePathTemplate("/v1/customers/{customer.id}/accounts/{account=*}")
+	require.NoError(t, err)
+	require.Equal(t, "/v1/customers/{p0}/accounts/{p1}", pattern)
+	require.Equal(t, map[string]string{"p0": "customer.id", "p1": "account"}, params)
+
+	pattern, params, err = parsePathTemplate("/v1/files/{name=**}")
+	require.NoError(t, err)
+	require.Equal(t, "/v1/files/*", pattern)
+	require.Equal(t, map[string]string{"*": "name"}, params)
+
+	for _, template := range []string{"v1/things", "/v1/{name=things/*}", "/v1/things:batchGet", "/v1/{name=**}/more"} {
+		_, _, err = parsePathTemplate(template)
+		require.Error(t, err, template)
+	}
+}
+
+func TestRouteFromHTTPRule(t *testing.T) {
+	route, err := routeFromHTTPRule(transcodedRoute{}, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/tests/{field1}"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.MethodGet, route.httpMethod)
+	require.Equal(t, "/v1/tests/{p0}", route.pattern)
+	require.Equal(t, "", route.body)
+
+	route, err = routeFromHTTPRule(transcodedRoute{}, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: "head", Path: "/v1/tests"}},
+		Body:    "*",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.MethodHead, route.httpMethod)
+	require.Equal(t, "*", route.body)
+}
+
+// annotatedTestMethod returns the descriptor of the TestService Test method, annotated with the given
+// google.api.http rule.
+func annotatedTestMethod(t *testing.T, rule *annotations.HttpRule) protoreflect.MethodDescriptor {
+	fdp := protodesc.ToFileDescriptorProto(test.File_core_testdata_proto_test_proto)
+	method := fdp.GetService()[0].GetMethod()[0]
+	method.Options = &descriptorpb.MethodOptions{}
+	proto.SetExtension(method.Options, annotations.E_Http, rule)
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	return fd.Services().Get(0).Methods().ByName("Test")
+}
+
+func TestGrpcJSONTranscoder_AnnotatedRoute(t *testing.T) {
+	ctx := testutil.NewTestContext()
+	server := grpc.NewServer()
+	test.RegisterTestServiceServer(server, &testServer{})
+	transcoder, err := newGrpcJSONTranscoder(ctx, server, &config.GRPCServerConfig{}, nil)
+	require.NoError(t, err)
+	go func() { _ = transcoder.Start() }()
+	defer func() {
+		_ = transcoder.Stop()
+		server.Stop()
+	}()
+
+	routes := methodRoutes(ctx, annotatedTestMethod(t, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/tests/{field1}"},
+		AdditionalBindings: []*annotations.HttpRule{{
+			Pattern: &annotations.HttpRule_Post{Post: "/v1/tests"},
+			Body:    "*",
+		}},
+	}))
+	require.Len(t, routes, 2)
+
+	r := chi.NewRouter()
+	for _, route := range routes {
+		require.Equal(t, "/test.TestService/Test", route.fullMethod)
+		r.Method(route.httpMethod, route.pattern, transcoder.handler(route))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/tests/world", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"field1":"world"}`, w.Body.String())
+
+	// Unknown query parameters are ignored, as they are by grpc-gateway.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/tests/world?field2=x", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"field1":"world"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/tests", strings.NewReader(`{"field1":"posted"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"field1":"posted"}`, w.Body.String())
+}
+
+// kindTestServer fails with errors mapped from their Kind, as the generated gRPC handlers do.
+type kindTestServer struct {
+	test.UnimplementedTestServiceServer
+}
+
+func (*kindTestServer) Test(ctx context.Context, _ *test.TestRequest) (*test.TestReply, error) {
+	return nil, common.MapGRPCError(ctx, &common.ServerError{Kind: common.UnauthorizedError}).Err()
+}
+
+func TestGrpcJSONTranscoder_KindError(t *testing.T) {
+	r := startTranscoder(t, &kindTestServer{})
+
+	// Errors are written with the same codes as REST handlers write for the same Kind.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test.TestService/Test", strings.NewReader(`{}`)))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	expected := common.MapError(context.Background(), &common.ServerError{Kind: common.UnauthorizedError})
+	require.JSONEq(t, `{"status":{"code":"`+expected.Code+`","description":"`+expected.Description+`"}}`, w.Body.String())
+}
@@ -14,9 +14,9 @@ import (
 
 	anzlog "github.com/anz-bank/sysl-go/log"
 
-	"github.com/anz-bank/pkg/health"
 	"github.com/anz-bank/sysl-go/config"
 	"github.com/anz-bank/sysl-go/handlerinitialiser"
+	"github.com/anz-bank/sysl-go/health"
 	"github.com/anz-bank/sysl-go/metrics"
 	"github.com/anz-bank/sysl-go/status"
 	"github.com/go-chi/chi"
@@ -37,7 +37,7 @@ type Manager interface {
 	AddAdminHTTPMiddleware() func(ctx context.Context, r chi.Router)
 }
 
-func configureAdminServerListener(ctx context.Context, hl Manager, promRegistry *prometheus.Registry, healthServer *health.HTTPServer, mWare []func(handler http.Handler) http.Handler) (StoppableServer, error) {
+func configureAdminServerListener(ctx context.Context, hl Manager, promRegistry *prometheus.Registry, healthServer *health.Server, logLevel *logLevelController, authPolicy *authorizationPolicy, mWare []func(handler http.Handler) http.Handler) (StoppableServer, error) {
 	// validate hl manager configuration
 	if hl.AdminServerConfig() == nil {
 		return nil, errors.New("missing adminserverconfig")
@@ -72,11 +72,17 @@ func configureAdminServerListener(ctx context.Context, hl Manager, promRegistry
 				r.Get("/", metrics.Handler(promRegistry).(http.HandlerFunc))
 			})
 		}
+		if logLevel != nil {
+			logLevel.register(r)
+		}
+		if authPolicy != nil {
+			authPolicy.register(r)
+		}
 		registerProfilingHandler(ctx, hl.LibraryConfig(), r)
 	})
 	adminRouter.Route("/", func(r chi.Router) {
 		if healthServer != nil {
-			healthServer.RegisterWith(r)
+			healthServer.RegisterHTTP(r)
 		}
 	})
 
@@ -85,7 +91,7 @@ func configureAdminServerListener(ctx context.Context, hl Manager, promRegistry
 	return listenAdmin, nil
 }
 
-func configurePublicServerListener(ctx context.Context, hl Manager, mWare []func(handler http.Handler) http.Handler, hooks *Hooks) (StoppableServer, error) {
+func configurePublicServerListener(ctx context.Context, hl Manager, mWare []func(handler http.Handler) http.Handler, hooks *Hooks, transcoder *grpcJSONTranscoder) (StoppableServer, error) {
 	rootPublicRouter, publicRouter := configureRouters("", mWare) // note basePath will be patched during the WireRoutes call below
 
 	publicTLSConfig, err := config.MakeTLSConfig(ctx, hl.PublicServerConfig().HTTP.Common.TLS)
@@ -97,7 +103,11 @@ func configurePublicServerListener(ctx context.Context, hl Manager, mWare []func
 		h.WireRoutes(ctx, publicRouter)
 	}
 
-	if len(hl.EnabledHandlers()) == 0 {
+	if transcoder != nil {
+		transcoder.WireRoutes(ctx, publicRouter)
+	}
+
+	if len(hl.EnabledHandlers()) == 0 && transcoder == nil {
 		anzlog.Info(ctx, "No service handlers enabled by config.")
 	}
 
@@ -97,7 +97,7 @@ func Test_prepareMiddleware(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			got := prepareMiddleware("server", tt.args.promRegistry, contextTimeout)
+			got := prepareMiddleware("server", tt.args.promRegistry, nil, nil, nil, nil, contextTimeout)
 			assert.NotEmpty(t, got)
 		})
 	}
@@ -503,9 +503,9 @@ func Test_configureAdminServerListener_Valid(t *testing.T) {
 		public: func() *config.UpstreamConfig { return &config.UpstreamConfig{ContextTimeout: contextTimeout} },
 	}
 
-	mWare := prepareMiddleware("test", nil, contextTimeout)
+	mWare := prepareMiddleware("test", nil, nil, nil, nil, nil, contextTimeout)
 
-	srv, err := configureAdminServerListener(ctx, manager, nil, nil, mWare.admin)
+	srv, err := configureAdminServerListener(ctx, manager, nil, nil, nil, nil, mWare.admin)
 	require.NotNil(t, srv)
 	require.NoError(t, err)
 
@@ -536,9 +536,9 @@ func Test_configureAdminServerListener_MissingLibraryConfig(t *testing.T) {
 		public: func() *config.UpstreamConfig { return &config.UpstreamConfig{ContextTimeout: contextTimeout} },
 	}
 
-	mWare := prepareMiddleware("test", nil, contextTimeout)
+	mWare := prepareMiddleware("test", nil, nil, nil, nil, nil, contextTimeout)
 
-	srv, err := configureAdminServerListener(ctx, manager, nil, nil, mWare.admin)
+	srv, err := configureAdminServerListener(ctx, manager, nil, nil, nil, nil, mWare.admin)
 	require.Nil(t, srv)
 	require.Error(t, err)
 }
@@ -566,9 +566,9 @@ func Test_configureAdminServerListener_MissingAdminConfig(t *testing.T) {
 		public: func() *config.UpstreamConfig { return &config.UpstreamConfig{ContextTimeout: contextTimeout} },
 	}
 
-	mWare := prepareMiddleware("test", nil, contextTimeout)
+	mWare := prepareMiddleware("test", nil, nil, nil, nil, nil, contextTimeout)
 
-	srv, err := configureAdminServerListener(ctx, manager, nil, nil, mWare.admin)
+	srv, err := configureAdminServerListener(ctx, manager, nil, nil, nil, nil, mWare.admin)
 	require.Nil(t, srv)
 	require.Error(t, err)
 }
@@ -601,7 +601,7 @@ func Test_configureAdminServerListener_MissingMiddlewareHandler(t *testing.T) {
 		public: func() *config.UpstreamConfig { return &config.UpstreamConfig{ContextTimeout: contextTimeout} },
 	}
 
-	srv, err := configureAdminServerListener(ctx, manager, nil, nil, nil)
+	srv, err := configureAdminServerListener(ctx, manager, nil, nil, nil, nil, nil)
 	require.NotNil(t, srv)
 	require.NoError(t, err)
 }
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/jwtauth"
+)
+
+type sharedJWTAuthenticatorKey struct{}
+
+// sharedJWTAuthenticator lazily builds the authenticator shared by all the authorization rules of a
+// server, such that the JWKS of the issuers are fetched and refreshed once per process.
+type sharedJWTAuthenticator struct {
+	hooks *Hooks
+
+	once          sync.Once
+	authenticator jwtauth.Authenticator
+	err           error
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+// withSharedJWTAuthenticator puts the authenticator shared by the authorization rules resolved
+// with the returned context into the context. The authenticator must be stopped with the server.
+func withSharedJWTAuthenticator(ctx context.Context, hooks *Hooks) (context.Context, *sharedJWTAuthenticator) {
+	shared := &sharedJWTAuthenticator{hooks: hooks}
+	return context.WithValue(ctx, sharedJWTAuthenticatorKey{}, shared), shared
+}
+
+// stop cancels the context the authenticator was built with, which stops the background refreshes
+// of the JWKS of the issuers.
+func (s *sharedJWTAuthenticator) stop() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// buildContext returns the context to build the authenticator with, cancelled when it is stopped.
+func (s *sharedJWTAuthenticator) buildContext(ctx context.Context) context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ctx, s.cancel = context.WithCancel(ctx)
+	if s.stopped {
+		s.cancel()
+	}
+	return ctx
+}
+
+// getJWTAuthenticator returns the authenticator of the authorization rule of the given endpoint.
+// The authenticator is shared when the context holds one (see withSharedJWTAuthenticator),
+// otherwise a new authenticator is built.
+func getJWTAuthenticator(ctx context.Context, hooks *Hooks, endpointName string) (jwtauth.Authenticator, error) {
+	shared, ok := ctx.Value(sharedJWTAuthenticatorKey{}).(*sharedJWTAuthenticator)
+	if !ok {
+		shared = &sharedJWTAuthenticator{hooks: hooks}
+	}
+	if shared.hooks == nil || shared.hooks.JWTAuthenticator == nil {
+		cfg := config.GetDefaultConfig(ctx)
+		if cfg == nil || cfg.Library.Authentication == nil || cfg.Library.Authentication.JWTAuth == nil {
+			return nil, fmt.Errorf("method/endpoint %s requires a JWT-based authorization rule, but there is no config for library.authentication.jwtauth", endpointName)
+		}
+	}
+	shared.once.Do(func() {
+		shared.authenticator, shared.err = shared.build(shared.buildContext(ctx))
+	})
+	return shared.authenticator, shared.err
+}
+
+func (s *sharedJWTAuthenticator) build(ctx context.Context) (jwtauth.Authenticator, error) {
+	if s.hooks != nil && s.hooks.JWTAuthenticator != nil {
+		authenticator, err := s.hooks.JWTAuthenticator(ctx)
+		if err == nil && authenticator == nil {
+			err = fmt.Errorf("Hooks.JWTAuthenticator returned no authenticator")
+		}
+		return authenticator, err
+	}
+
+	// TODO(fletcher) inject custom http client instrumented with monitoring
+	httpClient, err := config.DefaultHTTPClient(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpClientFactory := func(_ string) *http.Client {
+		return httpClient
+	}
+	authenticator, err := jwtauth.AuthFromConfig(ctx, config.GetDefaultConfig(ctx).Library.Authentication.JWTAuth, httpClientFactory)
+	if err != nil {
+		return nil, err
+	}
+	return authenticator, nil
+}
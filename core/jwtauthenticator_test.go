@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/jsontime"
+	"github.com/anz-bank/sysl-go/jwtauth"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+func TestSharedJWTAuthenticatorFromConfig(t *testing.T) {
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		_, _ = w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.DefaultConfig{}
+	cfg.Library.Authentication = &config.AuthenticationConfig{JWTAuth: &jwtauth.Config{Issuers: []jwtauth.IssuerConfig{{
+		Name: "issuer", JWKSURL: srv.URL, CacheTTL: jsontime.Duration(time.Minute),
+	}}}}
+	hooks := &Hooks{}
+	ctx, _ := withSharedJWTAuthenticator(config.PutDefaultConfig(testutil.NewTestContext(), cfg), hooks)
+
+	for _, name := range []string{"GET /a", "POST /b", "PUT /c"} {
+		_, err := ResolveRESTAuthorizationRule(ctx, hooks, name, `jwtHasScope("a")`)
+		require.NoError(t, err)
+	}
+	_, err := ResolveGRPCAuthorizationRule(ctx, hooks, "Service.Method", `jwtHasScope("a")`)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestSharedJWTAuthenticatorFromHook(t *testing.T) {
+	var calls int
+	hooks := &Hooks{
+		JWTAuthenticator: func(ctx context.Context) (jwtauth.Authenticator, error) {
+			calls++
+			return jwtauth.InsecureAuthenticator{}, nil
+		},
+	}
+	ctx := config.PutDefaultConfig(testutil.NewTestContext(), &config.DefaultConfig{})
+
+	// Without a shared authenticator in the context, one is built per rule.
+	_, err := ResolveRESTAuthorizationRule(ctx, hooks, "GET /a", `jwtHasScope("a")`)
+	require.NoError(t, err)
+	_, err = ResolveRESTAuthorizationRule(ctx, hooks, "GET /b", `jwtHasScope("a")`)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+
+	calls = 0
+	ctx, _ = withSharedJWTAuthenticator(ctx, hooks)
+	_, err = ResolveRESTAuthorizationRule(ctx, hooks, "GET /a", `jwtHasScope("a")`)
+	require.NoError(t, err)
+	_, err = ResolveGRPCAuthorizationRule(ctx, hooks, "Service.Method", `jwtHasScope("a")`)
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestSharedJWTAuthenticatorStop(t *testing.T) {
+	var buildCtx context.Context
+	hooks := &Hooks{
+		JWTAuthenticator: func(ctx context.Context) (jwtauth.Authenticator, error) {
+			buildCtx = ctx
+			return jwtauth.InsecureAuthenticator{}, nil
+		},
+	}
+	ctx, shared := withSharedJWTAuthenticator(config.PutDefaultConfig(testutil.NewTestContext(), &config.DefaultConfig{}), hooks)
+	_, err := ResolveRESTAuthorizationRule(ctx, hooks, "GET /a", `jwtHasScope("a")`)
+	require.NoError(t, err)
+	require.NoError(t, buildCtx.Err())
+
+	// Stopping the server cancels the context of the authenticator, stopping its background refreshes.
+	shared.stop()
+	require.ErrorIs(t, buildCtx.Err(), context.Canceled)
+	(*sharedJWTAuthenticator)(nil).stop()
+}
+
+func TestSharedJWTAuthenticatorMissingConfig(t *testing.T) {
+	ctx, _ := withSharedJWTAuthenticator(config.PutDefaultConfig(testutil.NewTestContext(), &config.DefaultConfig{}), &Hooks{})
+	_, err := ResolveRESTAuthorizationRule(ctx, &Hooks{}, "GET /a", `jwtHasScope("a")`)
+	require.EqualError(t, err, "method/endpoint GET /a requires a JWT-based authorization rule, but there is no config for library.authentication.jwtauth")
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/core/authrules"
+	"github.com/anz-bank/sysl-go/log"
+)
+
+const defaultLogLevelOverrideHeader = "X-Log-Level"
+
+// logLevelController changes the level of the logger at runtime through the /-/loglevel admin endpoint.
+type logLevelController struct {
+	level        *log.AtomicLevel
+	defaultLevel log.Level
+
+	// authorizationRule, when set, must be satisfied by requests changing the level.
+	authorizationRule authrules.Rule
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	revertAt time.Time
+}
+
+// withAtomicLogLevel wraps the logger in the context such that its level can be changed at runtime.
+func withAtomicLogLevel(ctx context.Context, defaultConfig *config.DefaultConfig) (context.Context, *logLevelController) {
+	level := log.InfoLevel
+	if defaultConfig.Library.Log.Level != 0 {
+		level = defaultConfig.Library.Log.Level
+	}
+	c := &logLevelController{level: log.NewAtomicLevel(level), defaultLevel: level}
+	return log.PutLogger(ctx, log.NewAtomicLevelLogger(log.GetLogger(ctx), c.level)), c
+}
+
+type logLevelResponse struct {
+	Level        string     `json:"level"`
+	DefaultLevel string     `json:"defaultLevel"`
+	RevertAt     *time.Time `json:"revertAt,omitempty"`
+}
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+
+	// TTL is the duration after which the level reverts to the default level, for example 10m.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// setLevel changes the level, reverting to the default level after the ttl when not zero.
+func (c *logLevelController) setLevel(level log.Level, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.revertAt = time.Time{}
+	c.level.SetLevel(level)
+	if ttl > 0 {
+		c.revertAt = time.Now().Add(ttl)
+		var timer *time.Timer
+		timer = time.AfterFunc(ttl, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.timer == timer {
+				c.level.SetLevel(c.defaultLevel)
+				c.timer = nil
+				c.revertAt = time.Time{}
+			}
+		})
+		c.timer = timer
+	}
+}
+
+func (c *logLevelController) state() logLevelResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp := logLevelResponse{Level: c.level.Level().String(), DefaultLevel: c.defaultLevel.String()}
+	if !c.revertAt.IsZero() {
+		revertAt := c.revertAt
+		resp.RevertAt = &revertAt
+	}
+	return resp
+}
+
+func (c *logLevelController) register(r chi.Router) {
+	r.Get("/loglevel", c.get)
+	r.Put("/loglevel", c.put)
+}
+
+func (c *logLevelController) get(w http.ResponseWriter, r *http.Request) {
+	c.writeState(w)
+}
+
+func (c *logLevelController) put(w http.ResponseWriter, r *http.Request) {
+	if c.authorizationRule != nil {
+		if _, err := c.authorizationRule(common.RequestHeaderToContext(r.Context(), r.Header)); err != nil {
+			log.Debugf(r.Context(), "log level change not authorized: %v", err)
+			http.Error(w, "not authorized to change the log level", http.StatusForbidden)
+			return
+		}
+	}
+	var req logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	level, err := log.ParseLevel(req.Level)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+			http.Error(w, "invalid ttl: "+req.TTL, http.StatusBadRequest)
+			return
+		}
+	}
+	c.setLevel(level, ttl)
+	log.Infof(r.Context(), "log level changed to %s (ttl: %s)", level, ttl)
+	c.writeState(w)
+}
+
+func (c *logLevelController) writeState(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c.state())
+}
+
+// resolveLogLevelOverrideRule resolves the library.log.levelOverride authorization rule, which
+// authorizes both the override header and changes through the /-/loglevel endpoint.
+// Returns nil when no authorization rule has been configured.
+func resolveLogLevelOverrideRule(ctx context.Context, hooks *Hooks) (authrules.Rule, error) {
+	cfg := config.GetDefaultConfig(ctx)
+	if cfg == nil || cfg.Library.Log.LevelOverride.AuthorizationRule == "" {
+		return nil, nil
+	}
+	if hooks == nil {
+		hooks = &Hooks{}
+	}
+	return resolveAuthorizationRule(ctx, hooks, "log level override", cfg.Library.Log.LevelOverride.AuthorizationRule, authrules.MakeRESTJWTAuthorizationRule)
+}
+
+// newLogLevelOverrideMiddleware returns the middleware that sets the log level of a request from
+// the library.log.levelOverride header, when the request satisfies the authorization rule.
+// Returns nil when the rule is nil.
+func newLogLevelOverrideMiddleware(ctx context.Context, rule authrules.Rule) func(http.Handler) http.Handler {
+	if rule == nil {
+		return nil
+	}
+	header := defaultLogLevelOverrideHeader
+	if cfg := config.GetDefaultConfig(ctx); cfg != nil && cfg.Library.Log.LevelOverride.Header != "" {
+		header = cfg.Library.Log.LevelOverride.Header
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := r.Header.Get(header)
+			if value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			level, err := log.ParseLevel(value)
+			if err != nil {
+				log.Debugf(ctx, "ignoring %s header: %v", header, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if _, err := rule(common.RequestHeaderToContext(ctx, r.Header)); err != nil {
+				log.Debugf(ctx, "ignoring %s header, request not authorized: %v", header, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(log.WithLevel(ctx, level)))
+		})
+	}
+}
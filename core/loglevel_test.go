@@ -0,0 +1,128 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+func TestLogLevelController(t *testing.T) {
+	ctx, logger := testutil.NewTestContextWithLogger()
+	cfg := &config.DefaultConfig{}
+	cfg.Library.Log.Level = log.InfoLevel
+	ctx, c := withAtomicLogLevel(ctx, cfg)
+
+	r := chi.NewRouter()
+	r.Route("/-", c.register)
+	do := func(method, body string) (int, logLevelResponse) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/-/loglevel", strings.NewReader(body)).WithContext(ctx))
+		var resp logLevelResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		}
+		return w.Code, resp
+	}
+
+	code, resp := do(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, logLevelResponse{Level: "info", DefaultLevel: "info"}, resp)
+
+	log.Debug(ctx, "hidden")
+	count := logger.EntryCount()
+
+	code, resp = do(http.MethodPut, `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "debug", resp.Level)
+	require.Nil(t, resp.RevertAt)
+	log.Debug(ctx, "shown")
+	require.Equal(t, "shown", logger.LastEntry().Message)
+	require.Greater(t, logger.EntryCount(), count)
+
+	code, _ = do(http.MethodPut, `{"level":"verbose"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodPut, `{"level":"debug","ttl":"soon"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, resp = do(http.MethodPut, `{"level":"error","ttl":"10ms"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "error", resp.Level)
+	require.NotNil(t, resp.RevertAt)
+	require.Eventually(t, func() bool {
+		return c.level.Level() == log.InfoLevel
+	}, time.Second, 5*time.Millisecond)
+	_, resp = do(http.MethodGet, "")
+	require.Nil(t, resp.RevertAt)
+}
+
+func TestLogLevelOverrideMiddleware(t *testing.T) {
+	ctx, logger := testutil.NewTestContextWithLogger()
+	cfg := &config.DefaultConfig{}
+	ctx = config.PutDefaultConfig(ctx, cfg)
+
+	rule, err := resolveLogLevelOverrideRule(ctx, nil)
+	require.NoError(t, err)
+	require.Nil(t, rule)
+	require.Nil(t, newLogLevelOverrideMiddleware(ctx, rule))
+
+	cfg.Library.Log.LevelOverride.AuthorizationRule = "true"
+	_, err = resolveLogLevelOverrideRule(ctx, &Hooks{})
+	require.Error(t, err) // no authentication configured
+
+	cfg.Development = &config.DevelopmentConfig{DisableAllAuthorizationRules: true}
+	rule, err = resolveLogLevelOverrideRule(ctx, &Hooks{})
+	require.NoError(t, err)
+	m := newLogLevelOverrideMiddleware(ctx, rule)
+	require.NotNil(t, m)
+
+	handler := m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Debug(r.Context(), "debug")
+	}))
+	count := logger.EntryCount()
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, count, logger.EntryCount())
+
+	req.Header.Set(defaultLogLevelOverrideHeader, "debug")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, "debug", logger.LastEntry().Message)
+}
+
+func TestLogLevelControllerAuthorization(t *testing.T) {
+	ctx := testutil.NewTestContext()
+	ctx, c := withAtomicLogLevel(ctx, &config.DefaultConfig{})
+	c.authorizationRule = func(ctx context.Context) (context.Context, error) {
+		if common.RequestHeaderFromContext(ctx).Get("Authorization") != "Bearer admin" {
+			return nil, errors.New("not admin")
+		}
+		return ctx, nil
+	}
+
+	r := chi.NewRouter()
+	r.Route("/-", c.register)
+	put := func(header http.Header) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/-/loglevel", strings.NewReader(`{"level":"debug"}`)).WithContext(ctx)
+		req.Header = header
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusForbidden, put(http.Header{}))
+	require.Equal(t, log.InfoLevel, c.level.Level())
+	require.Equal(t, http.StatusOK, put(http.Header{"Authorization": {"Bearer admin"}}))
+	require.Equal(t, log.DebugLevel, c.level.Level())
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/log"
+)
+
+// withLogSampling wraps the logger in the context with a log.SamplingLogger when enabled through
+// library.log.sampling, returning nil otherwise.
+func withLogSampling(ctx context.Context, defaultConfig *config.DefaultConfig) (context.Context, *log.SamplingLogger) {
+	cfg := defaultConfig.Library.Log.Sampling
+	if !cfg.Enabled {
+		return ctx, nil
+	}
+	logger := log.NewSamplingLogger(log.GetLogger(ctx), cfg)
+	return log.PutLogger(ctx, logger), logger
+}
+
+// registerLogSamplingMetrics registers the log_messages_dropped_total counters, by reason.
+func registerLogSamplingMetrics(registry prometheus.Registerer, logger *log.SamplingLogger) error {
+	for reason, value := range map[string]func(log.SamplingStats) uint64{
+		"sampled":      func(s log.SamplingStats) uint64 { return s.Sampled },
+		"rate_limited": func(s log.SamplingStats) uint64 { return s.RateLimited },
+	} {
+		value := value
+		err := registry.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "log_messages_dropped_total",
+			Help:        "Log messages dropped by sampling or rate limiting",
+			ConstLabels: prometheus.Labels{"reason": reason},
+		}, func() float64 { return float64(value(logger.Stats())) }))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
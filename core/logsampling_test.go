@@ -0,0 +1,37 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+func TestWithLogSampling(t *testing.T) {
+	ctx, logger := testutil.NewTestContextWithLogger()
+	cfg := &config.DefaultConfig{}
+
+	_, sampling := withLogSampling(ctx, cfg)
+	require.Nil(t, sampling)
+
+	cfg.Library.Log.Sampling = log.SamplingConfig{Enabled: true, Initial: 1}
+	ctx, sampling = withLogSampling(ctx, cfg)
+	require.NotNil(t, sampling)
+	log.Info(ctx, "message")
+	log.Info(log.WithStr(ctx, "key", "value"), "message")
+	require.Equal(t, 1, logger.EntryCount())
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registerLogSamplingMetrics(registry, sampling))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, m := range families[0].GetMetric() {
+		values[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+	}
+	require.Equal(t, map[string]float64{"sampled": 1, "rate_limited": 0}, values)
+}
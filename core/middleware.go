@@ -7,7 +7,9 @@ import (
 
 	"github.com/anz-bank/sysl-go/common"
 	"github.com/anz-bank/sysl-go/metrics"
+	"github.com/anz-bank/sysl-go/tracing"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type middlewareCollection struct {
@@ -15,16 +17,28 @@ type middlewareCollection struct {
 	public []func(handler http.Handler) http.Handler
 }
 
-func prepareMiddleware(name string, promRegistry *prometheus.Registry, contextTimeout time.Duration) middlewareCollection {
+func prepareMiddleware(name string, promRegistry *prometheus.Registry, metricsConfig *metrics.HTTPServerConfig, tracerProvider trace.TracerProvider, accessLog *accessLogger, logLevelOverride func(http.Handler) http.Handler, contextTimeout time.Duration) middlewareCollection {
 	result := middlewareCollection{}
 	result.addToBoth(Recoverer)
+	if tracerProvider != nil {
+		result.public = append(result.public, tracing.NewHTTPServerMiddleware(tracerProvider))
+	}
 	result.addToBoth(common.Timeout(contextTimeout, http.HandlerFunc(timeoutHandler)))
 
 	result.public = append(result.public, common.TraceabilityMiddleware)
 	result.addToBoth(common.CoreRequestContextMiddleware)
+	if tracerProvider != nil {
+		result.public = append(result.public, traceLogFieldsMiddleware)
+	}
+	if logLevelOverride != nil {
+		result.public = append(result.public, logLevelOverride)
+	}
+	if accessLog != nil {
+		result.public = append(result.public, accessLog.middleware)
+	}
 
 	if promRegistry != nil {
-		metricsMiddleware := metrics.NewHTTPServerMetricsMiddleware(promRegistry, name, metrics.GetChiPathPattern)
+		metricsMiddleware := metrics.NewHTTPServerMetricsMiddlewareWithConfig(promRegistry, name, metrics.GetChiPathPattern, metricsConfig)
 		result.addToBoth(metricsMiddleware)
 	}
 
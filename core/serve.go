@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"reflect"
 	"sort"
@@ -14,15 +15,18 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/afero"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
+	"google.golang.org/grpc"
 
-	pkgHealth "github.com/anz-bank/pkg/health"
 	pkg "github.com/anz-bank/pkg/log"
 	zero "github.com/anz-bank/pkg/logging"
 	"github.com/anz-bank/sysl-go/config"
 	"github.com/anz-bank/sysl-go/health"
 	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/metrics"
+	"github.com/anz-bank/sysl-go/tracing"
 	"github.com/anz-bank/sysl-go/validator"
 )
 
@@ -69,6 +73,9 @@ func validateConfig(ctx context.Context, hooks *Hooks, conf *config.DefaultConfi
 			return err
 		}
 	}
+	if err := conf.Library.Metrics.HTTPServer.Validate(); err != nil {
+		return err
+	}
 	return validator.Validate(conf)
 }
 
@@ -129,10 +136,18 @@ func NewTemporalWorker[
 		return nil, err
 	}
 
+	ctx, tracerProvider, err := initTracing(ctx, hooks, defaultConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	clientOptions := client.Options{
 		HostPort:  defaultConfig.GenCode.Upstream.Temporal.HostPort,
 		Namespace: defaultConfig.GenCode.Upstream.Temporal.Namespace,
 	}
+	if err = addTemporalTracingInterceptor(ctx, &clientOptions); err != nil {
+		return nil, err
+	}
 
 	if hooks.ExperimentalValidateTemporalClientOptions != nil {
 		if err = hooks.ExperimentalValidateTemporalClientOptions(ctx, &clientOptions); err != nil {
@@ -177,6 +192,8 @@ func NewTemporalWorker[
 			serviceIntf,
 			downstreamClients,
 		),
+		ctx:            ctx,
+		tracerProvider: tracerProvider,
 	}, nil
 }
 
@@ -293,6 +310,11 @@ func NewServer(
 	// Put the default configuration in the context.
 	ctx = config.PutDefaultConfig(ctx, defaultConfig)
 
+	// Put the registry of the health checks of dependencies in the context, for use by the
+	// create-service callback and the downstream clients.
+	healthRegistry := health.NewRegistry()
+	ctx = health.PutRegistry(ctx, healthRegistry)
+
 	// Create the service by calling the create-service callback.
 	createServiceResult := reflect.ValueOf(createService).Call(
 		[]reflect.Value{reflect.ValueOf(ctx), appConfig},
@@ -321,18 +343,64 @@ func NewServer(
 		ctx = log.PutLogger(ctx, logger)
 	}
 
+	// Authorization audit entries bypass the sampling and level of the logger.
+	ctx = withAuditLogger(ctx)
+
+	// Sample within the level filtering, so that disabled messages are not counted by the sampler.
+	ctx, samplingLogger := withLogSampling(ctx, defaultConfig)
 	ctx = withLogLevel(ctx, defaultConfig)
+	var logLevel *logLevelController
+	if admin != nil {
+		ctx, logLevel = withAtomicLogLevel(ctx, defaultConfig)
+	}
+
+	ctx, tracerProvider, err := initTracing(ctx, hooks, defaultConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	// Collect prometheus metrics if the admin server is enabled.
 	var promRegistry *prometheus.Registry
 	if admin != nil {
 		promRegistry = prometheus.NewRegistry()
+		ctx = metrics.PutRegistry(ctx, promRegistry)
+		if samplingLogger != nil {
+			if err = registerLogSamplingMetrics(promRegistry, samplingLogger); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Share a single JWT authenticator between all the authorization rules of the server.
+	ctx, jwtAuthenticator := withSharedJWTAuthenticator(ctx, hooks)
+
+	ctx, authPolicy, err := withAuthorizationPolicy(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	manager, grpcManager, err := newManagers(ctx, serviceIntf, hooks)
 	if err != nil {
 		return nil, err
 	}
+	if authPolicy != nil {
+		if err = authPolicy.validateEndpoints(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Compile the admin.auth policies up front so that invalid configuration is reported here.
+	var adminAuth func(http.Handler) http.Handler
+	if manager != nil && manager.AdminServerConfig() != nil {
+		adminAuth, err = newAdminAuthMiddleware(ctx, hooks, manager.AdminServerConfig().BasePath)
+		if err != nil {
+			return nil, err
+		}
+		// The authorization rules must not be reloadable by anyone who can reach the admin server.
+		if authPolicy != nil && adminAuth == nil {
+			return nil, errors.New("library.authentication.ruleFile requires admin.auth to protect the authorization rule reload endpoint of the admin server")
+		}
+	}
 
 	server := &autogenServer{
 		ctx:                ctx,
@@ -340,6 +408,12 @@ func NewServer(
 		restManager:        manager,
 		grpcServerManager:  grpcManager,
 		prometheusRegistry: promRegistry,
+		tracerProvider:     tracerProvider,
+		logLevel:           logLevel,
+		authPolicy:         authPolicy,
+		jwtAuthenticator:   jwtAuthenticator,
+		adminAuth:          adminAuth,
+		healthRegistry:     healthRegistry,
 		multiServer:        nil,
 		hooks:              hooks,
 	}
@@ -592,6 +666,12 @@ type autogenServer struct {
 	restManager        Manager
 	grpcServerManager  *GrpcServerManager
 	prometheusRegistry *prometheus.Registry
+	tracerProvider     *sdktrace.TracerProvider
+	logLevel           *logLevelController
+	authPolicy         *authorizationPolicy
+	jwtAuthenticator   *sharedJWTAuthenticator
+	adminAuth          func(http.Handler) http.Handler
+	healthRegistry     *health.Registry
 	multiServer        StoppableServer
 	hooks              *Hooks
 	m                  sync.Mutex // protect access to multiServer
@@ -610,13 +690,24 @@ func (s *autogenServer) Start() error {
 	if contextTimeout == 0 {
 		contextTimeout = defaultContextTimeout
 	}
-	mWare := prepareMiddleware(s.name, s.prometheusRegistry, contextTimeout)
+	var metricsConfig *metrics.HTTPServerConfig
+	if s.restManager != nil && s.restManager.LibraryConfig() != nil {
+		metricsConfig = &s.restManager.LibraryConfig().Metrics.HTTPServer
+	}
+	tp := tracing.GetTracerProvider(ctx)
+	logLevelRule, err := resolveLogLevelOverrideRule(ctx, s.hooks)
+	if err != nil {
+		return err
+	}
+	if s.logLevel != nil {
+		s.logLevel.authorizationRule = logLevelRule
+	}
+	mWare := prepareMiddleware(s.name, s.prometheusRegistry, metricsConfig, tp, newAccessLogger(ctx), newLogLevelOverrideMiddleware(ctx, logLevelRule), contextTimeout)
 
 	// load health server
 	var healthServer *health.Server
-	var err error
 	if s.restManager != nil && s.restManager.LibraryConfig() != nil && s.restManager.LibraryConfig().Health {
-		healthServer, err = health.NewServer()
+		healthServer, err = health.NewServerWithRegistry(s.healthRegistry)
 		if err != nil {
 			return err
 		}
@@ -626,15 +717,18 @@ func (s *autogenServer) Start() error {
 	var restIsRunning, grpcIsRunning bool
 
 	servers := make([]StoppableServer, 0)
+	if s.tracerProvider != nil {
+		servers = append(servers, newTracerProviderServer(ctx, s.tracerProvider))
+	}
 
 	// Make the listener function for the REST Admin server
 	if s.restManager != nil && s.restManager.AdminServerConfig() != nil {
 		log.Info(ctx, "found AdminServerConfig for REST")
-		var healthHTTPServer *pkgHealth.HTTPServer
-		if healthServer != nil {
-			healthHTTPServer = healthServer.HTTP
+		adminMWare := mWare.admin
+		if s.adminAuth != nil {
+			adminMWare = append(append([]func(http.Handler) http.Handler{}, mWare.admin...), s.adminAuth)
 		}
-		serverAdmin, err := configureAdminServerListener(ctx, s.restManager, s.prometheusRegistry, healthHTTPServer, mWare.admin)
+		serverAdmin, err := configureAdminServerListener(ctx, s.restManager, s.prometheusRegistry, healthServer, s.logLevel, s.authPolicy, adminMWare)
 		if err != nil {
 			return err
 		}
@@ -643,10 +737,38 @@ func (s *autogenServer) Start() error {
 		log.Info(ctx, "no AdminServerConfig for REST was found")
 	}
 
+	// Build the gRPC Public server ahead of the REST Public server so that its methods can be transcoded.
+	var grpcPublicServer *grpc.Server
+	var transcoder *grpcJSONTranscoder
+	if s.grpcServerManager != nil && s.grpcServerManager.GrpcPublicServerConfig != nil && len(s.grpcServerManager.EnabledGrpcHandlers) > 0 {
+		grpcServerManager := *s.grpcServerManager
+		if s.prometheusRegistry != nil {
+			grpcServerManager.GrpcServerOptions = append(
+				metrics.NewGRPCServerMetrics(s.prometheusRegistry, s.name).ServerOptions(),
+				grpcServerManager.GrpcServerOptions...)
+		}
+		if tp != nil {
+			grpcServerManager.GrpcServerOptions = withGRPCTracing(tp, grpcServerManager.GrpcServerOptions)
+		}
+		grpcPublicServer = newPublicGrpcServer(ctx, grpcServerManager, s.hooks)
+		if s.grpcServerManager.GrpcPublicServerConfig.EnableJSONTranscoding {
+			if s.restManager != nil && s.restManager.PublicServerConfig() != nil {
+				transcoderServer := newTranscoderGrpcServer(ctx, grpcServerManager, s.hooks)
+				transcoder, err = newGrpcJSONTranscoder(ctx, transcoderServer, s.grpcServerManager.GrpcPublicServerConfig, s.hooks)
+				if err != nil {
+					return err
+				}
+				servers = append(servers, transcoder)
+			} else {
+				log.Info(ctx, "gRPC JSON transcoding requires a PublicServerConfig for REST, transcoding disabled")
+			}
+		}
+	}
+
 	// Make the listener function for the REST Public server
 	if s.restManager != nil && s.restManager.PublicServerConfig() != nil {
 		log.Info(ctx, "found PublicServerConfig for REST")
-		serverPublic, err := configurePublicServerListener(ctx, s.restManager, mWare.public, s.hooks)
+		serverPublic, err := configurePublicServerListener(ctx, s.restManager, mWare.public, s.hooks, transcoder)
 		if err != nil {
 			return err
 		}
@@ -657,9 +779,9 @@ func (s *autogenServer) Start() error {
 	}
 
 	// Make the listener function for the gRPC Public server.
-	if s.grpcServerManager != nil && s.grpcServerManager.GrpcPublicServerConfig != nil && len(s.grpcServerManager.EnabledGrpcHandlers) > 0 {
+	if grpcPublicServer != nil {
 		log.Info(ctx, "found GrpcPublicServerConfig for gRPC")
-		serverPublicGrpc := configurePublicGrpcServerListener(ctx, *s.grpcServerManager, s.hooks)
+		serverPublicGrpc := preparePublicGrpcServerListener(ctx, grpcPublicServer, *s.grpcServerManager, s.hooks)
 		servers = append(servers, serverPublicGrpc)
 		grpcIsRunning = true
 	} else {
@@ -705,6 +827,7 @@ func (e MultiError) Error() string {
 func (s *autogenServer) Stop() error {
 	s.m.Lock()
 	defer s.m.Unlock()
+	s.jwtAuthenticator.stop()
 
 	if s.multiServer == nil {
 		return nil
@@ -716,6 +839,7 @@ func (s *autogenServer) Stop() error {
 func (s *autogenServer) GracefulStop() error {
 	s.m.Lock()
 	defer s.m.Unlock()
+	s.jwtAuthenticator.stop()
 
 	if s.multiServer == nil {
 		return nil
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -72,6 +74,73 @@ func TestNewServerReturnsErrorIfValidateConfigReturnsError(t *testing.T) {
 	assert.EqualError(t, err, errString)
 }
 
+func TestNewServerReturnsErrorIfAdminAuthIsInvalid(t *testing.T) {
+	// Override sysl-go app command line interface to directly pass in app config
+	ctx := WithConfigFile(context.Background(), []byte(`
+admin:
+  contextTimeout: 1s
+  http:
+    readTimeout: 1s
+    writeTimeout: 1s
+  auth:
+    routes:
+      - path: "/-/["
+        open: true
+`))
+
+	srv, err := NewServer(
+		ctx,
+		&struct{}{},
+		func(ctx context.Context, config TestAppConfig) (*TestServiceInterface, *Hooks, error) {
+			return &TestServiceInterface{}, nil, nil
+		},
+		&TestServiceInterface{},
+		func(ctx context.Context, serviceIntf interface{}, _ *Hooks) (Manager, *GrpcServerManager, error) {
+			cfg := config.GetDefaultConfig(ctx)
+			return NewHTTPManagerShim(&cfg.Library, &cfg.Admin.HTTP, nil, nil, nil), nil, nil
+		},
+	)
+	assert.Nil(t, srv)
+	assert.ErrorContains(t, err, "invalid admin.auth route path /-/[")
+}
+
+func TestNewServerReturnsErrorIfRuleFileHasNoAdminAuth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+	newServer := func(adminAuth string) (StoppableServer, error) {
+		return NewServer(
+			WithConfigFile(context.Background(), []byte(`
+library:
+  authentication:
+    ruleFile:
+      path: `+path+`
+admin:
+  contextTimeout: 1s
+  http:
+    readTimeout: 1s
+    writeTimeout: 1s
+`+adminAuth)),
+			&struct{}{},
+			func(ctx context.Context, config TestAppConfig) (*TestServiceInterface, *Hooks, error) {
+				return &TestServiceInterface{}, nil, nil
+			},
+			&TestServiceInterface{},
+			func(ctx context.Context, serviceIntf interface{}, _ *Hooks) (Manager, *GrpcServerManager, error) {
+				cfg := config.GetDefaultConfig(ctx)
+				return NewHTTPManagerShim(&cfg.Library, &cfg.Admin.HTTP, nil, nil, nil), nil, nil
+			},
+		)
+	}
+
+	srv, err := newServer("")
+	assert.Nil(t, srv)
+	assert.ErrorContains(t, err, "library.authentication.ruleFile requires admin.auth")
+
+	srv, err = newServer("  auth:\n    default:\n      bearerTokens: [token]\n")
+	assert.NoError(t, err)
+	assert.NotNil(t, srv)
+}
+
 // Test a new server initialises a logger.
 func TestNewServerInitialisesLogger(t *testing.T) {
 	ctx, err := newServerContext(context.Background())
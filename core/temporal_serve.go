@@ -1,14 +1,27 @@
 package core
 
-import "go.temporal.io/sdk/worker"
+import (
+	"context"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.temporal.io/sdk/worker"
+)
 
 type TemporalServer[Spec any] struct {
 	Spec TemporalServiceSpec[Spec]
+
+	ctx            context.Context
+	tracerProvider *sdktrace.TracerProvider // shut down by Start once the worker stops, if set
 }
 
 func (t *TemporalServer[Spec]) Start() error {
 	t.Spec.Register()
-	return t.Spec.Run(worker.InterruptCh())
+	// Run returns once the worker stops, whether interrupted or stopped through Stop.
+	err := t.Spec.Run(worker.InterruptCh())
+	if t.tracerProvider != nil {
+		_ = t.tracerProvider.Shutdown(t.ctx)
+	}
+	return err
 }
 
 func (t *TemporalServer[Spec]) Stop() error {
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/tracing"
+)
+
+// initTracing puts the TracerProvider into the context when tracing is enabled, either through
+// Hooks.TracerProvider or the library.trace.openTelemetry configuration. The provider created from
+// configuration is installed as the global provider and returned so that it can be shut down.
+func initTracing(ctx context.Context, hooks *Hooks, cfg *config.DefaultConfig) (context.Context, *sdktrace.TracerProvider, error) {
+	if hooks != nil && hooks.TracerProvider != nil {
+		if tp := hooks.TracerProvider(ctx); tp != nil {
+			return tracing.PutTracerProvider(ctx, tp), nil, nil
+		}
+	}
+	tp, err := tracing.NewTracerProvider(ctx, &cfg.Library.Trace.OpenTelemetry)
+	if err != nil || tp == nil {
+		return ctx, nil, err
+	}
+	log.Info(ctx, "OpenTelemetry tracing enabled")
+	tracing.Install(tp)
+	return tracing.PutTracerProvider(ctx, tp), tp, nil
+}
+
+// traceLogFieldsMiddleware bridges the sysl-go trace ID and the OpenTelemetry trace by recording
+// the trace ID against the span and the span against the logger.
+func traceLogFieldsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("traceid", common.GetTraceIDStringFromContext(ctx)))
+		next.ServeHTTP(w, r.WithContext(tracing.WithLogFields(ctx)))
+	})
+}
+
+// withGRPCTracing returns the gRPC server options with tracing through tp. Spans are recorded around
+// the given options, while the trace and span IDs are added to the logger within them, after the
+// interceptors that put the logger into the context (see makeLoggerInterceptor).
+func withGRPCTracing(tp trace.TracerProvider, opts []grpc.ServerOption) []grpc.ServerOption {
+	result := append(tracing.GRPCServerOptions(tp), opts...)
+	return append(result, tracing.GRPCServerLogFieldsOptions()...)
+}
+
+// tracerProviderServer shuts down the TracerProvider, flushing any pending spans, when stopped.
+type tracerProviderServer struct {
+	ctx     context.Context
+	tp      *sdktrace.TracerProvider
+	stopped chan struct{}
+	once    sync.Once
+}
+
+func newTracerProviderServer(ctx context.Context, tp *sdktrace.TracerProvider) *tracerProviderServer {
+	return &tracerProviderServer{ctx: ctx, tp: tp, stopped: make(chan struct{})}
+}
+
+func (s *tracerProviderServer) Start() error {
+	<-s.stopped
+	return nil
+}
+
+func (s *tracerProviderServer) Stop() error {
+	var err error
+	s.once.Do(func() {
+		close(s.stopped)
+		err = s.tp.Shutdown(s.ctx)
+	})
+	return err
+}
+
+func (s *tracerProviderServer) GracefulStop() error {
+	return s.Stop()
+}
+
+func (s *tracerProviderServer) GetName() string {
+	return "OpenTelemetry tracer provider"
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/config"
+	test "github.com/anz-bank/sysl-go/core/testdata/proto"
+	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/testutil"
+	"github.com/anz-bank/sysl-go/tracing"
+)
+
+func TestPrepareMiddleware_Tracing(t *testing.T) {
+	ctx, logger := testutil.NewTestContextWithLogger()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	mWare := prepareMiddleware("test", nil, nil, tp, nil, nil, defaultContextTimeout)
+	r := chi.NewRouter()
+	r.Use(mWare.public...)
+	var traceID string
+	r.Get("/hello", func(w http.ResponseWriter, r *http.Request) {
+		traceID = common.GetTraceIDStringFromContext(r.Context())
+		log.Info(r.Context(), "hello")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil).WithContext(ctx)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /hello", spans[0].Name)
+	require.Contains(t, spans[0].Attributes, attribute.String("traceid", traceID))
+	for _, entry := range logger.Entries() {
+		if entry.Message == "hello" {
+			require.Equal(t, spans[0].SpanContext.TraceID().String(), entry.Fields["trace_id"])
+			return
+		}
+	}
+	require.Fail(t, "log entry not found")
+}
+
+// loggingTestServer logs within the handler of each request.
+type loggingTestServer struct {
+	test.UnimplementedTestServiceServer
+}
+
+func (*loggingTestServer) Test(ctx context.Context, req *test.TestRequest) (*test.TestReply, error) {
+	log.Info(ctx, "hello")
+	return &test.TestReply{Field1: req.GetField1()}, nil
+}
+
+func TestWithGRPCTracing(t *testing.T) {
+	ctx, logger := testutil.NewTestContextWithLogger()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	opts, err := DefaultGrpcServerOptions(ctx, &config.GRPCServerConfig{})
+	require.NoError(t, err)
+	server := grpc.NewServer(withGRPCTracing(tp, opts)...)
+	test.RegisterTestServiceServer(server, &loggingTestServer{})
+	lis := bufconn.Listen(1024 * 1024)
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = test.NewTestServiceClient(conn).Test(ctx, &test.TestRequest{Field1: "hello"})
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	for _, entry := range logger.Entries() {
+		if entry.Message == "hello" {
+			require.Equal(t, spans[0].SpanContext.TraceID().String(), entry.Fields["trace_id"])
+			require.Equal(t, spans[0].SpanContext.SpanID().String(), entry.Fields["span_id"])
+			return
+		}
+	}
+	require.Fail(t, "log entry not found")
+}
+
+func TestInitTracing(t *testing.T) {
+	ctx := testutil.NewTestContext()
+	cfg := &config.DefaultConfig{}
+
+	ctx2, tp, err := initTracing(ctx, nil, cfg)
+	require.NoError(t, err)
+	require.Nil(t, tp)
+	require.Nil(t, tracing.GetTracerProvider(ctx2))
+
+	provided := sdktrace.NewTracerProvider()
+	ctx2, tp, err = initTracing(ctx, &Hooks{TracerProvider: func(ctx context.Context) trace.TracerProvider { return provided }}, cfg)
+	require.NoError(t, err)
+	require.Nil(t, tp)
+	require.Equal(t, provided, tracing.GetTracerProvider(ctx2))
+}
+
+func TestTracerProviderServer(t *testing.T) {
+	ctx := testutil.NewTestContext()
+	s := newTracerProviderServer(ctx, sdktrace.NewTracerProvider())
+	done := make(chan error)
+	go func() { done <- s.Start() }()
+	require.NoError(t, s.GracefulStop())
+	require.NoError(t, <-done)
+	require.NoError(t, s.Stop())
+}
@@ -2,23 +2,156 @@ package health
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
 
 	health "github.com/anz-bank/pkg/health"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
 	*health.Server
+
+	// Registry holds the dependency checks reported by the readiness and liveness endpoints.
+	Registry *Registry
+
+	grpcServer atomic.Value // *grpc.Server
 }
 
 func (s *Server) RegisterServer(ctx context.Context, server *grpc.Server) {
 	s.GRPC.RegisterWith(server)
+	// The grpc.health.v1 service may have been registered already (see core.Hooks.HealthCheck).
+	if _, ok := server.GetServiceInfo()[grpc_health_v1.Health_ServiceDesc.ServiceName]; !ok {
+		s.grpcServer.Store(server)
+		grpc_health_v1.RegisterHealthServer(server, &grpcHealthServer{s: s})
+	}
 }
 
 func NewServer() (*Server, error) {
+	return NewServerWithRegistry(NewRegistry())
+}
+
+// NewServerWithRegistry returns a Server whose readiness and liveness include the checks of the registry.
+func NewServerWithRegistry(registry *Registry) (*Server, error) {
 	s, err := health.NewServer()
 	if err != nil {
 		return nil, err
 	}
-	return &Server{s}, nil
+	s.SetReadyProvider(&readiness{registry: registry})
+	return &Server{Server: s, Registry: registry}, nil
+}
+
+// readiness is ready once set ready and while no critical check of the registry fails.
+type readiness struct {
+	ready    uint32
+	registry *Registry
+}
+
+func (r *readiness) IsReady() bool {
+	return r.isSet() && r.registry.Readiness(context.Background()).Status != StatusFail
+}
+
+func (r *readiness) isSet() bool {
+	return atomic.LoadUint32(&r.ready) == 1
+}
+
+func (r *readiness) SetReady(b bool) {
+	var v uint32
+	if b {
+		v = 1
+	}
+	atomic.StoreUint32(&r.ready, v)
+}
+
+// started returns whether the server has been set ready, regardless of the checks of the registry.
+func (s *Server) started() bool {
+	r, ok := s.ReadyProvider.(*readiness)
+	return !ok || r.isSet()
+}
+
+// readiness returns the report of the readiness checks of the given gRPC service, or all the checks
+// when the service is empty.
+func (s *Server) readiness(ctx context.Context, service string) Report {
+	var report Report
+	if service == "" {
+		report = s.Registry.Readiness(ctx)
+	} else {
+		report = s.Registry.Service(ctx, service)
+	}
+	if !s.started() {
+		report.Status = StatusFail
+		report.Checks = append([]CheckResult{{Name: "startup", Status: StatusFail, Critical: true, Duration: "0s", Error: "server not started"}}, report.Checks...)
+	}
+	return report
+}
+
+// RegisterHTTP registers the HTTP health endpoints with the given router:
+//
+//	/healthz: 200 OK while the application is alive
+//	/livez:   JSON report of the liveness checks, 503 when a critical check fails
+//	/readyz:  JSON report of all the checks, 503 when a critical check fails or before the server has started
+//	/version: JSON version information of the application
+func (s *Server) RegisterHTTP(r health.Router) {
+	r.Handle("/healthz", requireGet(s.HTTP.HandleAlive))
+	r.Handle("/livez", requireGet(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, s.Registry.Liveness(r.Context()))
+	}))
+	r.Handle("/readyz", requireGet(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, s.readiness(r.Context(), ""))
+	}))
+	r.Handle("/version", requireGet(s.HTTP.HandleVersion))
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == StatusFail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	b, _ := json.MarshalIndent(report, "", "  ")
+	_, _ = w.Write(b)
+}
+
+func requireGet(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			msg := fmt.Sprintf("%d method not allowed, use GET", http.StatusMethodNotAllowed)
+			http.Error(w, msg, http.StatusMethodNotAllowed)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// grpcHealthServer implements grpc.health.v1 from the checks of the registry. The empty service
+// reports the status of the application, other services report the status of their checks.
+type grpcHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	s *Server
+}
+
+func (g *grpcHealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	service := req.GetService()
+	if service != "" && !g.knows(service) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %s", service)
+	}
+	servingStatus := grpc_health_v1.HealthCheckResponse_SERVING
+	if g.s.readiness(ctx, service).Status == StatusFail {
+		servingStatus = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// knows returns whether the service is served by the gRPC server or has checks registered against it.
+func (g *grpcHealthServer) knows(service string) bool {
+	if server, ok := g.s.grpcServer.Load().(*grpc.Server); ok {
+		if _, ok := server.GetServiceInfo()[service]; ok {
+			return true
+		}
+	}
+	return g.s.Registry.HasService(service)
 }
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerHTTP(t *testing.T) {
+	s, err := NewServer()
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	s.RegisterHTTP(mux)
+	get := func(path string) (int, Report) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report Report
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+		return w.Code, report
+	}
+
+	code, report := get("/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "startup", report.Checks[0].Name)
+	require.False(t, s.IsReady())
+
+	s.SetReady(true)
+	code, report = get("/readyz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusPass, report.Status)
+	require.True(t, s.IsReady())
+
+	require.NoError(t, s.Registry.Register(Check{Name: "db", Critical: true, Liveness: true, Probe: func(context.Context) error {
+		return errors.New("down")
+	}}))
+	code, report = get("/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "down", report.Checks[0].Error)
+	require.False(t, s.IsReady())
+	code, _ = get("/livez")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/livez", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServerGRPC(t *testing.T) {
+	s, err := NewServer()
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	s.RegisterServer(context.Background(), server)
+	g := &grpcHealthServer{s: s}
+	check := func(service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+		resp, err := g.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		return resp.GetStatus(), err
+	}
+
+	servingStatus, err := check("")
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, servingStatus)
+
+	s.SetReady(true)
+	require.NoError(t, s.Registry.Register(Check{Name: "queue", Critical: true, Services: []string{"pkg.Queue"}, Probe: func(context.Context) error {
+		return errors.New("down")
+	}}))
+	servingStatus, err = check(grpc_health_v1.Health_ServiceDesc.ServiceName)
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, servingStatus)
+	servingStatus, err = check("pkg.Queue")
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, servingStatus)
+	_, err = check("pkg.Unknown")
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,254 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCheckTimeout is the timeout of the checks registered without a timeout.
+const DefaultCheckTimeout = 5 * time.Second
+
+// DefaultCacheTTL is the time for which the result of a check is reused by a new Registry.
+const DefaultCacheTTL = 2 * time.Second
+
+// Status is the status of a check or of a report.
+type Status string
+
+const (
+	// StatusPass is the status of a passing check, or of a report whose checks all pass.
+	StatusPass Status = "pass"
+	// StatusWarn is the status of a report with failing non-critical checks only.
+	StatusWarn Status = "warn"
+	// StatusFail is the status of a failing check, or of a report with failing critical checks.
+	StatusFail Status = "fail"
+)
+
+// Check is a probe of a dependency of the application, such as a downstream service.
+type Check struct {
+	// Name identifies the check in reports, it must be unique within a Registry.
+	Name string
+
+	// Probe returns nil when the dependency is healthy. The context is cancelled after the Timeout.
+	Probe func(ctx context.Context) error
+
+	// Timeout is the time allowed for the probe, defaulting to DefaultCheckTimeout.
+	Timeout time.Duration
+
+	// Critical checks make the application not ready (or not alive for liveness checks) when they
+	// fail. Failing non-critical checks are reported but do not change the status of the application.
+	Critical bool
+
+	// Liveness checks are also reported by the liveness endpoints.
+	Liveness bool
+
+	// Services are the names of the gRPC services the check is reported against through grpc.health.v1,
+	// the check applies to all services when not set.
+	Services []string
+}
+
+// CheckResult is the result of a check.
+type CheckResult struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the aggregate result of the checks of a Registry.
+type Report struct {
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Registry holds the checks of the dependencies of an application.
+//
+// The result of each check is reused for the cache TTL, so that the health endpoints polled by
+// orchestrators and load balancers (often from every replica) do not probe the dependencies each time.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []*registeredCheck
+	cacheTTL time.Duration
+}
+
+// registeredCheck is a check along with its last result.
+type registeredCheck struct {
+	Check
+
+	mu     sync.Mutex // held while probing, so that concurrent reports share the result
+	result CheckResult
+	ranAt  time.Time
+}
+
+// NewRegistry returns an empty Registry whose results are reused for DefaultCacheTTL.
+func NewRegistry() *Registry {
+	return &Registry{cacheTTL: DefaultCacheTTL}
+}
+
+// SetCacheTTL sets the time for which the result of each check is reused, zero disabling the cache.
+func (r *Registry) SetCacheTTL(ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cacheTTL = ttl
+}
+
+// Register adds a check to the registry.
+func (r *Registry) Register(check Check) error {
+	if check.Name == "" {
+		return fmt.Errorf("health check without a name")
+	}
+	if check.Probe == nil {
+		return fmt.Errorf("health check %s without a probe", check.Name)
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultCheckTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.checks {
+		if c.Name == check.Name {
+			return fmt.Errorf("health check %s already registered", check.Name)
+		}
+	}
+	r.checks = append(r.checks, &registeredCheck{Check: check})
+	return nil
+}
+
+// Readiness runs all the checks of the registry.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	return r.run(ctx, func(Check) bool { return true })
+}
+
+// Liveness runs the liveness checks of the registry.
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, func(c Check) bool { return c.Liveness })
+}
+
+// Service runs the checks of the registry that apply to the given gRPC service.
+func (r *Registry) Service(ctx context.Context, service string) Report {
+	return r.run(ctx, func(c Check) bool { return c.appliesTo(service) })
+}
+
+// HasService returns whether any check has been registered against the given gRPC service.
+func (r *Registry) HasService(service string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, c := range r.checks {
+		for _, s := range c.Services {
+			if s == service {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c Check) appliesTo(service string) bool {
+	if len(c.Services) == 0 {
+		return true
+	}
+	for _, s := range c.Services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs the selected checks concurrently.
+func (r *Registry) run(ctx context.Context, include func(Check) bool) Report {
+	if r == nil {
+		return Report{Status: StatusPass, Checks: []CheckResult{}}
+	}
+	r.mu.RLock()
+	var checks []*registeredCheck
+	for _, c := range r.checks {
+		if include(c.Check) {
+			checks = append(checks, c)
+		}
+	}
+	ttl := r.cacheTTL
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = checks[i].cachedRun(ctx, ttl)
+		}(i)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := Report{Status: StatusPass, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusFail {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusPass {
+			report.Status = StatusWarn
+		}
+	}
+	return report
+}
+
+// cachedRun returns the last result of the check when younger than the ttl, running it otherwise.
+func (c *registeredCheck) cachedRun(ctx context.Context, ttl time.Duration) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.ranAt.IsZero() && time.Since(c.ranAt) < ttl {
+		return c.result
+	}
+	c.result = c.run(ctx)
+	c.ranAt = time.Now()
+	return c.result
+}
+
+func (c Check) run(ctx context.Context) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	start := time.Now()
+	result = CheckResult{Name: c.Name, Status: StatusPass, Critical: c.Critical}
+
+	errs := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errs <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		errs <- c.Probe(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", c.Timeout)
+	}
+	result.Duration = time.Since(start).String()
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+type registryKey struct{}
+
+// PutRegistry puts the registry in the context.
+func PutRegistry(ctx context.Context, registry *Registry) context.Context {
+	return context.WithValue(ctx, registryKey{}, registry)
+}
+
+// GetRegistry returns the registry in the context, nil if none.
+func GetRegistry(ctx context.Context) *Registry {
+	registry, _ := ctx.Value(registryKey{}).(*Registry)
+	return registry
+}
@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	pass := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("down") }
+	slow := func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+
+	require.Error(t, r.Register(Check{Probe: pass}))
+	require.Error(t, r.Register(Check{Name: "noprobe"}))
+	require.NoError(t, r.Register(Check{Name: "db", Probe: pass, Critical: true, Liveness: true}))
+	require.Error(t, r.Register(Check{Name: "db", Probe: pass}))
+
+	report := r.Readiness(context.Background())
+	require.Equal(t, StatusPass, report.Status)
+	require.Len(t, report.Checks, 1)
+
+	require.NoError(t, r.Register(Check{Name: "cache", Probe: fail}))
+	report = r.Readiness(context.Background())
+	require.Equal(t, StatusWarn, report.Status)
+	require.Equal(t, CheckResult{Name: "cache", Status: StatusFail, Duration: report.Checks[0].Duration, Error: "down"}, report.Checks[0])
+
+	require.NoError(t, r.Register(Check{Name: "queue", Probe: slow, Timeout: 10 * time.Millisecond, Critical: true, Services: []string{"pkg.Queue"}}))
+	report = r.Readiness(context.Background())
+	require.Equal(t, StatusFail, report.Status)
+	require.Equal(t, "timed out after 10ms", report.Checks[2].Error)
+
+	require.Equal(t, StatusPass, r.Liveness(context.Background()).Status)
+	require.Equal(t, StatusWarn, r.Service(context.Background(), "pkg.Other").Status)
+	require.Equal(t, StatusFail, r.Service(context.Background(), "pkg.Queue").Status)
+	require.True(t, r.HasService("pkg.Queue"))
+	require.False(t, r.HasService("pkg.Other"))
+}
+
+func TestRegistryCache(t *testing.T) {
+	r := NewRegistry()
+	r.SetCacheTTL(50 * time.Millisecond)
+	var calls int32
+	require.NoError(t, r.Register(Check{Name: "db", Probe: func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}}))
+
+	r.Readiness(context.Background())
+	r.Readiness(context.Background())
+	r.Service(context.Background(), "pkg.Other")
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls), "the result is reused within the ttl")
+
+	time.Sleep(60 * time.Millisecond)
+	r.Readiness(context.Background())
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls), "the check runs again once the result expires")
+
+	r.SetCacheTTL(0)
+	r.Readiness(context.Background())
+	r.Readiness(context.Background())
+	require.Equal(t, int32(4), atomic.LoadInt32(&calls), "a zero ttl disables the cache")
+}
+
+func TestRegistryContext(t *testing.T) {
+	require.Nil(t, GetRegistry(context.Background()))
+	r := NewRegistry()
+	require.Equal(t, r, GetRegistry(PutRegistry(context.Background(), r)))
+}
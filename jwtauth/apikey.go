@@ -0,0 +1,123 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// APIKeyScheme is the iss claim of the requests authenticated by API key.
+const APIKeyScheme = "apikey"
+
+// DefaultAPIKeyHeader is the header the API key of a request is read from when neither a header nor a
+// query parameter is configured.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// APIKeyConfig defines config for the authentication of requests by API key.
+type APIKeyConfig struct {
+	// Header is the header holding the API key, defaulting to X-API-Key when Query is not set either.
+	Header string `json:"header,omitempty"           yaml:"header,omitempty"           mapstructure:"header"`
+
+	// Query is the query parameter holding the API key, not read when empty. Keys passed as a query
+	// parameter are part of the URL, so are written to any log of the URLs of the requests.
+	Query string `json:"query,omitempty"            yaml:"query,omitempty"            mapstructure:"query"`
+
+	// Keys are the accepted API keys.
+	Keys []APIKey `json:"keys,omitempty"             yaml:"keys,omitempty"             mapstructure:"keys"`
+
+	// KeysFile is the path of a YAML list of accepted API keys, in addition to the Keys.
+	KeysFile string `json:"keysFile,omitempty"         yaml:"keysFile,omitempty"         mapstructure:"keysFile"`
+}
+
+// KeyHeader returns the header holding the API key, empty when the key is only read from the query parameter.
+func (c APIKeyConfig) KeyHeader() string {
+	if c.Header == "" && c.Query == "" {
+		return DefaultAPIKeyHeader
+	}
+	return c.Header
+}
+
+// APIKey defines an accepted API key.
+type APIKey struct {
+	// Name identifies the key, and is the sub claim of the requests authenticated with it.
+	Name string `json:"name"                       yaml:"name"                       mapstructure:"name"`
+
+	// Hash is the hex encoded SHA-256 hash of the key.
+	Hash string `json:"hash"                       yaml:"hash"                       mapstructure:"hash"`
+
+	// Scopes are the scope claim of the requests authenticated with the key.
+	Scopes []string `json:"scopes,omitempty"           yaml:"scopes,omitempty"           mapstructure:"scopes"`
+
+	// Labels are the labels claim of the requests authenticated with the key.
+	Labels map[string]string `json:"labels,omitempty"           yaml:"labels,omitempty"           mapstructure:"labels"`
+}
+
+// APIKeyAuthenticator authenticates requests by API key, read from a header or a query parameter.
+//
+// Authenticated requests are given the claims iss (apikey), sub (the name of the key), scope and labels,
+// such that authorization rules apply to them like to the requests authenticated by jwt.
+type APIKeyAuthenticator struct {
+	header string
+	query  string
+	keys   map[[sha256.Size]byte]APIKey
+}
+
+// NewAPIKeyAuthenticator creates an APIKeyAuthenticator from config.
+func NewAPIKeyAuthenticator(c APIKeyConfig) (*APIKeyAuthenticator, error) {
+	keys := c.Keys
+	if c.KeysFile != "" {
+		var err error
+		if keys, err = readCredentialsFile(c.KeysFile, keys); err != nil {
+			return nil, err
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwtauth.Config: apiKey must have keys or a keysFile")
+	}
+	a := &APIKeyAuthenticator{header: c.KeyHeader(), query: c.Query, keys: make(map[[sha256.Size]byte]APIKey, len(keys))}
+	names := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.Name == "" {
+			return nil, errors.New("jwtauth.Config: apiKey keys must have a name")
+		}
+		if names[key.Name] {
+			return nil, fmt.Errorf("jwtauth.Config: apiKey key names are not unique: %s", key.Name)
+		}
+		names[key.Name] = true
+		hash, err := hex.DecodeString(key.Hash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("jwtauth.Config: apiKey key %s must have a hex encoded SHA-256 hash", key.Name)
+		}
+		if _, ok := a.keys[[sha256.Size]byte(hash)]; ok {
+			return nil, fmt.Errorf("jwtauth.Config: apiKey key %s has the hash of another key", key.Name)
+		}
+		a.keys[[sha256.Size]byte(hash)] = key
+	}
+	return a, nil
+}
+
+// AuthenticateCredentials implements the CredentialsAuthenticator interface.
+func (a *APIKeyAuthenticator) AuthenticateCredentials(ctx context.Context, header http.Header, query url.Values) (Claims, bool, error) {
+	var raw string
+	if a.header != "" {
+		raw = header.Get(a.header)
+	}
+	if raw == "" && a.query != "" {
+		raw = query.Get(a.query)
+	}
+	if raw == "" {
+		return nil, false, nil
+	}
+	// Keys are looked up by their hash, such that the time taken does not depend on the raw key.
+	key, ok := a.keys[sha256.Sum256([]byte(raw))]
+	if !ok {
+		pkgLogger.Debug(ctx, "unknown api key")
+		return Claims{}, true, invalidCredentials(errors.New("unknown api key"))
+	}
+	return credentialClaims(APIKeyScheme, key.Name, key.Scopes, key.Labels), true, nil
+}
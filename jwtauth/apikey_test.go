@@ -0,0 +1,80 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKeyOneHash = "9b346041bc9a49574eb2665b2ad2a0a3f9f9cce4e42f5d1f26deb8a256b5966a" // sha256 of key-one
+	testKeyTwoHash = "c8df51469c308a59bfbd48a3e0bdd228ca922d6032035f5ef6e4ad45f473a9f3" // sha256 of key-two
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	a, err := NewAPIKeyAuthenticator(APIKeyConfig{Keys: []APIKey{
+		{Name: "one", Hash: testKeyOneHash, Scopes: []string{"read", "write"}, Labels: map[string]string{"team": "payments"}},
+	}})
+	require.NoError(t, err)
+
+	claims, found, err := a.AuthenticateCredentials(testContext(), http.Header{"X-Api-Key": {"key-one"}}, nil)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, Claims{
+		"iss":    "apikey",
+		"sub":    "one",
+		"scope":  "read write",
+		"labels": map[string]interface{}{"team": "payments"},
+	}, claims)
+
+	_, found, err = a.AuthenticateCredentials(testContext(), http.Header{"X-Api-Key": {"key-two"}}, nil)
+	require.True(t, found)
+	requireAuthErrCode(t, AuthErrCodeInvalidCredentials, err)
+
+	_, found, err = a.AuthenticateCredentials(testContext(), http.Header{}, url.Values{"api_key": {"key-one"}})
+	require.NoError(t, err)
+	require.False(t, found, "the query is not read unless configured")
+}
+
+func TestAPIKeyAuthenticatorQueryAndFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+- name: two
+  hash: `+testKeyTwoHash+`
+  scopes: [admin]
+`), 0o600))
+	a, err := NewAPIKeyAuthenticator(APIKeyConfig{
+		Header:   "X-Key",
+		Query:    "api_key",
+		Keys:     []APIKey{{Name: "one", Hash: testKeyOneHash}},
+		KeysFile: path,
+	})
+	require.NoError(t, err)
+
+	claims, found, err := a.AuthenticateCredentials(testContext(), http.Header{}, url.Values{"api_key": {"key-two"}})
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, Claims{"iss": "apikey", "sub": "two", "scope": "admin"}, claims)
+
+	claims, _, err = a.AuthenticateCredentials(testContext(), http.Header{"X-Key": {"key-one"}}, url.Values{"api_key": {"key-two"}})
+	require.NoError(t, err)
+	require.Equal(t, "one", claims["sub"], "the header takes precedence over the query")
+}
+
+func TestNewAPIKeyAuthenticatorErrors(t *testing.T) {
+	for _, c := range []APIKeyConfig{
+		{},
+		{Keys: []APIKey{{Hash: testKeyOneHash}}},
+		{Keys: []APIKey{{Name: "one", Hash: "key-one"}}},
+		{Keys: []APIKey{{Name: "one", Hash: testKeyOneHash}, {Name: "one", Hash: testKeyTwoHash}}},
+		{Keys: []APIKey{{Name: "one", Hash: testKeyOneHash}, {Name: "two", Hash: testKeyOneHash}}},
+		{KeysFile: filepath.Join(t.TempDir(), "missing.yaml")},
+	} {
+		_, err := NewAPIKeyAuthenticator(c)
+		require.Error(t, err, "%+v", c)
+	}
+}
@@ -3,6 +3,9 @@ package jwtauth
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-jose/go-jose/v3/jwt"
@@ -28,12 +31,33 @@ type Verifier interface {
 // issuers and keys.
 type StdAuthenticator struct {
 	Verifiers map[string]Verifier
+
+	// Validations are the validations of the claims of the tokens of each issuer, keyed like the
+	// Verifiers. The time claims of the tokens of issuers without validation are validated with the
+	// DefaultLeeway.
+	Validations map[string]*ClaimsValidation
+
+	// Introspectors authenticate the tokens of the issuers configured for introspection, keyed by
+	// issuer name. Tokens that are not jwts are introspected by the introspector of the issuer whose
+	// token prefix they start with, or by the only introspector when it has no token prefix. Jwts of
+	// issuers without a verifier are introspected by the introspector of their issuer.
+	Introspectors map[string]Authenticator
+
+	// TokenPrefixes are the prefixes of the opaque tokens of the issuers, keyed like the Introspectors.
+	TokenPrefixes map[string]string
+
+	// Schemes authenticate the requests without a bearer token by other credentials, such as API
+	// keys, in order. See AuthenticateCredentials.
+	Schemes []CredentialsAuthenticator
 }
 
 // Authenticate authenticates a jwt and returns the extracted claims, or an
 // error if any occur.
 func (a *StdAuthenticator) Authenticate(ctx context.Context, raw string) (Claims, error) {
 	token, err := jwt.ParseSigned(raw)
+	if err != nil && len(a.Introspectors) > 0 {
+		return a.introspect(ctx, raw)
+	}
 	if err != nil {
 		pkgLogger.Debug(ctx, "error parsing jwt:", err)
 		return Claims{}, &AuthError{
@@ -51,14 +75,15 @@ func (a *StdAuthenticator) Authenticate(ctx context.Context, raw string) (Claims
 			Cause: errors.Wrap(err, "jwt verify error"),
 		}
 	}
-	if err := insecureClaims.ValidateWithLeeway(jwt.Expected{Time: time.Now()}, time.Second); err != nil {
-		pkgLogger.Debug(ctx, "jwt expired")
-		return Claims{}, &AuthError{
-			Code:  AuthErrCodeInvalidJWT,
-			Cause: err,
-		}
+	validation := a.Validations[insecureClaims.Issuer]
+	if err := validation.validateTime(insecureClaims, time.Now()); err != nil {
+		pkgLogger.Debug(ctx, "jwt time validation error:", err)
+		return Claims{}, err
 	}
 	verifier, ok := a.Verifiers[insecureClaims.Issuer]
+	if introspector, introspected := a.Introspectors[insecureClaims.Issuer]; !ok && introspected {
+		return introspector.Authenticate(ctx, raw)
+	}
 	if !ok {
 		pkgLogger.Debugf(ctx, "issuer not registered: %s", insecureClaims.Issuer)
 		return Claims{}, &AuthError{
@@ -73,9 +98,43 @@ func (a *StdAuthenticator) Authenticate(ctx context.Context, raw string) (Claims
 		pkgLogger.Debug(ctx, err)
 		return Claims{}, err // Don't wrap this error
 	}
+	if err := validation.validateClaims(claims); err != nil {
+		pkgLogger.Debug(ctx, "jwt claims validation error:", err)
+		return Claims{}, err
+	}
 	return claims, nil
 }
 
+// introspect authenticates an opaque token with the introspector of its issuer, such that tokens are
+// never sent to the introspection endpoints of other issuers.
+func (a *StdAuthenticator) introspect(ctx context.Context, raw string) (Claims, error) {
+	issuer, ok := a.introspectionIssuer(raw)
+	if !ok {
+		pkgLogger.Debug(ctx, "opaque token matches no introspection issuer")
+		return Claims{}, &AuthError{
+			Code:  AuthErrCodeInvalidJWT,
+			Cause: errors.New("token matches the token prefix of no introspection issuer"),
+		}
+	}
+	return a.Introspectors[issuer].Authenticate(ctx, raw)
+}
+
+// introspectionIssuer returns the issuer with the longest token prefix of the given token, or the only
+// introspection issuer when it has no token prefix.
+func (a *StdAuthenticator) introspectionIssuer(raw string) (string, bool) {
+	var issuer, prefix string
+	for name := range a.Introspectors {
+		p := a.TokenPrefixes[name]
+		if p == "" && len(a.Introspectors) == 1 {
+			return name, true
+		}
+		if p != "" && strings.HasPrefix(raw, p) && len(p) > len(prefix) {
+			issuer, prefix = name, p
+		}
+	}
+	return issuer, prefix != ""
+}
+
 // InsecureAuthenticator does not attempt to verify the signature of a jwt.
 //
 // USE ONLY IN TESTING.
@@ -101,3 +160,14 @@ func (i InsecureAuthenticator) Authenticate(ctx context.Context, raw string) (Cl
 	}
 	return insecureClaims, nil
 }
+
+// AuthenticateCredentials implements the CredentialsAuthenticator interface with the Schemes, returning
+// the result of the first scheme the request holds credentials of.
+func (a *StdAuthenticator) AuthenticateCredentials(ctx context.Context, header http.Header, query url.Values) (Claims, bool, error) {
+	for _, scheme := range a.Schemes {
+		if claims, found, err := scheme.AuthenticateCredentials(ctx, header, query); found {
+			return claims, true, err
+		}
+	}
+	return nil, false, nil
+}
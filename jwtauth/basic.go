@@ -0,0 +1,235 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anz-bank/sysl-go/jsontime"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/time/rate"
+)
+
+// BasicScheme is the iss claim of the requests authenticated by Basic credentials.
+const BasicScheme = "basic"
+
+// Defaults of the BasicConfig.
+const (
+	DefaultBasicCacheTTL                  = 5 * time.Minute
+	DefaultBasicMaxVerificationsPerSecond = 20
+)
+
+// maxBasicCacheEntries bounds the number of credentials cached by a BasicAuthenticator.
+const maxBasicCacheEntries = 10000
+
+// BasicConfig defines config for the authentication of requests by Basic credentials (RFC 7617).
+type BasicConfig struct {
+	// Users are the accepted users.
+	Users []BasicUser `json:"users,omitempty"            yaml:"users,omitempty"            mapstructure:"users"`
+
+	// UsersFile is the path of a YAML list of accepted users, in addition to the Users.
+	UsersFile string `json:"usersFile,omitempty"        yaml:"usersFile,omitempty"        mapstructure:"usersFile"`
+
+	// CacheTTL is how long authenticated credentials are cached, defaulting to 5m. Negative values
+	// disable the cache.
+	CacheTTL jsontime.Duration `json:"cacheTTL,omitempty"         yaml:"cacheTTL,omitempty"         mapstructure:"cacheTTL"`
+
+	// MaxVerificationsPerSecond limits the bcrypt verifications of credentials that are not cached,
+	// defaulting to 20. Credentials are rejected while the limit is exceeded.
+	MaxVerificationsPerSecond float64 `json:"maxVerificationsPerSecond,omitempty" yaml:"maxVerificationsPerSecond,omitempty" mapstructure:"maxVerificationsPerSecond"`
+}
+
+// BasicUser defines an accepted user of Basic credentials.
+type BasicUser struct {
+	// Username is the name of the user, and is the sub claim of the requests authenticated as the user.
+	Username string `json:"username"                   yaml:"username"                   mapstructure:"username"`
+
+	// PasswordHash is the bcrypt hash of the password of the user.
+	PasswordHash string `json:"passwordHash"               yaml:"passwordHash"               mapstructure:"passwordHash"`
+
+	// Scopes are the scope claim of the requests authenticated as the user.
+	Scopes []string `json:"scopes,omitempty"           yaml:"scopes,omitempty"           mapstructure:"scopes"`
+
+	// Labels are the labels claim of the requests authenticated as the user.
+	Labels map[string]string `json:"labels,omitempty"           yaml:"labels,omitempty"           mapstructure:"labels"`
+}
+
+// BasicAuthenticator authenticates requests by the Basic credentials of their Authorization header.
+//
+// Authenticated requests are given the claims iss (basic), sub (the username), scope and labels, such
+// that authorization rules apply to them like to the requests authenticated by jwt.
+//
+// Authenticated credentials are cached, and the bcrypt verifications of the credentials that are not
+// cached are rate limited, such that requests with invalid credentials cannot exhaust the CPU. The
+// passwords of unknown users are verified against a hash with the highest cost of the users, such that
+// the time taken to reject them does not reveal which users exist.
+type BasicAuthenticator struct {
+	users           map[string]BasicUser
+	unknownUserHash []byte
+	cacheTTL        time.Duration
+	limiter         *rate.Limiter
+
+	// salt keys the cache, such that the cached credentials cannot be recovered from its keys.
+	salt  []byte
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]basicCacheEntry
+}
+
+type basicCacheEntry struct {
+	username string
+	expires  time.Time
+}
+
+// NewBasicAuthenticator creates a BasicAuthenticator from config.
+func NewBasicAuthenticator(c BasicConfig) (*BasicAuthenticator, error) {
+	users := c.Users
+	if c.UsersFile != "" {
+		var err error
+		if users, err = readCredentialsFile(c.UsersFile, users); err != nil {
+			return nil, err
+		}
+	}
+	if len(users) == 0 {
+		return nil, errors.New("jwtauth.Config: basic must have users or a usersFile")
+	}
+	if c.MaxVerificationsPerSecond < 0 {
+		return nil, errors.New("jwtauth.Config: basic maxVerificationsPerSecond must not be negative")
+	}
+	if c.MaxVerificationsPerSecond == 0 {
+		c.MaxVerificationsPerSecond = DefaultBasicMaxVerificationsPerSecond
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = jsontime.Duration(DefaultBasicCacheTTL)
+	}
+	a := &BasicAuthenticator{
+		users:    make(map[string]BasicUser, len(users)),
+		cacheTTL: time.Duration(c.CacheTTL),
+		limiter:  rate.NewLimiter(rate.Limit(c.MaxVerificationsPerSecond), int(math.Ceil(c.MaxVerificationsPerSecond))),
+		salt:     make([]byte, 32),
+		cache:    map[[sha256.Size]byte]basicCacheEntry{},
+	}
+	if _, err := rand.Read(a.salt); err != nil {
+		return nil, err
+	}
+	maxCost := bcrypt.MinCost
+	for _, user := range users {
+		if user.Username == "" || strings.Contains(user.Username, ":") {
+			return nil, fmt.Errorf("jwtauth.Config: basic users must have a username without a colon: %q", user.Username)
+		}
+		if _, ok := a.users[user.Username]; ok {
+			return nil, fmt.Errorf("jwtauth.Config: basic usernames are not unique: %s", user.Username)
+		}
+		cost, err := bcrypt.Cost([]byte(user.PasswordHash))
+		if err != nil {
+			return nil, fmt.Errorf("jwtauth.Config: basic user %s must have a bcrypt password hash", user.Username)
+		}
+		if cost > maxCost {
+			maxCost = cost
+		}
+		a.users[user.Username] = user
+	}
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return nil, err
+	}
+	var err error
+	if a.unknownUserHash, err = bcrypt.GenerateFromPassword(password, maxCost); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// AuthenticateCredentials implements the CredentialsAuthenticator interface.
+func (a *BasicAuthenticator) AuthenticateCredentials(ctx context.Context, header http.Header, _ url.Values) (Claims, bool, error) {
+	val := header.Get("Authorization")
+	if len(val) < 6 || !strings.EqualFold(val[:6], "basic ") {
+		return nil, false, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(val[6:]))
+	if err != nil {
+		pkgLogger.Debug(ctx, "invalid basic credentials:", err)
+		return Claims{}, true, invalidCredentials(errors.Wrap(err, "invalid basic credentials"))
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return Claims{}, true, invalidCredentials(errors.New("invalid basic credentials"))
+	}
+	key := a.cacheKey(username, password)
+	now := time.Now()
+	if a.cached(key, username, now) {
+		user := a.users[username]
+		return credentialClaims(BasicScheme, user.Username, user.Scopes, user.Labels), true, nil
+	}
+
+	if !a.limiter.Allow() {
+		pkgLogger.Debug(ctx, "basic credentials verification rate limit exceeded")
+		return Claims{}, true, &AuthError{Code: AuthErrCodeUnknown, Cause: errors.New("basic credentials verification rate limit exceeded")}
+	}
+	user, known := a.users[username]
+	hash := []byte(user.PasswordHash)
+	if !known {
+		hash = a.unknownUserHash
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || !known {
+		pkgLogger.Debugf(ctx, "basic credentials rejected for user %s", username)
+		return Claims{}, true, invalidCredentials(errors.New("invalid username or password"))
+	}
+	if a.cacheTTL > 0 {
+		a.store(key, basicCacheEntry{username: username, expires: now.Add(a.cacheTTL)}, now)
+	}
+	return credentialClaims(BasicScheme, user.Username, user.Scopes, user.Labels), true, nil
+}
+
+func (a *BasicAuthenticator) cacheKey(username, password string) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(a.salt)
+	h.Write([]byte(username))
+	h.Write([]byte{0})
+	h.Write([]byte(password))
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// cached returns whether the credentials of the user with the given key were authenticated within
+// the cache ttl.
+func (a *BasicAuthenticator) cached(key [sha256.Size]byte, username string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry, ok := a.cache[key]
+	if !ok {
+		return false
+	}
+	if !now.Before(entry.expires) {
+		delete(a.cache, key)
+		return false
+	}
+	return entry.username == username
+}
+
+// store caches the given entry, evicting the expired entries when the cache is full. Entries are not
+// cached while the cache is full of unexpired entries.
+func (a *BasicAuthenticator) store(key [sha256.Size]byte, entry basicCacheEntry, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.cache) >= maxBasicCacheEntries {
+		for k, e := range a.cache {
+			if !now.Before(e.expires) {
+				delete(a.cache, k)
+			}
+		}
+		if len(a.cache) >= maxBasicCacheEntries {
+			return
+		}
+	}
+	a.cache[key] = entry
+}
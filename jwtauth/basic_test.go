@@ -0,0 +1,134 @@
+package jwtauth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testPasswordHash is the bcrypt hash of s3cret, with the minimum cost to keep the tests fast.
+const testPasswordHash = "$2a$04$R2PbTG75j1JqOx6t06D1EOT8X1WNQbtubaQJi3i9CRb/MA6dIIYTa"
+
+func basicHeader(username, password string) http.Header {
+	return http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))}}
+}
+
+func TestBasicAuthenticator(t *testing.T) {
+	a, err := NewBasicAuthenticator(BasicConfig{Users: []BasicUser{
+		{Username: "svc", PasswordHash: testPasswordHash, Scopes: []string{"read"}, Labels: map[string]string{"caller": "batch"}},
+	}})
+	require.NoError(t, err)
+
+	claims, found, err := a.AuthenticateCredentials(testContext(), basicHeader("svc", "s3cret"), nil)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, Claims{
+		"iss":    "basic",
+		"sub":    "svc",
+		"scope":  "read",
+		"labels": map[string]interface{}{"caller": "batch"},
+	}, claims)
+
+	for _, header := range []http.Header{
+		basicHeader("svc", "wrong"),
+		basicHeader("other", "s3cret"),
+		{"Authorization": {"Basic not-base64"}},
+		{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte("svc"))}},
+	} {
+		_, found, err = a.AuthenticateCredentials(testContext(), header, nil)
+		require.True(t, found)
+		requireAuthErrCode(t, AuthErrCodeInvalidCredentials, err)
+	}
+
+	_, found, err = a.AuthenticateCredentials(testContext(), http.Header{"Authorization": {"Bearer token"}}, nil)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestBasicAuthenticatorUnknownUserHashCost(t *testing.T) {
+	a, err := NewBasicAuthenticator(BasicConfig{Users: []BasicUser{{Username: "svc", PasswordHash: testPasswordHash}}})
+	require.NoError(t, err)
+	cost, err := bcrypt.Cost(a.unknownUserHash)
+	require.NoError(t, err)
+	require.Equal(t, 4, cost, "unknown users are verified with the cost of the configured users")
+}
+
+func TestBasicAuthenticatorCacheAndRateLimit(t *testing.T) {
+	a, err := NewBasicAuthenticator(BasicConfig{
+		Users:                     []BasicUser{{Username: "svc", PasswordHash: testPasswordHash}},
+		MaxVerificationsPerSecond: 1,
+	})
+	require.NoError(t, err)
+
+	_, _, err = a.AuthenticateCredentials(testContext(), basicHeader("svc", "s3cret"), nil)
+	require.NoError(t, err)
+
+	// Cached credentials are not verified again, while the others are rate limited.
+	claims, _, err := a.AuthenticateCredentials(testContext(), basicHeader("svc", "s3cret"), nil)
+	require.NoError(t, err)
+	require.Equal(t, "svc", claims["sub"])
+	_, found, err := a.AuthenticateCredentials(testContext(), basicHeader("svc", "wrong"), nil)
+	require.True(t, found)
+	requireAuthErrCode(t, AuthErrCodeUnknown, err)
+
+	_, err = NewBasicAuthenticator(BasicConfig{
+		Users:                     []BasicUser{{Username: "svc", PasswordHash: testPasswordHash}},
+		MaxVerificationsPerSecond: -1,
+	})
+	require.Error(t, err)
+}
+
+func TestBasicAuthenticatorUsersFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"username": "svc", "passwordHash": "`+testPasswordHash+`"}]`), 0o600))
+	a, err := NewBasicAuthenticator(BasicConfig{UsersFile: path})
+	require.NoError(t, err)
+	claims, _, err := a.AuthenticateCredentials(testContext(), basicHeader("svc", "s3cret"), nil)
+	require.NoError(t, err)
+	require.Equal(t, "svc", claims["sub"])
+}
+
+func TestNewBasicAuthenticatorErrors(t *testing.T) {
+	for _, c := range []BasicConfig{
+		{},
+		{Users: []BasicUser{{PasswordHash: testPasswordHash}}},
+		{Users: []BasicUser{{Username: "a:b", PasswordHash: testPasswordHash}}},
+		{Users: []BasicUser{{Username: "svc", PasswordHash: "s3cret"}}},
+		{Users: []BasicUser{{Username: "svc", PasswordHash: testPasswordHash}, {Username: "svc", PasswordHash: testPasswordHash}}},
+	} {
+		_, err := NewBasicAuthenticator(c)
+		require.Error(t, err, "%+v", c)
+	}
+}
+
+func TestStdAuthenticatorCredentials(t *testing.T) {
+	auth, err := AuthFromConfig(testContext(), &Config{
+		APIKey: &APIKeyConfig{Keys: []APIKey{{Name: "one", Hash: testKeyOneHash}}},
+		Basic:  &BasicConfig{Users: []BasicUser{{Username: "svc", PasswordHash: testPasswordHash}}},
+	}, func(string) *http.Client { return nil })
+	require.NoError(t, err)
+
+	claims, found, err := AuthenticateRequestCredentials(testContext(), auth, http.Header{"X-Api-Key": {"key-one"}}, nil)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "apikey", claims["iss"])
+
+	claims, found, err = AuthenticateRequestCredentials(testContext(), auth, basicHeader("svc", "s3cret"), nil)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "basic", claims["iss"])
+
+	_, found, err = AuthenticateRequestCredentials(testContext(), auth, http.Header{}, nil)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// Authenticators that are not CredentialsAuthenticators find no credentials.
+	_, found, err = AuthenticateRequestCredentials(testContext(), InsecureAuthenticator{}, basicHeader("svc", "s3cret"), nil)
+	require.NoError(t, err)
+	require.False(t, found)
+}
@@ -3,6 +3,7 @@ package jwtauth
 import (
 	"context"
 	"encoding/json"
+	"sync"
 )
 
 // Claims is weakly typed so it can hold any conceivable JSON claims value.
@@ -28,7 +29,11 @@ var claimsKey = &claimsKeyStruct{}
 
 // AddClaimsToContext adds claims to the context.
 func AddClaimsToContext(ctx context.Context, c Claims) context.Context {
-	return context.WithValue(ctx, claimsKey, clone(c))
+	c = clone(c)
+	if r, ok := ctx.Value(claimsRecorderKey).(*ClaimsRecorder); ok {
+		r.record(c)
+	}
+	return context.WithValue(ctx, claimsKey, c)
 }
 
 // GetClaimsFromContext retrieves claims from the context.
@@ -43,3 +48,37 @@ func GetClaimsFromContext(ctx context.Context) (Claims, bool) {
 	}
 	return clone(claims), true
 }
+
+type claimsRecorderKeyStruct struct{}
+
+var claimsRecorderKey = &claimsRecorderKeyStruct{}
+
+// ClaimsRecorder records the claims added with AddClaimsToContext to contexts derived from the
+// context it was put into. It gives middleware access to the claims of the requests authenticated
+// by the handlers they wrap.
+type ClaimsRecorder struct {
+	mu     sync.Mutex
+	claims Claims
+}
+
+// WithClaimsRecorder returns a context holding a new ClaimsRecorder.
+func WithClaimsRecorder(ctx context.Context) (context.Context, *ClaimsRecorder) {
+	r := &ClaimsRecorder{}
+	return context.WithValue(ctx, claimsRecorderKey, r), r
+}
+
+// Claims returns a copy of the last claims recorded, false if none were recorded.
+func (r *ClaimsRecorder) Claims() (Claims, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.claims == nil {
+		return Claims{}, false
+	}
+	return clone(r.claims), true
+}
+
+func (r *ClaimsRecorder) record(c Claims) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.claims = c
+}
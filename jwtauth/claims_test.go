@@ -45,3 +45,18 @@ func TestGetClaimsFromContextEmpty(t *testing.T) {
 	assert.False(t, ok)
 	assert.Empty(t, claims)
 }
+
+func TestClaimsRecorder(t *testing.T) {
+	ctx, recorder := WithClaimsRecorder(context.Background())
+	_, ok := recorder.Claims()
+	require.False(t, ok)
+
+	_ = AddClaimsToContext(context.WithValue(ctx, claimsKey, nil), Claims{"sub": "alice"})
+	claims, ok := recorder.Claims()
+	require.True(t, ok)
+	assert.Equal(t, Claims{"sub": "alice"}, claims)
+
+	claims["sub"] = "bob"
+	claims, _ = recorder.Claims()
+	assert.Equal(t, "alice", claims["sub"])
+}
@@ -2,16 +2,29 @@ package jwtauth
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/anz-bank/sysl-go/config/sensitive"
 	"github.com/anz-bank/sysl-go/jsontime"
+	"github.com/anz-bank/sysl-go/metrics"
+	"github.com/go-jose/go-jose/v3"
 	"github.com/pkg/errors"
 )
 
 // Config defines configuration for the standard authenticator.
 type Config struct {
 	Issuers []IssuerConfig `json:"issuers"         yaml:"issuers"         mapstructure:"issuers"`
+
+	// APIKey authenticates the requests without a bearer token by API key.
+	APIKey *APIKeyConfig `json:"apiKey,omitempty" yaml:"apiKey,omitempty" mapstructure:"apiKey"`
+
+	// Basic authenticates the requests without a bearer token by Basic credentials.
+	Basic *BasicConfig `json:"basic,omitempty"  yaml:"basic,omitempty"  mapstructure:"basic"`
 }
 
 // AuthFromConfig constructs a standard authenticator from config.
@@ -24,36 +37,249 @@ func AuthFromConfig(ctx context.Context, c *Config, client func(string) *http.Cl
 		return nil, errors.New("AuthConfig: Config must not be nil")
 	}
 	verifiers := map[string]Verifier{}
+	validations := map[string]*ClaimsValidation{}
+	introspectors := map[string]Authenticator{}
+	tokenPrefixes := map[string]string{}
 	for _, ic := range c.Issuers {
 		if ic.Name == "" {
 			return nil, errors.New("AuthConfig: Issuer must have a name")
 		}
-		if _, ok := verifiers[ic.Name]; ok {
+		if _, ok := validations[ic.Name]; ok {
 			return nil, errors.New("AuthConfig: Issuer names are not unique")
 		}
+		if ic.Name == APIKeyScheme || ic.Name == BasicScheme {
+			// The iss claim of the requests authenticated by credentials must not be mistaken for an issuer.
+			return nil, fmt.Errorf("AuthConfig: Issuer name %s is reserved for the credentials authenticated by %s", ic.Name, ic.Name)
+		}
+		validation, err := ClaimsValidationFromIssuerConfig(ic)
+		if err != nil {
+			return nil, errors.Wrapf(err, "AuthConfig: Error creating claims validation for issuer %s", ic.Name)
+		}
+		validations[ic.Name] = validation
+		if ic.Introspection != nil {
+			if sources := ic.keySources(); len(sources) > 0 {
+				return nil, fmt.Errorf("AuthConfig: Issuer %s must not have both introspection and %s set", ic.Name, strings.Join(sources, ", "))
+			}
+			introspector, err := NewIntrospectionAuthenticator(ic.Name, *ic.Introspection, client(ic.Name), validation)
+			if err != nil {
+				return nil, errors.Wrapf(err, "AuthConfig: Error creating introspection for issuer %s", ic.Name)
+			}
+			introspectors[ic.Name] = introspector
+			tokenPrefixes[ic.Name] = ic.Introspection.TokenPrefix
+			continue
+		}
 		v, err := VerifierFromIssuerConfig(ctx, ic, client(ic.Name))
 		if err != nil {
 			return nil, errors.Wrapf(err, "AuthConfig: Error creating verifier for issuer %s", ic.Name)
 		}
 		verifiers[ic.Name] = v
 	}
-	return &StdAuthenticator{
-		Verifiers: verifiers,
-	}, nil
+	auth := &StdAuthenticator{
+		Verifiers:   verifiers,
+		Validations: validations,
+	}
+	if len(introspectors) > 0 {
+		if err := validateTokenPrefixes(tokenPrefixes); err != nil {
+			return nil, err
+		}
+		auth.Introspectors = introspectors
+		auth.TokenPrefixes = tokenPrefixes
+	}
+	if c.APIKey != nil {
+		apiKey, err := NewAPIKeyAuthenticator(*c.APIKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "AuthConfig: Error creating api key authenticator")
+		}
+		auth.Schemes = append(auth.Schemes, apiKey)
+	}
+	if c.Basic != nil {
+		basic, err := NewBasicAuthenticator(*c.Basic)
+		if err != nil {
+			return nil, errors.Wrap(err, "AuthConfig: Error creating basic authenticator")
+		}
+		auth.Schemes = append(auth.Schemes, basic)
+	}
+	return auth, nil
+}
+
+// validateTokenPrefixes checks that each of several introspection issuers has a distinct token prefix,
+// such that the tokens of an issuer are never sent to the others.
+func validateTokenPrefixes(tokenPrefixes map[string]string) error {
+	if len(tokenPrefixes) < 2 {
+		return nil
+	}
+	names := make([]string, 0, len(tokenPrefixes))
+	for name := range tokenPrefixes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	issuers := map[string]string{}
+	for _, name := range names {
+		prefix := tokenPrefixes[name]
+		if prefix == "" {
+			return fmt.Errorf("AuthConfig: Issuer %s must have an introspection tokenPrefix when several issuers use introspection", name)
+		}
+		if other, ok := issuers[prefix]; ok {
+			return fmt.Errorf("AuthConfig: Issuers %s and %s must not have the same introspection tokenPrefix", other, name)
+		}
+		issuers[prefix] = name
+	}
+	return nil
 }
 
 // IssuerConfig defines config for issuers for the std authenticator.
+//
+// Exactly one of JWKSURL, JWKS, JWKSFile, PublicKeyFile, SharedSecret or Introspection must be set.
 type IssuerConfig struct {
 	Name         string            `json:"name"                       yaml:"name"                       mapstructure:"name"`
 	JWKSURL      string            `json:"jwksUrl,omitempty"          yaml:"jwksUrl,omitempty"          mapstructure:"jwksUrl"`
 	CacheTTL     jsontime.Duration `json:"cacheTTL"                   yaml:"cacheTTL"                   mapstructure:"cacheTTL"`
 	CacheRefresh jsontime.Duration `json:"cacheRefresh"               yaml:"cacheRefresh"               mapstructure:"cacheRefresh"`
+
+	// KidMissRefreshInterval is the minimum time between the refreshes of the jwksUrl made for tokens
+	// with unknown key ids, defaulting to 30s.
+	KidMissRefreshInterval jsontime.Duration `json:"kidMissRefreshInterval,omitempty" yaml:"kidMissRefreshInterval,omitempty" mapstructure:"kidMissRefreshInterval"`
+
+	// MaxBackoff is the maximum delay between the attempts to refresh the jwksUrl after failures,
+	// defaulting to 5m.
+	MaxBackoff jsontime.Duration `json:"maxBackoff,omitempty"       yaml:"maxBackoff,omitempty"       mapstructure:"maxBackoff"`
+
+	// MaxStale is how long the keys of the jwksUrl are served after the cache ttl while they cannot be
+	// refreshed, defaulting to 24h. Negative values disable serving stale keys.
+	MaxStale jsontime.Duration `json:"maxStale,omitempty"         yaml:"maxStale,omitempty"         mapstructure:"maxStale"`
+
+	// FetchTimeout is the timeout of each request for the jwksUrl, defaulting to 5s.
+	FetchTimeout jsontime.Duration `json:"fetchTimeout,omitempty"     yaml:"fetchTimeout,omitempty"     mapstructure:"fetchTimeout"`
+
+	// JWKS is an inline JWKS document holding the public keys of the issuer.
+	JWKS string `json:"jwks,omitempty"             yaml:"jwks,omitempty"             mapstructure:"jwks"`
+
+	// JWKSFile is the path of a JWKS document holding the public keys of the issuer.
+	JWKSFile string `json:"jwksFile,omitempty"         yaml:"jwksFile,omitempty"         mapstructure:"jwksFile"`
+
+	// PublicKeyFile is the path of a PEM encoded public key or certificate of the issuer.
+	PublicKeyFile string `json:"publicKeyFile,omitempty"    yaml:"publicKeyFile,omitempty"    mapstructure:"publicKeyFile"`
+
+	// SharedSecret is the secret of the HMAC signatures of the issuer, at least 32 bytes long.
+	SharedSecret *sensitive.String `json:"sharedSecret,omitempty"     yaml:"sharedSecret,omitempty"     mapstructure:"sharedSecret"`
+
+	// Algorithms are the signing algorithms accepted from the issuer, for example RS256 or ES256.
+	// Defaults to the algorithms of the public key, the HMAC algorithms for a shared secret, and the
+	// asymmetric algorithms for a JWKS.
+	Algorithms []string `json:"algorithms,omitempty"       yaml:"algorithms,omitempty"       mapstructure:"algorithms"`
+
+	// Introspection is the introspection endpoint (RFC 7662) of an issuer of opaque tokens.
+	Introspection *IntrospectionConfig `json:"introspection,omitempty"    yaml:"introspection,omitempty"    mapstructure:"introspection"`
+
+	// Audiences are the audiences of which the tokens of the issuer must hold at least one in their
+	// aud claim, not checked when empty.
+	Audiences []string `json:"audiences,omitempty"        yaml:"audiences,omitempty"        mapstructure:"audiences"`
+
+	// RequiredClaims are the claims the tokens of the issuer must hold.
+	RequiredClaims []RequiredClaimConfig `json:"requiredClaims,omitempty"   yaml:"requiredClaims,omitempty"   mapstructure:"requiredClaims"`
+
+	// MaxAge is the maximum time since the tokens of the issuer were issued (iat), not checked when zero.
+	MaxAge jsontime.Duration `json:"maxAge,omitempty"           yaml:"maxAge,omitempty"           mapstructure:"maxAge"`
+
+	// Leeway is the clock skew allowed when validating the exp, nbf and iat claims, defaulting to 1s.
+	Leeway jsontime.Duration `json:"leeway,omitempty"           yaml:"leeway,omitempty"           mapstructure:"leeway"`
 }
 
 // VerifierFromIssuerConfig creates a token verifier from issuer config.
+//
+//nolint:funlen
 func VerifierFromIssuerConfig(ctx context.Context, i IssuerConfig, client *http.Client) (Verifier, error) {
-	if i.JWKSURL != "" {
-		return NewRemoteJWKSIssuer(ctx, i.Name, i.JWKSURL, client, time.Duration(i.CacheTTL), time.Duration(i.CacheRefresh))
+	if len(i.keySources()) != 1 {
+		return nil, errors.New("jwtauth.Config: Must have exactly one of jwksUrl, jwks, jwksFile, publicKeyFile or sharedSecret set")
+	}
+
+	switch {
+	case i.JWKSURL != "":
+		algorithms, err := resolveAlgorithms(i.Algorithms, asymmetricAlgos, asymmetricAlgos)
+		if err != nil {
+			return nil, err
+		}
+		options := RemoteJWKSOptions{
+			KidMissRefreshInterval: time.Duration(i.KidMissRefreshInterval),
+			MaxBackoff:             time.Duration(i.MaxBackoff),
+			MaxStale:               time.Duration(i.MaxStale),
+			FetchTimeout:           time.Duration(i.FetchTimeout),
+		}
+		if registry := metrics.GetRegistry(ctx); registry != nil {
+			options.Metrics = metrics.NewJWKSMetrics(registry, i.Name)
+		}
+		v, err := NewRemoteJWKSIssuerWithOptions(ctx, i.Name, i.JWKSURL, client,
+			time.Duration(i.CacheTTL), time.Duration(i.CacheRefresh), options)
+		if err != nil {
+			return nil, err
+		}
+		return withAllowedAlgorithms(v, algorithms), nil
+	case i.JWKS != "" || i.JWKSFile != "":
+		data := []byte(i.JWKS)
+		if i.JWKSFile != "" {
+			var err error
+			if data, err = os.ReadFile(i.JWKSFile); err != nil {
+				return nil, err
+			}
+		}
+		jwks, err := parseJWKS(data)
+		if err != nil {
+			return nil, err
+		}
+		algorithms, err := resolveAlgorithms(i.Algorithms, asymmetricAlgos, asymmetricAlgos)
+		if err != nil {
+			return nil, err
+		}
+		return withAllowedAlgorithms(NewStaticKeysVerifier(jwks), algorithms), nil
+	case i.PublicKeyFile != "":
+		data, err := os.ReadFile(i.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parsePEMPublicKey(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid public key file %s", i.PublicKeyFile)
+		}
+		supported, err := algorithmsForKey(key)
+		if err != nil {
+			return nil, err
+		}
+		algorithms, err := resolveAlgorithms(i.Algorithms, supported, supported)
+		if err != nil {
+			return nil, err
+		}
+		jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key}}}
+		return withAllowedAlgorithms(NewStaticKeysVerifier(jwks), algorithms), nil
+	default:
+		secret := i.SharedSecret.Value()
+		if len(secret) < minSharedSecretLength {
+			return nil, fmt.Errorf("jwtauth.Config: sharedSecret must be at least %d bytes long", minSharedSecretLength)
+		}
+		algorithms, err := resolveAlgorithms(i.Algorithms, hmacAlgorithms, hmacAlgorithms)
+		if err != nil {
+			return nil, err
+		}
+		jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: []byte(secret)}}}
+		return withAllowedAlgorithms(NewStaticKeysVerifier(jwks), algorithms), nil
+	}
+}
+
+// keySources returns the names of the sources of the keys of the issuer that are set.
+func (i IssuerConfig) keySources() []string {
+	var sources []string
+	for _, source := range []struct {
+		name string
+		set  bool
+	}{
+		{"jwksUrl", i.JWKSURL != ""},
+		{"jwks", i.JWKS != ""},
+		{"jwksFile", i.JWKSFile != ""},
+		{"publicKeyFile", i.PublicKeyFile != ""},
+		{"sharedSecret", i.SharedSecret != nil},
+	} {
+		if source.set {
+			sources = append(sources, source.name)
+		}
 	}
-	return nil, errors.New("jwtauth.Config: Can only have one of SharedSecret, PublicKey or JWKSURL set")
+	return sources
 }
@@ -63,6 +63,24 @@ func TestAuthFromConfigIssuerMissingName(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestAuthFromConfigReservedIssuerNames(t *testing.T) {
+	ctx := testContext()
+	url, client := testClient()
+	for _, name := range []string{APIKeyScheme, BasicScheme} {
+		ac := &Config{
+			Issuers: []IssuerConfig{
+				{
+					Name:     name,
+					JWKSURL:  url,
+					CacheTTL: jsontime.Duration(time.Minute),
+				},
+			},
+		}
+		_, err := AuthFromConfig(ctx, ac, func(string) *http.Client { return client })
+		assert.EqualError(t, err, "AuthConfig: Issuer name "+name+" is reserved for the credentials authenticated by "+name)
+	}
+}
+
 func TestAuthFromConfigIssuerNoMethod(t *testing.T) {
 	ctx := testContext()
 	_, client := testClient()
@@ -0,0 +1,67 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// CredentialsAuthenticator authenticates the credentials of requests other than bearer tokens, such as
+// API keys and Basic credentials, into the same claims as tokens.
+type CredentialsAuthenticator interface {
+	// AuthenticateCredentials authenticates the credentials found in the header or query of a request.
+	// Returns found false when the request holds no credentials of the scheme.
+	AuthenticateCredentials(ctx context.Context, header http.Header, query url.Values) (claims Claims, found bool, err error)
+}
+
+// AuthenticateRequestCredentials authenticates the credentials of a request other than bearer tokens with
+// the given authenticator, when it is a CredentialsAuthenticator. Returns found false when it is not, or
+// when the request holds no such credentials.
+func AuthenticateRequestCredentials(ctx context.Context, authenticator Authenticator, header http.Header, query url.Values) (Claims, bool, error) {
+	credentials, ok := authenticator.(CredentialsAuthenticator)
+	if !ok {
+		return nil, false, nil
+	}
+	return credentials.AuthenticateCredentials(ctx, header, query)
+}
+
+// credentialClaims returns the claims of authenticated credentials: the scheme as the iss claim, the name
+// of the credentials as the sub claim, the space separated scopes as the scope claim and the labels as
+// the labels claim.
+func credentialClaims(scheme, name string, scopes []string, labels map[string]string) Claims {
+	claims := Claims{"iss": scheme, "sub": name}
+	if len(scopes) > 0 {
+		claims["scope"] = strings.Join(scopes, " ")
+	}
+	if len(labels) > 0 {
+		object := make(map[string]interface{}, len(labels))
+		for key, value := range labels {
+			object[key] = value
+		}
+		claims["labels"] = object
+	}
+	return claims
+}
+
+// readCredentialsFile appends the YAML (or JSON) list of credentials of the file with the given path
+// to the given credentials.
+func readCredentialsFile[T any](path string, credentials []T) ([]T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []T
+	if err := yaml.UnmarshalStrict(data, &entries); err != nil {
+		return nil, errors.Wrapf(err, "invalid credentials file %s", path)
+	}
+	return append(append([]T{}, credentials...), entries...), nil
+}
+
+func invalidCredentials(cause error) error {
+	return &AuthError{Code: AuthErrCodeInvalidCredentials, Cause: cause}
+}
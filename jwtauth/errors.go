@@ -38,6 +38,13 @@ const (
 	AuthErrCodeUntrustedSource
 	AuthErrCodeBadSignature
 	AuthErrCodeInsufficientPermissions
+	AuthErrCodeExpiredJWT
+	AuthErrCodeJWTNotYetValid
+	AuthErrCodeJWTTooOld
+	AuthErrCodeInvalidAudience
+	AuthErrCodeRequiredClaim
+	AuthErrCodeInactiveToken
+	AuthErrCodeInvalidCredentials
 )
 
 var errHTTPCodeMap = map[int]int{
@@ -54,4 +61,50 @@ var errHTTPCodeMap = map[int]int{
 
 	// Request is authenticated but does not have sufficient permissions to execute.
 	AuthErrCodeInsufficientPermissions: http.StatusForbidden,
+
+	// Request jwt has expired.
+	AuthErrCodeExpiredJWT: http.StatusUnauthorized,
+
+	// Request jwt is not valid yet (nbf) or was issued in the future (iat).
+	AuthErrCodeJWTNotYetValid: http.StatusUnauthorized,
+
+	// Request jwt was issued longer ago than the maximum age of tokens of its issuer.
+	AuthErrCodeJWTTooOld: http.StatusUnauthorized,
+
+	// Request jwt was not issued for any of the expected audiences.
+	AuthErrCodeInvalidAudience: http.StatusUnauthorized,
+
+	// Request jwt is missing a required claim or has an unexpected value for it.
+	AuthErrCodeRequiredClaim: http.StatusForbidden,
+
+	// Request token was reported as not active by the introspection endpoint of its issuer.
+	AuthErrCodeInactiveToken: http.StatusUnauthorized,
+
+	// Request API key or Basic credentials are malformed, unknown or do not match.
+	AuthErrCodeInvalidCredentials: http.StatusUnauthorized,
+}
+
+var errCodeNames = map[int]string{
+	AuthErrCodeUnknown:                 "unknown",
+	AuthErrCodeInvalidJWT:              "invalid_jwt",
+	AuthErrCodeUntrustedSource:         "untrusted_source",
+	AuthErrCodeBadSignature:            "bad_signature",
+	AuthErrCodeInsufficientPermissions: "insufficient_permissions",
+	AuthErrCodeExpiredJWT:              "expired_jwt",
+	AuthErrCodeJWTNotYetValid:          "jwt_not_yet_valid",
+	AuthErrCodeJWTTooOld:               "jwt_too_old",
+	AuthErrCodeInvalidAudience:         "invalid_audience",
+	AuthErrCodeRequiredClaim:           "required_claim",
+	AuthErrCodeInactiveToken:           "inactive_token",
+	AuthErrCodeInvalidCredentials:      "invalid_credentials",
+}
+
+// AuthErrCodeName returns the snake case name of an AuthError code, e.g. expired_jwt, suitable for
+// logs and metric labels. Unknown codes are named unknown.
+func AuthErrCodeName(code int) string {
+	name, ok := errCodeNames[code]
+	if !ok {
+		return errCodeNames[AuthErrCodeUnknown]
+	}
+	return name
 }
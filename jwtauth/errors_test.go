@@ -42,3 +42,11 @@ func TestAuthErrorUnwrap(t *testing.T) {
 	}
 	assert.Equal(t, inner, err.Unwrap())
 }
+
+func TestAuthErrCodeName(t *testing.T) {
+	assert.Equal(t, "expired_jwt", AuthErrCodeName(AuthErrCodeExpiredJWT))
+	assert.Equal(t, "unknown", AuthErrCodeName(-1))
+	for code := range errHTTPCodeMap {
+		assert.Contains(t, errCodeNames, code)
+	}
+}
@@ -0,0 +1,260 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anz-bank/sysl-go/config/sensitive"
+	"github.com/anz-bank/sysl-go/jsontime"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// maxIntrospectionCacheEntries bounds the number of introspection results cached by an IntrospectionAuthenticator.
+const maxIntrospectionCacheEntries = 10000
+
+// Defaults of the IntrospectionConfig.
+const (
+	DefaultInactiveCacheTTL     = 30 * time.Second
+	DefaultMaxRequestsPerSecond = 50
+)
+
+// IntrospectionConfig defines config for the introspection (RFC 7662) of the tokens of an issuer.
+type IntrospectionConfig struct {
+	// URL is the introspection endpoint of the issuer.
+	URL string `json:"url"                        yaml:"url"                        mapstructure:"url"`
+
+	// ClientID and ClientSecret are the client credentials the introspection endpoint is called with.
+	ClientID     string            `json:"clientId"                   yaml:"clientId"                   mapstructure:"clientId"`
+	ClientSecret *sensitive.String `json:"clientSecret,omitempty"     yaml:"clientSecret,omitempty"     mapstructure:"clientSecret"`
+
+	// TokenTypeHint is the token_type_hint sent to the introspection endpoint, defaulting to access_token.
+	TokenTypeHint string `json:"tokenTypeHint,omitempty"    yaml:"tokenTypeHint,omitempty"    mapstructure:"tokenTypeHint"`
+
+	// MaxCacheTTL limits how long active tokens are cached, which is otherwise until their exp claim.
+	MaxCacheTTL jsontime.Duration `json:"maxCacheTTL,omitempty"      yaml:"maxCacheTTL,omitempty"      mapstructure:"maxCacheTTL"`
+
+	// InactiveCacheTTL is how long inactive tokens are cached, defaulting to 30s. Negative values
+	// disable caching inactive tokens.
+	InactiveCacheTTL jsontime.Duration `json:"inactiveCacheTTL,omitempty" yaml:"inactiveCacheTTL,omitempty" mapstructure:"inactiveCacheTTL"`
+
+	// MaxRequestsPerSecond limits the requests made to the introspection endpoint for tokens that are
+	// not cached, defaulting to 50. Tokens are rejected while the limit is exceeded.
+	MaxRequestsPerSecond float64 `json:"maxRequestsPerSecond,omitempty" yaml:"maxRequestsPerSecond,omitempty" mapstructure:"maxRequestsPerSecond"`
+
+	// TokenPrefix is the prefix of the opaque tokens of the issuer, which selects the issuer whose
+	// introspection endpoint a token is sent to. Required when several issuers use introspection.
+	TokenPrefix string `json:"tokenPrefix,omitempty"      yaml:"tokenPrefix,omitempty"      mapstructure:"tokenPrefix"`
+}
+
+// IntrospectionAuthenticator is an Authenticator of opaque tokens that calls the introspection
+// endpoint (RFC 7662) of their issuer.
+//
+// The introspection response of an active token is returned as its claims, without the active member,
+// such that the scope, sub, aud and other members can be used like the claims of a jwt. The claims of
+// active tokens are cached until their exp claim, tokens without an exp claim are not cached. Inactive
+// tokens are cached for the InactiveCacheTTL, and the requests for tokens that are not cached are rate
+// limited, such that invalid tokens cannot flood the issuer.
+type IntrospectionAuthenticator struct {
+	issuer     string
+	config     IntrospectionConfig
+	client     *http.Client
+	validation *ClaimsValidation
+	limiter    *rate.Limiter
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]introspectionResult
+}
+
+// introspectionResult is a cached introspection result, with nil claims for an inactive token.
+type introspectionResult struct {
+	claims  Claims
+	expires time.Time
+}
+
+// NewIntrospectionAuthenticator creates an IntrospectionAuthenticator of the tokens of the issuer with
+// the given name. Issuers that do not return an iss member are given the name as the iss claim. The
+// claims of active tokens are validated with the given validation, where not nil.
+func NewIntrospectionAuthenticator(issuer string, c IntrospectionConfig, client *http.Client,
+	validation *ClaimsValidation) (*IntrospectionAuthenticator, error) {
+	if c.URL == "" {
+		return nil, errors.New("jwtauth.Config: introspection must have a url")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return nil, err
+	}
+	if c.ClientID == "" {
+		return nil, errors.New("jwtauth.Config: introspection must have a clientId")
+	}
+	if c.TokenTypeHint == "" {
+		c.TokenTypeHint = "access_token"
+	}
+	if c.InactiveCacheTTL == 0 {
+		c.InactiveCacheTTL = jsontime.Duration(DefaultInactiveCacheTTL)
+	}
+	if c.MaxRequestsPerSecond < 0 {
+		return nil, errors.New("jwtauth.Config: introspection maxRequestsPerSecond must not be negative")
+	}
+	if c.MaxRequestsPerSecond == 0 {
+		c.MaxRequestsPerSecond = DefaultMaxRequestsPerSecond
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &IntrospectionAuthenticator{
+		issuer:     issuer,
+		config:     c,
+		client:     client,
+		validation: validation,
+		limiter:    rate.NewLimiter(rate.Limit(c.MaxRequestsPerSecond), int(math.Ceil(c.MaxRequestsPerSecond))),
+		cache:      map[[sha256.Size]byte]introspectionResult{},
+	}, nil
+}
+
+// Authenticate implements the Authenticator interface.
+func (a *IntrospectionAuthenticator) Authenticate(ctx context.Context, raw string) (Claims, error) {
+	key := sha256.Sum256([]byte(raw))
+	now := time.Now()
+	if claims, ok := a.cached(key, now); ok {
+		if claims == nil {
+			return Claims{}, a.inactive()
+		}
+		return claims, nil
+	}
+
+	if !a.limiter.Allow() {
+		pkgLogger.Debug(ctx, "token introspection rate limit exceeded for issuer:", a.issuer)
+		return Claims{}, &AuthError{
+			Code:  AuthErrCodeUnknown,
+			Cause: fmt.Errorf("introspection rate limit exceeded for issuer %s", a.issuer),
+		}
+	}
+	claims, err := a.introspect(ctx, raw)
+	if err != nil {
+		pkgLogger.Debug(ctx, "token introspection error:", err)
+		var authErr *AuthError
+		if ttl := time.Duration(a.config.InactiveCacheTTL); ttl > 0 && errors.As(err, &authErr) && authErr.Code == AuthErrCodeInactiveToken {
+			a.store(key, introspectionResult{expires: now.Add(ttl)}, now)
+		}
+		return Claims{}, err
+	}
+	var timeClaims jwt.Claims
+	if err := convertClaims(claims, &timeClaims); err != nil {
+		return Claims{}, &AuthError{Code: AuthErrCodeUnknown, Cause: errors.Wrap(err, "introspection response error")}
+	}
+	if err := a.validation.validateTime(timeClaims, now); err != nil {
+		pkgLogger.Debug(ctx, "token time validation error:", err)
+		return Claims{}, err
+	}
+	if err := a.validation.validateClaims(claims); err != nil {
+		pkgLogger.Debug(ctx, "token claims validation error:", err)
+		return Claims{}, err
+	}
+	if timeClaims.Expiry != nil {
+		expires := timeClaims.Expiry.Time()
+		if maxTTL := time.Duration(a.config.MaxCacheTTL); maxTTL > 0 && now.Add(maxTTL).Before(expires) {
+			expires = now.Add(maxTTL)
+		}
+		a.store(key, introspectionResult{claims: claims, expires: expires}, now)
+	}
+	return clone(claims), nil
+}
+
+// introspect calls the introspection endpoint, returning the claims of an active token.
+func (a *IntrospectionAuthenticator) introspect(ctx context.Context, raw string) (Claims, error) {
+	form := url.Values{"token": {raw}, "token_type_hint": {a.config.TokenTypeHint}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, &AuthError{Code: AuthErrCodeUnknown, Cause: err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	var secret string
+	if a.config.ClientSecret != nil {
+		secret = a.config.ClientSecret.Value()
+	}
+	// Client credentials are form encoded before being used for basic auth, see RFC 6749 section 2.3.1.
+	req.SetBasicAuth(url.QueryEscape(a.config.ClientID), url.QueryEscape(secret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, &AuthError{Code: AuthErrCodeUnknown, Cause: errors.Wrap(err, "introspection request error")}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &AuthError{
+			Code:  AuthErrCodeUnknown,
+			Cause: fmt.Errorf("introspection error: Received status %d from issuer %s", resp.StatusCode, a.issuer),
+		}
+	}
+	var claims Claims
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, &AuthError{Code: AuthErrCodeUnknown, Cause: errors.Wrap(err, "introspection response error")}
+	}
+	if active, _ := claims["active"].(bool); !active {
+		return nil, a.inactive()
+	}
+	delete(claims, "active")
+	if _, ok := claims["iss"]; !ok {
+		claims["iss"] = a.issuer
+	}
+	return claims, nil
+}
+
+func (a *IntrospectionAuthenticator) inactive() error {
+	return &AuthError{Code: AuthErrCodeInactiveToken, Cause: fmt.Errorf("token is not active for issuer %s", a.issuer)}
+}
+
+// cached returns the cached claims of a token, nil for an inactive token.
+func (a *IntrospectionAuthenticator) cached(key [sha256.Size]byte, now time.Time) (Claims, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result, ok := a.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(result.expires) {
+		delete(a.cache, key)
+		return nil, false
+	}
+	if result.claims == nil {
+		return nil, true
+	}
+	return clone(result.claims), true
+}
+
+// store caches the given result, evicting the expired results when the cache is full. Results are
+// not cached while the cache is full of unexpired results.
+func (a *IntrospectionAuthenticator) store(key [sha256.Size]byte, result introspectionResult, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.cache) >= maxIntrospectionCacheEntries {
+		for k, r := range a.cache {
+			if !now.Before(r.expires) {
+				delete(a.cache, k)
+			}
+		}
+		if len(a.cache) >= maxIntrospectionCacheEntries {
+			return
+		}
+	}
+	a.cache[key] = result
+}
+
+// convertClaims converts claims into the given value through their JSON representation.
+func convertClaims(claims Claims, v interface{}) error {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
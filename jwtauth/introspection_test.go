@@ -0,0 +1,235 @@
+package jwtauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anz-bank/sysl-go/config/sensitive"
+	"github.com/anz-bank/sysl-go/jsontime"
+	"github.com/stretchr/testify/require"
+)
+
+// introspectionServer serves the given response for the token "opaque" and an inactive response for
+// other tokens, counting the introspection requests.
+func introspectionServer(t *testing.T, response Claims) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		clientID, secret, ok := r.BasicAuth()
+		if !ok || clientID != "client" || secret != "s%3Ccret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "access_token", r.PostForm.Get("token_type_hint"))
+		body := response
+		if r.PostForm.Get("token") != "opaque" {
+			body = Claims{"active": false}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func testSecret(value string) *sensitive.String {
+	secret := sensitive.New(value)
+	return &secret
+}
+
+func testIntrospectionConfig(url string) IntrospectionConfig {
+	return IntrospectionConfig{URL: url, ClientID: "client", ClientSecret: testSecret("s<cret")}
+}
+
+func TestIntrospectionAuthenticator(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	server, requests := introspectionServer(t, Claims{"active": true, "scope": "read write", "sub": "user", "exp": exp})
+	a, err := NewIntrospectionAuthenticator("idp", testIntrospectionConfig(server.URL), server.Client(), nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		claims, err := a.Authenticate(testContext(), "opaque")
+		require.NoError(t, err)
+		require.Equal(t, Claims{"iss": "idp", "scope": "read write", "sub": "user", "exp": float64(exp)}, claims)
+		claims["sub"] = "modified"
+	}
+	require.Equal(t, int32(1), requests.Load(), "active tokens should be cached until exp")
+
+	_, err = a.Authenticate(testContext(), "revoked")
+	requireAuthErrCode(t, AuthErrCodeInactiveToken, err)
+	_, err = a.Authenticate(testContext(), "revoked")
+	requireAuthErrCode(t, AuthErrCodeInactiveToken, err)
+	require.Equal(t, int32(2), requests.Load(), "inactive tokens should be cached for the inactive cache ttl")
+}
+
+func TestIntrospectionAuthenticatorInactiveCacheTTL(t *testing.T) {
+	server, requests := introspectionServer(t, Claims{"active": true})
+	c := testIntrospectionConfig(server.URL)
+	c.InactiveCacheTTL = jsontime.Duration(10 * time.Millisecond)
+	a, err := NewIntrospectionAuthenticator("idp", c, server.Client(), nil)
+	require.NoError(t, err)
+	_, err = a.Authenticate(testContext(), "revoked")
+	requireAuthErrCode(t, AuthErrCodeInactiveToken, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = a.Authenticate(testContext(), "revoked")
+	requireAuthErrCode(t, AuthErrCodeInactiveToken, err)
+	require.Equal(t, int32(2), requests.Load())
+
+	c.InactiveCacheTTL = jsontime.Duration(-1)
+	a, err = NewIntrospectionAuthenticator("idp", c, server.Client(), nil)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = a.Authenticate(testContext(), "revoked")
+		requireAuthErrCode(t, AuthErrCodeInactiveToken, err)
+	}
+	require.Equal(t, int32(4), requests.Load(), "negative ttls disable caching inactive tokens")
+}
+
+func TestIntrospectionAuthenticatorRateLimit(t *testing.T) {
+	server, requests := introspectionServer(t, Claims{"active": true})
+	c := testIntrospectionConfig(server.URL)
+	c.MaxRequestsPerSecond = 2
+	a, err := NewIntrospectionAuthenticator("idp", c, server.Client(), nil)
+	require.NoError(t, err)
+	for _, token := range []string{"a", "b"} {
+		_, err = a.Authenticate(testContext(), token)
+		requireAuthErrCode(t, AuthErrCodeInactiveToken, err)
+	}
+	_, err = a.Authenticate(testContext(), "c")
+	requireAuthErrCode(t, AuthErrCodeUnknown, err)
+	require.Equal(t, int32(2), requests.Load(), "requests beyond the limit are not made")
+
+	// Cached tokens are not limited.
+	_, err = a.Authenticate(testContext(), "a")
+	requireAuthErrCode(t, AuthErrCodeInactiveToken, err)
+
+	c.MaxRequestsPerSecond = -1
+	_, err = NewIntrospectionAuthenticator("idp", c, server.Client(), nil)
+	require.Error(t, err)
+}
+
+func TestIntrospectionAuthenticatorCacheTTL(t *testing.T) {
+	server, requests := introspectionServer(t, Claims{"active": true, "exp": time.Now().Add(time.Hour).Unix()})
+	c := testIntrospectionConfig(server.URL)
+	c.MaxCacheTTL = jsontime.Duration(10 * time.Millisecond)
+	a, err := NewIntrospectionAuthenticator("idp", c, server.Client(), nil)
+	require.NoError(t, err)
+	_, err = a.Authenticate(testContext(), "opaque")
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = a.Authenticate(testContext(), "opaque")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), requests.Load())
+
+	// Tokens without exp are not cached.
+	server, requests = introspectionServer(t, Claims{"active": true})
+	a, err = NewIntrospectionAuthenticator("idp", testIntrospectionConfig(server.URL), server.Client(), nil)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = a.Authenticate(testContext(), "opaque")
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(2), requests.Load())
+}
+
+func TestIntrospectionAuthenticatorValidation(t *testing.T) {
+	server, _ := introspectionServer(t, Claims{"active": true, "aud": "other", "exp": time.Now().Add(-time.Minute).Unix()})
+	a, err := NewIntrospectionAuthenticator("idp", testIntrospectionConfig(server.URL), server.Client(), nil)
+	require.NoError(t, err)
+	_, err = a.Authenticate(testContext(), "opaque")
+	requireAuthErrCode(t, AuthErrCodeExpiredJWT, err)
+
+	server, _ = introspectionServer(t, Claims{"active": true, "aud": "other"})
+	a, err = NewIntrospectionAuthenticator("idp", testIntrospectionConfig(server.URL), server.Client(),
+		&ClaimsValidation{Audiences: []string{"service"}})
+	require.NoError(t, err)
+	_, err = a.Authenticate(testContext(), "opaque")
+	requireAuthErrCode(t, AuthErrCodeInvalidAudience, err)
+}
+
+func TestIntrospectionAuthenticatorErrors(t *testing.T) {
+	server, _ := introspectionServer(t, Claims{"active": true})
+	c := testIntrospectionConfig(server.URL)
+	c.ClientSecret = testSecret("wrong")
+	a, err := NewIntrospectionAuthenticator("idp", c, server.Client(), nil)
+	require.NoError(t, err)
+	_, err = a.Authenticate(testContext(), "opaque")
+	requireAuthErrCode(t, AuthErrCodeUnknown, err)
+
+	_, err = NewIntrospectionAuthenticator("idp", IntrospectionConfig{ClientID: "client"}, nil, nil)
+	require.Error(t, err)
+	_, err = NewIntrospectionAuthenticator("idp", IntrospectionConfig{URL: server.URL}, nil, nil)
+	require.Error(t, err)
+}
+
+func TestStdAuthenticatorIntrospection(t *testing.T) {
+	server, _ := introspectionServer(t, Claims{"active": true, "scope": "read"})
+	url, client := testClient()
+	auth, err := AuthFromConfig(testContext(), &Config{Issuers: []IssuerConfig{
+		{Name: "test", JWKSURL: url, CacheTTL: jsontime.Duration(time.Minute)},
+		{Name: "idp", Introspection: &IntrospectionConfig{URL: server.URL, ClientID: "client", ClientSecret: testSecret("s<cret")}},
+	}}, func(name string) *http.Client {
+		if name == "test" {
+			return client
+		}
+		return server.Client()
+	})
+	require.NoError(t, err)
+
+	claims, err := auth.Authenticate(testContext(), "opaque")
+	require.NoError(t, err)
+	require.Equal(t, "read", claims["scope"])
+	require.Equal(t, "idp", claims["iss"])
+
+	_, err = auth.Authenticate(testContext(), "revoked")
+	requireAuthErrCode(t, AuthErrCodeInactiveToken, err)
+
+	// JWTs are still verified with the keys of their issuer.
+	claims, err = auth.Authenticate(testContext(), issueTestJWT())
+	require.NoError(t, err)
+	require.Equal(t, "test", claims["iss"])
+}
+
+func TestStdAuthenticatorIntrospectionTokenPrefix(t *testing.T) {
+	server, requests := introspectionServer(t, Claims{"active": true, "scope": "read"})
+	other, otherRequests := introspectionServer(t, Claims{"active": true, "scope": "other"})
+	auth, err := AuthFromConfig(testContext(), &Config{Issuers: []IssuerConfig{
+		{Name: "idp", Introspection: &IntrospectionConfig{URL: server.URL, ClientID: "client", ClientSecret: testSecret("s<cret"), TokenPrefix: "op"}},
+		{Name: "other", Introspection: &IntrospectionConfig{URL: other.URL, ClientID: "client", ClientSecret: testSecret("s<cret"), TokenPrefix: "oth_"}},
+	}}, func(name string) *http.Client { return server.Client() })
+	require.NoError(t, err)
+
+	claims, err := auth.Authenticate(testContext(), "opaque")
+	require.NoError(t, err)
+	require.Equal(t, "idp", claims["iss"])
+
+	// Tokens are only sent to the issuer of their prefix, and tokens of no issuer to none.
+	_, err = auth.Authenticate(testContext(), "oth_token")
+	requireAuthErrCode(t, AuthErrCodeInactiveToken, err)
+	_, err = auth.Authenticate(testContext(), "garbage")
+	requireAuthErrCode(t, AuthErrCodeInvalidJWT, err)
+	require.Equal(t, int32(1), requests.Load())
+	require.Equal(t, int32(1), otherRequests.Load())
+
+	for _, prefixes := range [][2]string{{"op", ""}, {"op", "op"}} {
+		_, err = AuthFromConfig(testContext(), &Config{Issuers: []IssuerConfig{
+			{Name: "idp", Introspection: &IntrospectionConfig{URL: server.URL, ClientID: "client", TokenPrefix: prefixes[0]}},
+			{Name: "other", Introspection: &IntrospectionConfig{URL: other.URL, ClientID: "client", TokenPrefix: prefixes[1]}},
+		}}, func(string) *http.Client { return nil })
+		require.Error(t, err, "%v", prefixes)
+	}
+}
+
+func TestAuthFromConfigIntrospectionWithKeys(t *testing.T) {
+	_, err := AuthFromConfig(testContext(), &Config{Issuers: []IssuerConfig{{
+		Name:          "idp",
+		JWKS:          testJWKS,
+		Introspection: &IntrospectionConfig{URL: "http://localhost", ClientID: "client"},
+	}}}, func(string) *http.Client { return nil })
+	require.ErrorContains(t, err, "must not have both introspection and jwks set")
+}
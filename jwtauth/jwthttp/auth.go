@@ -100,7 +100,8 @@ func (a Auth) Auth() func(http.Handler) http.Handler {
 // AuthAllowAnon is a middleware function. It takes a handler and produces a new handler that authenticates and
 // authorises requests before passing them to the given handler.
 //
-// If an authorization header is present, the contained JWT is validated.  Otherwise, middleware processing continues.
+// If an authorization header is present, the contained JWT is validated, as are the other credentials of the request
+// when the Authenticator is a jwtauth.CredentialsAuthenticator.  Otherwise, middleware processing continues.
 // AllowAnon is useful where claims (if present) are required by a middleware stack, but not all endpoints in a mux require
 // a jwt to be present.
 //
@@ -114,13 +115,17 @@ func (a Auth) AuthAllowAnon() func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims, ok := jwtauth.GetClaimsFromContext(r.Context())
 			if !ok {
-				raw := a.getBearer(r.Header)
-				if raw == "" {
-					next.ServeHTTP(w, r)
-					return
-				}
 				var err error
-				claims, err := a.Authenticate(r.Context(), raw)
+				if raw := a.getBearer(r.Header); raw != "" {
+					claims, err = a.Authenticate(r.Context(), raw)
+				} else {
+					var found bool
+					claims, found, err = jwtauth.AuthenticateRequestCredentials(r.Context(), a.Authenticator, r.Header, r.URL.Query())
+					if !found {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
 				if err != nil {
 					a.UnauthHandler(w, r, err)
 					return
@@ -139,11 +144,20 @@ func (a Auth) AuthAllowAnon() func(http.Handler) http.Handler {
 // AuthenticateRequest authenticates the request
 //
 // Returns the claims contained in the jwt, or an error if unable to
-// authenticate.
+// authenticate. Requests without a bearer token are authenticated by their
+// other credentials when the Authenticator is a jwtauth.CredentialsAuthenticator.
 func (a *Auth) AuthenticateRequest(req *http.Request) (jwtauth.Claims, error) {
 	// Find the token in the request and authenticate it
 	// It is the job of AuthN to accept or reject requests with no token
 	raw := a.getBearer(req.Header)
+	if raw == "" {
+		if claims, found, err := jwtauth.AuthenticateRequestCredentials(req.Context(), a.Authenticator, req.Header, req.URL.Query()); found {
+			if err != nil {
+				return jwtauth.Claims{}, err
+			}
+			return claims, nil
+		}
+	}
 	claims, err := a.Authenticate(req.Context(), raw)
 	if err != nil {
 		return jwtauth.Claims{}, err
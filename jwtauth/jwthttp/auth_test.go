@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -174,3 +175,47 @@ func TestGetBearer(t *testing.T) {
 	}
 	assert.Equal(t, "token", auth.getBearer(headers))
 }
+
+// credentialsAuthenticator authenticates the X-Api-Key header key-one, and rejects bearer tokens.
+type credentialsAuthenticator struct {
+	badAuthenticator
+}
+
+func (credentialsAuthenticator) AuthenticateCredentials(_ context.Context, header http.Header, _ url.Values) (jwtauth.Claims, bool, error) {
+	switch header.Get("X-Api-Key") {
+	case "":
+		return nil, false, nil
+	case "key-one":
+		return jwtauth.Claims{"iss": "apikey", "sub": "one"}, true, nil
+	default:
+		return jwtauth.Claims{}, true, &jwtauth.AuthError{Code: jwtauth.AuthErrCodeInvalidCredentials, Cause: errors.New("unknown api key")}
+	}
+}
+
+func TestAuthCredentials(t *testing.T) {
+	auth := &Auth{
+		Headers:       []string{"Authorization"},
+		Authenticator: credentialsAuthenticator{badAuthenticator{errors.New("bad auth")}},
+	}
+	var sub interface{}
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := jwtauth.GetClaimsFromContext(r.Context())
+		sub = claims["sub"]
+	})
+	for _, middleware := range []func(http.Handler) http.Handler{auth.Auth(), auth.AuthAllowAnon()} {
+		server := common.NewHTTPTestServer(middleware(endpoint))
+		for key, status := range map[string]int{"key-one": http.StatusOK, "key-two": http.StatusUnauthorized} {
+			sub = nil
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			req.Header.Set("X-Api-Key", key)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, status, resp.StatusCode, key)
+			if status == http.StatusOK {
+				assert.Equal(t, "one", sub)
+			}
+		}
+		server.Close()
+	}
+}
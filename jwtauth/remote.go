@@ -7,21 +7,120 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/anz-bank/sysl-go/metrics"
 	"github.com/go-jose/go-jose/v3"
 	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/pkg/errors"
 )
 
+// Defaults of the RemoteJWKSOptions.
+const (
+	DefaultKidMissRefreshInterval = 30 * time.Second
+	DefaultInitialBackoff         = time.Second
+	DefaultMaxBackoff             = 5 * time.Minute
+	DefaultMaxStale               = 24 * time.Hour
+	DefaultFetchTimeout           = 5 * time.Second
+)
+
+// RemoteJWKSOptions tune how a RemoteJWKSIssuer refreshes its jwks. Zero values take the defaults.
+type RemoteJWKSOptions struct {
+	// KidMissRefreshInterval is the minimum time between the refreshes made for tokens with a key id
+	// missing from the cached jwks. It is also the minimum cache ttl taken from a Cache-Control header.
+	KidMissRefreshInterval time.Duration
+
+	// InitialBackoff is the delay before retrying after consecutive failed refreshes, doubling with each
+	// failure up to MaxBackoff. The first failure is retried without delay.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// MaxStale is how long the keys of an expired cache keep being served while the jwks cannot be
+	// refreshed. Negative values disable serving stale keys.
+	MaxStale time.Duration
+
+	// FetchTimeout bounds each fetch of the jwks, such that a refresh made while verifying a token
+	// cannot hold the request for the whole timeout of the client.
+	FetchTimeout time.Duration
+
+	// Metrics records the state of the cache, nil to record nothing.
+	Metrics *metrics.JWKSMetrics
+}
+
+func (o RemoteJWKSOptions) kidMissRefreshInterval() time.Duration {
+	if o.KidMissRefreshInterval == 0 {
+		return DefaultKidMissRefreshInterval
+	}
+	return o.KidMissRefreshInterval
+}
+
+func (o RemoteJWKSOptions) backoff(failures int) time.Duration {
+	if failures < 2 {
+		return 0
+	}
+	initial, maxBackoff := o.InitialBackoff, o.MaxBackoff
+	if initial == 0 {
+		initial = DefaultInitialBackoff
+	}
+	if maxBackoff == 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	backoff := initial
+	for i := 2; i < failures && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+func (o RemoteJWKSOptions) fetchTimeout() time.Duration {
+	if o.FetchTimeout == 0 {
+		return DefaultFetchTimeout
+	}
+	return o.FetchTimeout
+}
+
+func (o RemoteJWKSOptions) maxStale() time.Duration {
+	switch {
+	case o.MaxStale == 0:
+		return DefaultMaxStale
+	case o.MaxStale < 0:
+		return 0
+	default:
+		return o.MaxStale
+	}
+}
+
 // RemoteJWKSIssuer is a Verifier that retrieves and stores a jwks from a remote issuer.
 //
 // Assumes the public key is served at GET {url}/.well-known/jwks.json.
+//
+// Refreshes are shared between concurrent callers, backed off exponentially on failure and throttled
+// for tokens with unknown key ids, such that tokens with random key ids cannot flood the issuer. The
+// keys of an expired cache keep being served while it is refreshed in the background, or while the
+// issuer is unavailable, up to the MaxStale option.
 type RemoteJWKSIssuer struct {
-	url    string
-	client *http.Client
-	cache  *jwksCache
+	url     string
+	client  *http.Client
+	cache   *jwksCache
+	options RemoteJWKSOptions
+
+	mu          sync.Mutex
+	inflight    *jwksRefresh
+	failures    int
+	lastAttempt time.Time
+}
+
+// jwksRefresh is a refresh of the jwks shared between its concurrent callers.
+type jwksRefresh struct {
+	done chan struct{}
+	jwks *jose.JSONWebKeySet
+	err  error
 }
 
 // NewRemoteJWKSIssuer creates a new RemoteJWKSIssuer.
@@ -33,6 +132,15 @@ type RemoteJWKSIssuer struct {
 // cacheRefresh defines a cycle-time for a pre-emptive refresh background process (where cacheRefresh > 0).
 func NewRemoteJWKSIssuer(ctx context.Context, issuer string, issuerURL string, client *http.Client, cacheTTL time.Duration,
 	cacheRefresh time.Duration) (*RemoteJWKSIssuer, error) {
+	return NewRemoteJWKSIssuerWithOptions(ctx, issuer, issuerURL, client, cacheTTL, cacheRefresh, RemoteJWKSOptions{})
+}
+
+// NewRemoteJWKSIssuerWithOptions creates a new RemoteJWKSIssuer with the given options, see NewRemoteJWKSIssuer.
+// UNSTABLE: This API should be avoided in favour of `VerifierFromIssuerConfig()`.
+//
+// The background refresh process stops when the given context is done.
+func NewRemoteJWKSIssuerWithOptions(ctx context.Context, issuer string, issuerURL string, client *http.Client,
+	cacheTTL time.Duration, cacheRefresh time.Duration, options RemoteJWKSOptions) (*RemoteJWKSIssuer, error) {
 	// Verify the issuer url is valid by parsing it
 	if _, err := url.Parse(issuerURL); err != nil {
 		return nil, err
@@ -46,24 +154,34 @@ func NewRemoteJWKSIssuer(ctx context.Context, issuer string, issuerURL string, c
 		cache: &jwksCache{
 			ttl: cacheTTL,
 		},
+		options: options,
 	}
-	if _, err := r.refreshCache(); err != nil {
+	if _, err := r.refresh(); err != nil {
 		pkgLogger.Debug(ctx, "Error initializing jwks cache for remote issuer:", issuer, err)
 	}
 	if cacheRefresh > 0 {
-		go func() {
-			for {
-				time.Sleep(cacheRefresh)
-				pkgLogger.Debug(ctx, "Refreshing JWKS Cache")
-				if _, err := r.refreshCache(); err != nil {
-					pkgLogger.Debug(ctx, "Error in Refreshing Cache for JWKS API", err)
-				}
-			}
-		}()
+		go r.refreshPeriodically(ctx, cacheRefresh)
 	}
 	return r, nil
 }
 
+// refreshPeriodically refreshes the cache every interval until the context is done.
+func (r *RemoteJWKSIssuer) refreshPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pkgLogger.Debug(ctx, "Refreshing JWKS Cache")
+			if _, err := r.refresh(); err != nil {
+				pkgLogger.Debug(ctx, "Error in Refreshing Cache for JWKS API", err)
+			}
+		}
+	}
+}
+
 // Verify implements the Verify interface for RemoteJWKSIssuer.
 func (r *RemoteJWKSIssuer) Verify(token *jwt.JSONWebToken, claims ...interface{}) error {
 	headers := token.Headers
@@ -76,9 +194,11 @@ func (r *RemoteJWKSIssuer) Verify(token *jwt.JSONWebToken, claims ...interface{}
 	}
 	kid := headers[0].KeyID
 
-	keys, err := r.cache.getKey(kid)
-	if err != nil {
-		jwks, err := r.refreshCache()
+	keys, age, cached := r.cache.lookup(kid)
+	ttl := r.cache.expiry()
+	switch {
+	case !cached || age > ttl+r.options.maxStale():
+		jwks, err := r.refresh()
 		if err != nil {
 			return &AuthError{
 				Code:  AuthErrCodeUnknown,
@@ -86,6 +206,15 @@ func (r *RemoteJWKSIssuer) Verify(token *jwt.JSONWebToken, claims ...interface{}
 			}
 		}
 		keys = jwks.Key(kid)
+	case age > ttl:
+		// Serve the stale keys while refreshing in the background.
+		r.refreshInBackground()
+		if len(keys) > 0 {
+			r.options.Metrics.StaleServed()
+		}
+	}
+	if len(keys) == 0 && cached {
+		keys = r.refreshForKidMiss(kid)
 	}
 	if len(keys) == 0 {
 		return &AuthError{
@@ -103,25 +232,131 @@ func (r *RemoteJWKSIssuer) Verify(token *jwt.JSONWebToken, claims ...interface{}
 	return nil
 }
 
+// refreshForKidMiss refreshes the cache for a key id missing from it, unless the cache has been
+// refreshed within the kid miss refresh interval.
+func (r *RemoteJWKSIssuer) refreshForKidMiss(kid string) []jose.JSONWebKey {
+	r.mu.Lock()
+	throttled := time.Since(r.lastAttempt) < r.options.kidMissRefreshInterval()
+	r.mu.Unlock()
+	r.options.Metrics.KidMiss(!throttled)
+	if throttled {
+		return nil
+	}
+	jwks, err := r.refresh()
+	if err != nil {
+		return nil
+	}
+	return jwks.Key(kid)
+}
+
+// refreshInBackground starts a refresh of the cache unless one is in progress or backing off.
+func (r *RemoteJWKSIssuer) refreshInBackground() {
+	r.mu.Lock()
+	idle := r.inflight == nil && !r.backingOff()
+	r.mu.Unlock()
+	if idle {
+		go func() { _, _ = r.refresh() }()
+	}
+}
+
+// backingOff returns whether a refresh must not be attempted yet after failed refreshes.
+// Must be called with r.mu held.
+func (r *RemoteJWKSIssuer) backingOff() bool {
+	return time.Since(r.lastAttempt) < r.options.backoff(r.failures)
+}
+
+// refresh refreshes the cache, sharing the refresh between concurrent callers and backing off
+// after failures.
+func (r *RemoteJWKSIssuer) refresh() (*jose.JSONWebKeySet, error) {
+	r.mu.Lock()
+	if call := r.inflight; call != nil {
+		r.mu.Unlock()
+		<-call.done
+		return call.jwks, call.err
+	}
+	if r.backingOff() {
+		failures := r.failures
+		r.mu.Unlock()
+		return nil, fmt.Errorf("jwks fetch backing off after %d consecutive failures", failures)
+	}
+	call := &jwksRefresh{done: make(chan struct{})}
+	r.inflight = call
+	r.lastAttempt = time.Now()
+	r.mu.Unlock()
+
+	call.jwks, call.err = r.refreshCache()
+
+	r.mu.Lock()
+	r.inflight = nil
+	if call.err != nil {
+		r.failures++
+	} else {
+		r.failures = 0
+	}
+	r.mu.Unlock()
+	close(call.done)
+	return call.jwks, call.err
+}
+
 func (r *RemoteJWKSIssuer) refreshCache() (*jose.JSONWebKeySet, error) {
-	resp, err := r.client.Get(r.url)
+	jwks, maxAge, err := r.fetch()
 	if err != nil {
+		r.options.Metrics.RefreshFailed()
 		return nil, err
 	}
+	r.cache.put(jwks, maxAge)
+	r.options.Metrics.RefreshSucceeded(len(jwks.Keys))
+	return jwks, nil
+}
+
+// fetch retrieves the jwks from the issuer, along with the max age of the Cache-Control header of the
+// response, zero if there is none.
+func (r *RemoteJWKSIssuer) fetch() (*jose.JSONWebKeySet, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.options.fetchTimeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("jwks fetch error: Received status %d from issuer", resp.StatusCode)
+		return nil, 0, fmt.Errorf("jwks fetch error: Received status %d from issuer", resp.StatusCode)
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, errors.Wrap(err, "Error reading jwks response")
+		return nil, 0, errors.Wrap(err, "Error reading jwks response")
 	}
 	var newjwks jose.JSONWebKeySet
 	if err := json.Unmarshal(body, &newjwks); err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	maxAge, ok := parseMaxAge(resp.Header.Get("Cache-Control"))
+	if ok && maxAge < r.options.kidMissRefreshInterval() {
+		maxAge = r.options.kidMissRefreshInterval()
 	}
-	r.cache.Put(&newjwks)
-	return &newjwks, nil
+	return &newjwks, maxAge, nil
+}
+
+// parseMaxAge returns the max age of a Cache-Control header value, zero for no-cache and no-store.
+// Returns false when the header does not limit the age.
+func parseMaxAge(cacheControl string) (time.Duration, bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return 0, true
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err == nil && seconds >= 0 {
+				return time.Duration(seconds) * time.Second, true
+			}
+		}
+	}
+	return 0, false
 }
 
 type jwksCache struct {
@@ -129,21 +364,49 @@ type jwksCache struct {
 	cache   *jose.JSONWebKeySet
 	ttl     time.Duration
 	setTime time.Time
+
+	// maxAge overrides the ttl with the max age of the Cache-Control header of the issuer, where > 0.
+	maxAge time.Duration
+}
+
+// expiry returns the time after which the cache has expired.
+func (c *jwksCache) expiry() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	if c.maxAge > 0 {
+		return c.maxAge
+	}
+	return c.ttl
 }
 
 // Error is returned to distinguish between an empty key and an expired cache.
 func (c *jwksCache) getKey(kid string) ([]jose.JSONWebKey, error) {
+	keys, age, cached := c.lookup(kid)
+	if !cached || c.expiry() < age {
+		return nil, errors.New("Cache expired")
+	}
+	return keys, nil
+}
+
+// lookup returns the keys with the given key id regardless of whether the cache has expired, along
+// with the age of the cache. Returns false when the cache has never been set.
+func (c *jwksCache) lookup(kid string) ([]jose.JSONWebKey, time.Duration, bool) {
 	c.RLock()
 	defer c.RUnlock()
-	if c.ttl < time.Since(c.setTime) {
-		return nil, errors.New("Cache expired")
+	if c.cache == nil {
+		return nil, 0, false
 	}
-	return c.cache.Key(kid), nil
+	return c.cache.Key(kid), time.Since(c.setTime), true
 }
 
 func (c *jwksCache) Put(jwks *jose.JSONWebKeySet) {
+	c.put(jwks, 0)
+}
+
+func (c *jwksCache) put(jwks *jose.JSONWebKeySet, maxAge time.Duration) {
 	c.Lock()
 	defer c.Unlock()
 	c.cache = jwks
 	c.setTime = time.Now()
+	c.maxAge = maxAge
 }
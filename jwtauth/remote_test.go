@@ -1,13 +1,17 @@
 package jwtauth
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/anz-bank/sysl-go/metrics"
 	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -242,28 +246,194 @@ func TestJWKSCacheClearAfterTTL(t *testing.T) {
 	assert.Error(t, err)
 }
 
-// This test is unstable due to how the refresh loop works. This will probably be fixed with the cache uplift.
-func TestJWKSCacheRefreshOK(t *testing.T) {
-	t.Skip("todo fixme unstable test needs rework")
-	ctx := testContext()
+func TestJWKSCacheRefreshStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(testContext())
+	defer cancel()
+	server, fetches := countingJWKSServer(t, http.StatusOK, "")
 	refreshDelay := 10 * time.Millisecond
-	url, client := testClient()
-	v, err := NewRemoteJWKSIssuer(ctx, "test-issuer", url, client, time.Minute, refreshDelay)
+	v, err := NewRemoteJWKSIssuer(ctx, "test-issuer", server.URL, server.Client(), time.Minute, refreshDelay)
 	require.NoError(t, err)
 	require.NotNil(t, v)
+
+	require.Eventually(t, func() bool { return fetches.Load() > 2 }, time.Second, refreshDelay)
 	k, err := v.cache.getKey("test")
 	require.NoError(t, err)
-	require.NotNil(t, k)
+	require.NotEmpty(t, k)
+
+	cancel()
+	time.Sleep(2 * refreshDelay)
+	stopped := fetches.Load()
+	time.Sleep(3 * refreshDelay)
+	require.Equal(t, stopped, fetches.Load())
+}
+
+// countingJWKSServer serves the test jwks with the given status and Cache-Control header, counting the fetches.
+func countingJWKSServer(t *testing.T, status int, cacheControl string) (*httptest.Server, *atomic.Int32) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(testJWKS))
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func parseTestJWT(t *testing.T, token string) *jwt.JSONWebToken {
+	jwtToken, err := jwt.ParseSigned(token)
+	require.NoError(t, err)
+	return jwtToken
+}
+
+func TestRemoteJWKSKidMissThrottled(t *testing.T) {
+	server, fetches := countingJWKSServer(t, http.StatusOK, "")
+	registry := prometheus.NewRegistry()
+	jwksMetrics := metrics.NewJWKSMetrics(registry, "test-issuer")
+	v, err := NewRemoteJWKSIssuerWithOptions(testContext(), "test-issuer", server.URL, server.Client(), time.Minute, 0,
+		RemoteJWKSOptions{KidMissRefreshInterval: 50 * time.Millisecond, Metrics: jwksMetrics})
+	require.NoError(t, err)
+
+	untrusted := parseTestJWT(t, issueUntrustedTestJWT())
+	for i := 0; i < 10; i++ {
+		requireAuthErrCode(t, AuthErrCodeUntrustedSource, v.Verify(untrusted, &Claims{}))
+	}
+	require.Equal(t, int32(1), fetches.Load())
+
+	time.Sleep(60 * time.Millisecond)
+	requireAuthErrCode(t, AuthErrCodeUntrustedSource, v.Verify(untrusted, &Claims{}))
+	require.Equal(t, int32(2), fetches.Load())
+	require.NoError(t, v.Verify(parseTestJWT(t, issueTestJWT()), &Claims{}))
+
+	require.Equal(t, 2.0, counterValue(t, registry, "jwks_refreshes_total", "success"))
+	require.Equal(t, 10.0, counterValue(t, registry, "jwks_kid_misses_total", "throttled"))
+	require.Equal(t, 1.0, counterValue(t, registry, "jwks_kid_misses_total", "refreshed"))
+}
+
+// counterValue returns the value of the counter with the given name and result label of the registry,
+// zero if it has not been recorded.
+func counterValue(t *testing.T, registry *prometheus.Registry, name, result string) float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "result" && label.GetValue() == result {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestRemoteJWKSBacksOffAfterFailures(t *testing.T) {
+	server, fetches := countingJWKSServer(t, http.StatusInternalServerError, "")
+	v, err := NewRemoteJWKSIssuerWithOptions(testContext(), "test-issuer", server.URL, server.Client(), time.Minute, 0,
+		RemoteJWKSOptions{InitialBackoff: 50 * time.Millisecond})
+	require.NoError(t, err)
+	token := parseTestJWT(t, issueTestJWT())
+
+	// The first failure is retried immediately, after which refreshes back off.
+	requireAuthErrCode(t, AuthErrCodeUnknown, v.Verify(token, &Claims{}))
+	require.Equal(t, int32(2), fetches.Load())
+	for i := 0; i < 10; i++ {
+		requireAuthErrCode(t, AuthErrCodeUnknown, v.Verify(token, &Claims{}))
+	}
+	require.Equal(t, int32(2), fetches.Load())
+
+	time.Sleep(60 * time.Millisecond)
+	requireAuthErrCode(t, AuthErrCodeUnknown, v.Verify(token, &Claims{}))
+	require.Equal(t, int32(3), fetches.Load())
+}
+
+func TestRemoteJWKSFetchTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	v, err := NewRemoteJWKSIssuerWithOptions(testContext(), "test-issuer", server.URL, &http.Client{}, time.Minute, 0,
+		RemoteJWKSOptions{FetchTimeout: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	start := time.Now()
+	requireAuthErrCode(t, AuthErrCodeUnknown, v.Verify(parseTestJWT(t, issueTestJWT()), &Claims{}))
+	require.Less(t, time.Since(start), time.Second, "the fetch is bounded by the fetch timeout")
+}
+
+func TestRemoteJWKSOptionsBackoff(t *testing.T) {
+	options := RemoteJWKSOptions{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for failures, expected := range []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		require.Equal(t, expected, options.backoff(failures), "failures: %d", failures)
+	}
+	require.Equal(t, DefaultMaxBackoff, RemoteJWKSOptions{}.backoff(100))
+}
+
+func TestRemoteJWKSServesStaleKeys(t *testing.T) {
+	var available atomic.Bool
+	available.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(testJWKS))
+	}))
+	defer server.Close()
+	registry := prometheus.NewRegistry()
+	ttl := 10 * time.Millisecond
+	v, err := NewRemoteJWKSIssuerWithOptions(testContext(), "test-issuer", server.URL, server.Client(), ttl, 0,
+		RemoteJWKSOptions{Metrics: metrics.NewJWKSMetrics(registry, "test-issuer")})
+	require.NoError(t, err)
 
-	// Not locking here produces a race condition caught by -race
-	// this is NOT a race condition in the code, but in this test
-	v.cache.Lock()
-	v.cache.cache.Keys = nil
-	v.cache.Unlock()
-	time.Sleep(refreshDelay + 5*time.Millisecond)
+	available.Store(false)
+	time.Sleep(2 * ttl)
+	token := parseTestJWT(t, issueTestJWT())
+	require.NoError(t, v.Verify(token, &Claims{}))
+	require.Equal(t, 1.0, counterValue(t, registry, "jwks_refreshes_total", "success"))
+	require.Eventually(t, func() bool {
+		return counterValue(t, registry, "jwks_refreshes_total", "failure") == 1
+	}, time.Second, time.Millisecond, "the stale cache should be refreshed in the background")
+
+	// Keys are no longer served once they have been stale for longer than MaxStale.
+	v.options.MaxStale = -1
+	requireAuthErrCode(t, AuthErrCodeUnknown, v.Verify(token, &Claims{}))
+}
 
-	// This should now return the refreshed key
-	k, err = v.cache.getKey("test")
+func TestRemoteJWKSHonoursCacheControl(t *testing.T) {
+	server, fetches := countingJWKSServer(t, http.StatusOK, "public, max-age=3600")
+	v, err := NewRemoteJWKSIssuer(testContext(), "test-issuer", server.URL, server.Client(), time.Millisecond, 0)
 	require.NoError(t, err)
-	require.NotNil(t, k)
+	time.Sleep(5 * time.Millisecond)
+	_, err = v.cache.getKey("test")
+	require.NoError(t, err)
+	require.NoError(t, v.Verify(parseTestJWT(t, issueTestJWT()), &Claims{}))
+	require.Equal(t, int32(1), fetches.Load())
+}
+
+func TestParseMaxAge(t *testing.T) {
+	for header, expected := range map[string]time.Duration{
+		"max-age=60":             time.Minute,
+		"public, max-age=\"10\"": 10 * time.Second,
+		"no-cache":               0,
+		"private, no-store":      0,
+	} {
+		maxAge, ok := parseMaxAge(header)
+		require.True(t, ok, header)
+		require.Equal(t, expected, maxAge, header)
+	}
+	for _, header := range []string{"", "public", "max-age=forever", "max-age=-1"} {
+		_, ok := parseMaxAge(header)
+		require.False(t, ok, header)
+	}
 }
@@ -0,0 +1,189 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/pkg/errors"
+)
+
+// minSharedSecretLength is the minimum length of an HMAC shared secret, the size of the SHA-256 hash
+// used by HS256 (see RFC 7518 section 3.2).
+const minSharedSecretLength = 32
+
+var (
+	rsaAlgorithms   = []string{string(jose.RS256), string(jose.RS384), string(jose.RS512), string(jose.PS256), string(jose.PS384), string(jose.PS512)}
+	hmacAlgorithms  = []string{string(jose.HS256), string(jose.HS384), string(jose.HS512)}
+	asymmetricAlgos = append(append([]string{}, rsaAlgorithms...), string(jose.ES256), string(jose.ES384), string(jose.ES512), string(jose.EdDSA))
+)
+
+// StaticKeysVerifier is a Verifier of tokens signed with a fixed set of keys.
+//
+// The key is selected with the key id of the token, or is the only key of the set when either the
+// token or the key has no key id.
+type StaticKeysVerifier struct {
+	keys jose.JSONWebKeySet
+}
+
+// NewStaticKeysVerifier returns a verifier of tokens signed with the given keys.
+func NewStaticKeysVerifier(keys jose.JSONWebKeySet) *StaticKeysVerifier {
+	return &StaticKeysVerifier{keys: keys}
+}
+
+// Verify implements the Verify interface for StaticKeysVerifier.
+func (v *StaticKeysVerifier) Verify(token *jwt.JSONWebToken, claims ...interface{}) error {
+	if len(token.Headers) != 1 {
+		return &AuthError{
+			Code:  AuthErrCodeInvalidJWT,
+			Cause: errors.New("Token must have one header"),
+		}
+	}
+	header := token.Headers[0]
+	var keys []jose.JSONWebKey
+	switch {
+	case len(v.keys.Keys) == 1 && (header.KeyID == "" || v.keys.Keys[0].KeyID == ""):
+		keys = v.keys.Keys
+	case header.KeyID != "":
+		keys = v.keys.Key(header.KeyID)
+	}
+	if len(keys) == 0 {
+		return &AuthError{
+			Code:  AuthErrCodeUntrustedSource,
+			Cause: errors.New("No matching key id for incoming jwt"),
+		}
+	}
+	if keys[0].Algorithm != "" && keys[0].Algorithm != header.Algorithm {
+		return &AuthError{
+			Code:  AuthErrCodeBadSignature,
+			Cause: fmt.Errorf("jwt signed with %s but key %s is for %s", header.Algorithm, keys[0].KeyID, keys[0].Algorithm),
+		}
+	}
+	if err := token.Claims(keys[0], claims...); err != nil {
+		return &AuthError{
+			Code:  AuthErrCodeBadSignature,
+			Cause: errors.Wrap(err, "jwt verify error"),
+		}
+	}
+	return nil
+}
+
+// algorithmAllowList is a Verifier rejecting the tokens signed with algorithms that are not allowed,
+// before verifying them with the wrapped Verifier.
+type algorithmAllowList struct {
+	Verifier
+	allowed map[string]bool
+}
+
+// withAllowedAlgorithms returns the verifier restricted to the given algorithms.
+func withAllowedAlgorithms(v Verifier, algorithms []string) Verifier {
+	allowed := make(map[string]bool, len(algorithms))
+	for _, alg := range algorithms {
+		allowed[alg] = true
+	}
+	return &algorithmAllowList{Verifier: v, allowed: allowed}
+}
+
+func (a *algorithmAllowList) Verify(token *jwt.JSONWebToken, claims ...interface{}) error {
+	for _, h := range token.Headers {
+		if !a.allowed[h.Algorithm] {
+			return &AuthError{
+				Code:  AuthErrCodeInvalidJWT,
+				Cause: fmt.Errorf("signing algorithm not allowed: %s", h.Algorithm),
+			}
+		}
+	}
+	return a.Verifier.Verify(token, claims...)
+}
+
+// resolveAlgorithms returns the configured algorithms, or the given defaults when not configured,
+// checking that all of them are among the supported algorithms.
+func resolveAlgorithms(configured, defaults, supported []string) ([]string, error) {
+	if len(configured) == 0 {
+		return defaults, nil
+	}
+	for _, alg := range configured {
+		if !contains(supported, alg) {
+			return nil, fmt.Errorf("algorithm %s is not supported by the key, expected one of %s", alg, strings.Join(supported, ", "))
+		}
+	}
+	return configured, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// algorithmsForKey returns the signing algorithms supported by the given public key.
+func algorithmsForKey(key interface{}) ([]string, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return rsaAlgorithms, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return []string{string(jose.ES256)}, nil
+		case elliptic.P384():
+			return []string{string(jose.ES384)}, nil
+		case elliptic.P521():
+			return []string{string(jose.ES512)}, nil
+		}
+		return nil, fmt.Errorf("unsupported elliptic curve %s", k.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return []string{string(jose.EdDSA)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// parsePEMPublicKey parses a PEM encoded public key, PKCS #1 RSA public key or certificate.
+func parsePEMPublicKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %s", block.Type)
+	}
+}
+
+// parseJWKS parses a JWKS document holding public keys only.
+func parseJWKS(data []byte) (jose.JSONWebKeySet, error) {
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return jwks, errors.Wrap(err, "invalid JWKS")
+	}
+	if len(jwks.Keys) == 0 {
+		return jwks, errors.New("JWKS has no keys")
+	}
+	for _, k := range jwks.Keys {
+		if !k.IsPublic() {
+			return jwks, fmt.Errorf("JWKS key %s is not a public key", k.KeyID)
+		}
+	}
+	return jwks, nil
+}
@@ -0,0 +1,106 @@
+package jwtauth
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/config/sensitive"
+)
+
+const testSharedSecret = "0123456789abcdef0123456789abcdef"
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func testPublicKeyPEM(t *testing.T) []byte {
+	der, err := x509.MarshalPKIXPublicKey(testPublicKey.Key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func verifyWith(t *testing.T, ic IssuerConfig, token string) error {
+	v, err := VerifierFromIssuerConfig(testContext(), ic, http.DefaultClient)
+	require.NoError(t, err)
+	parsed, err := jwt.ParseSigned(token)
+	require.NoError(t, err)
+	var claims Claims
+	return v.Verify(parsed, &claims)
+}
+
+func requireAuthErrCode(t *testing.T, code int, err error) {
+	var authErr *AuthError
+	require.True(t, errors.As(err, &authErr), err)
+	require.Equal(t, code, authErr.Code)
+}
+
+func signHMAC(t *testing.T, alg jose.SignatureAlgorithm, secret []byte) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: secret}, nil)
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(Claims{"iss": "test"}).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifierFromIssuerConfigStaticKeys(t *testing.T) {
+	secret := sensitive.New(testSharedSecret)
+	for name, ic := range map[string]IssuerConfig{
+		"inline jwks":     {Name: "test", JWKS: testJWKS},
+		"jwks file":       {Name: "test", JWKSFile: writeTestFile(t, "jwks.json", []byte(testJWKS))},
+		"public key file": {Name: "test", PublicKeyFile: writeTestFile(t, "key.pem", testPublicKeyPEM(t))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, verifyWith(t, ic, issueTestJWT()))
+
+			token, err := jwt.Signed(testUntrustedSigner).Claims(Claims{"iss": "test"}).CompactSerialize()
+			require.NoError(t, err)
+			require.Error(t, verifyWith(t, ic, token))
+
+			// A token signed with HS256 using the public key as the secret must be rejected.
+			requireAuthErrCode(t, AuthErrCodeInvalidJWT, verifyWith(t, ic, signHMAC(t, jose.HS256, testPublicKeyPEM(t))))
+		})
+	}
+
+	t.Run("shared secret", func(t *testing.T) {
+		ic := IssuerConfig{Name: "test", SharedSecret: &secret}
+		require.NoError(t, verifyWith(t, ic, signHMAC(t, jose.HS256, []byte(testSharedSecret))))
+		require.NoError(t, verifyWith(t, ic, signHMAC(t, jose.HS512, []byte(testSharedSecret))))
+		requireAuthErrCode(t, AuthErrCodeBadSignature, verifyWith(t, ic, signHMAC(t, jose.HS256, []byte(testSharedSecret+"x"))))
+		requireAuthErrCode(t, AuthErrCodeInvalidJWT, verifyWith(t, ic, issueTestJWT()))
+
+		ic.Algorithms = []string{"HS512"}
+		requireAuthErrCode(t, AuthErrCodeInvalidJWT, verifyWith(t, ic, signHMAC(t, jose.HS256, []byte(testSharedSecret))))
+	})
+}
+
+func TestVerifierFromIssuerConfigInvalid(t *testing.T) {
+	secret := sensitive.New(testSharedSecret)
+	short := sensitive.New("short")
+	for name, ic := range map[string]IssuerConfig{
+		"no source":                {Name: "test"},
+		"two sources":              {Name: "test", JWKS: testJWKS, SharedSecret: &secret},
+		"short secret":             {Name: "test", SharedSecret: &short},
+		"hmac algorithm for jwks":  {Name: "test", JWKS: testJWKS, Algorithms: []string{"HS256"}},
+		"rsa algorithm for secret": {Name: "test", SharedSecret: &secret, Algorithms: []string{"RS256"}},
+		"ec algorithm for rsa key": {Name: "test", PublicKeyFile: writeTestFile(t, "key.pem", testPublicKeyPEM(t)), Algorithms: []string{"ES256"}},
+		"private key in jwks":      {Name: "test", JWKS: `{"keys":[` + testPrivateKey + `]}`},
+		"invalid pem":              {Name: "test", PublicKeyFile: writeTestFile(t, "key.pem", []byte("not a key"))},
+		"missing file":             {Name: "test", JWKSFile: filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := VerifierFromIssuerConfig(testContext(), ic, http.DefaultClient)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package jwtauth
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/pkg/errors"
+)
+
+// DefaultLeeway is the clock skew allowed when validating the exp, nbf and iat claims of tokens.
+const DefaultLeeway = time.Second
+
+// RequiredClaimConfig defines a claim that the tokens of an issuer must hold.
+//
+// The claim must equal Value or match Pattern when either is set, and must be present otherwise.
+// The claim matches when any of its values matches for claims holding an array.
+type RequiredClaimConfig struct {
+	Name    string `json:"name"              yaml:"name"              mapstructure:"name"`
+	Value   string `json:"value,omitempty"   yaml:"value,omitempty"   mapstructure:"value"`
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty" mapstructure:"pattern"`
+}
+
+// ClaimsValidation validates the claims of the tokens of an issuer, beyond their signature.
+type ClaimsValidation struct {
+	// Audiences are the audiences of which the tokens must hold at least one, not checked when empty.
+	Audiences []string
+
+	// RequiredClaims are the claims the tokens must hold.
+	RequiredClaims []RequiredClaim
+
+	// MaxAge is the maximum time since the tokens were issued (iat), not checked when zero.
+	MaxAge time.Duration
+
+	// Leeway is the clock skew allowed when validating the time claims.
+	Leeway time.Duration
+}
+
+// RequiredClaim is a claim that tokens must hold, see RequiredClaimConfig.
+type RequiredClaim struct {
+	Name    string
+	Value   string
+	Pattern *regexp.Regexp
+}
+
+// ClaimsValidationFromIssuerConfig creates the validation of the claims of the tokens of an issuer.
+func ClaimsValidationFromIssuerConfig(i IssuerConfig) (*ClaimsValidation, error) {
+	v := &ClaimsValidation{
+		Audiences: i.Audiences,
+		MaxAge:    time.Duration(i.MaxAge),
+		Leeway:    time.Duration(i.Leeway),
+	}
+	if v.Leeway == 0 {
+		v.Leeway = DefaultLeeway
+	}
+	for _, c := range i.RequiredClaims {
+		if c.Name == "" {
+			return nil, errors.New("jwtauth.Config: Required claim must have a name")
+		}
+		if c.Value != "" && c.Pattern != "" {
+			return nil, fmt.Errorf("jwtauth.Config: Required claim %s must not have both a value and a pattern", c.Name)
+		}
+		rc := RequiredClaim{Name: c.Name, Value: c.Value}
+		if c.Pattern != "" {
+			pattern, err := regexp.Compile("^(?:" + c.Pattern + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "jwtauth.Config: Invalid pattern of required claim %s", c.Name)
+			}
+			rc.Pattern = pattern
+		}
+		v.RequiredClaims = append(v.RequiredClaims, rc)
+	}
+	return v, nil
+}
+
+// leeway returns the leeway of the validation, which may be nil.
+func (v *ClaimsValidation) leeway() time.Duration {
+	if v == nil {
+		return DefaultLeeway
+	}
+	return v.Leeway
+}
+
+// validateTime validates the exp, nbf and iat claims of a token, and its age when the validation
+// has a maximum age.
+func (v *ClaimsValidation) validateTime(claims jwt.Claims, now time.Time) error {
+	leeway := v.leeway()
+	if err := claims.ValidateWithLeeway(jwt.Expected{Time: now}, leeway); err != nil {
+		code := AuthErrCodeInvalidJWT
+		switch {
+		case errors.Is(err, jwt.ErrExpired):
+			code = AuthErrCodeExpiredJWT
+		case errors.Is(err, jwt.ErrNotValidYet), errors.Is(err, jwt.ErrIssuedInTheFuture):
+			code = AuthErrCodeJWTNotYetValid
+		}
+		return &AuthError{Code: code, Cause: err}
+	}
+	if v == nil || v.MaxAge == 0 {
+		return nil
+	}
+	if claims.IssuedAt == nil {
+		return &AuthError{Code: AuthErrCodeJWTTooOld, Cause: errors.New("jwt has no iat claim")}
+	}
+	if age := now.Sub(claims.IssuedAt.Time()); age > v.MaxAge+leeway {
+		return &AuthError{Code: AuthErrCodeJWTTooOld, Cause: fmt.Errorf("jwt issued %s ago, longer than %s", age.Round(time.Second), v.MaxAge)}
+	}
+	return nil
+}
+
+// validateClaims validates the audience and the required claims of the verified claims of a token.
+func (v *ClaimsValidation) validateClaims(claims Claims) error {
+	if v == nil {
+		return nil
+	}
+	if len(v.Audiences) > 0 {
+		audiences := claimValues(claims["aud"])
+		if !anyEqual(audiences, v.Audiences) {
+			return &AuthError{Code: AuthErrCodeInvalidAudience, Cause: fmt.Errorf("jwt audience %v not expected", audiences)}
+		}
+	}
+	for _, rc := range v.RequiredClaims {
+		value, ok := claims[rc.Name]
+		if !ok {
+			return &AuthError{Code: AuthErrCodeRequiredClaim, Cause: fmt.Errorf("jwt has no %s claim", rc.Name)}
+		}
+		if rc.Value == "" && rc.Pattern == nil {
+			continue
+		}
+		if !rc.matches(claimValues(value)) {
+			return &AuthError{Code: AuthErrCodeRequiredClaim, Cause: fmt.Errorf("jwt %s claim has an unexpected value", rc.Name)}
+		}
+	}
+	return nil
+}
+
+func (rc RequiredClaim) matches(values []string) bool {
+	for _, value := range values {
+		if rc.Pattern != nil && rc.Pattern.MatchString(value) || rc.Pattern == nil && value == rc.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// claimValues returns the values of a claim holding a single value or an array of values.
+func claimValues(claim interface{}) []string {
+	switch c := claim.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{c}
+	case []interface{}:
+		values := make([]string, 0, len(c))
+		for _, v := range c {
+			values = append(values, claimString(v))
+		}
+		return values
+	case []string:
+		return c
+	default:
+		return []string{claimString(c)}
+	}
+}
+
+// claimString formats a claim value, without exponents for JSON numbers.
+func claimString(value interface{}) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func anyEqual(values, expected []string) bool {
+	for _, v := range values {
+		if contains(expected, v) {
+			return true
+		}
+	}
+	return false
+}
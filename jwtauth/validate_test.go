@@ -0,0 +1,93 @@
+package jwtauth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/jsontime"
+)
+
+func issueTestJWTWithClaims(t *testing.T, claims Claims) string {
+	claims["iss"] = "test"
+	token, err := jwt.Signed(testSigner).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthenticateClaimsValidation(t *testing.T) {
+	ic := IssuerConfig{
+		Name:      "test",
+		JWKS:      testJWKS,
+		Audiences: []string{"service-a", "service-b"},
+		RequiredClaims: []RequiredClaimConfig{
+			{Name: "sub"},
+			{Name: "tenant", Value: "anz"},
+			{Name: "scope", Pattern: "accounts:(read|write)"},
+		},
+		MaxAge: jsontime.Duration(time.Hour),
+		Leeway: jsontime.Duration(time.Minute),
+	}
+	auth, err := AuthFromConfig(testContext(), &Config{Issuers: []IssuerConfig{ic}}, func(string) *http.Client { return nil })
+	require.NoError(t, err)
+
+	now := time.Now()
+	valid := func() Claims {
+		return Claims{
+			"aud":    []string{"service-b", "other"},
+			"sub":    "user",
+			"tenant": "anz",
+			"scope":  []string{"profile", "accounts:read"},
+			"iat":    jwt.NewNumericDate(now.Add(-time.Minute)),
+		}
+	}
+	claims, err := auth.Authenticate(testContext(), issueTestJWTWithClaims(t, valid()))
+	require.NoError(t, err)
+	require.Equal(t, "user", claims["sub"])
+
+	for name, tt := range map[string]struct {
+		modify func(Claims)
+		code   int
+	}{
+		"expired beyond leeway":    {func(c Claims) { c["exp"] = jwt.NewNumericDate(now.Add(-2 * time.Minute)) }, AuthErrCodeExpiredJWT},
+		"not valid yet":            {func(c Claims) { c["nbf"] = jwt.NewNumericDate(now.Add(2 * time.Minute)) }, AuthErrCodeJWTNotYetValid},
+		"issued in the future":     {func(c Claims) { c["iat"] = jwt.NewNumericDate(now.Add(2 * time.Minute)) }, AuthErrCodeJWTNotYetValid},
+		"too old":                  {func(c Claims) { c["iat"] = jwt.NewNumericDate(now.Add(-2 * time.Hour)) }, AuthErrCodeJWTTooOld},
+		"no iat":                   {func(c Claims) { delete(c, "iat") }, AuthErrCodeJWTTooOld},
+		"other audience":           {func(c Claims) { c["aud"] = "service-c" }, AuthErrCodeInvalidAudience},
+		"no audience":              {func(c Claims) { delete(c, "aud") }, AuthErrCodeInvalidAudience},
+		"missing required claim":   {func(c Claims) { delete(c, "sub") }, AuthErrCodeRequiredClaim},
+		"unexpected claim value":   {func(c Claims) { c["tenant"] = "other" }, AuthErrCodeRequiredClaim},
+		"claim not matching":       {func(c Claims) { c["scope"] = "accounts:delete" }, AuthErrCodeRequiredClaim},
+		"claim matching partially": {func(c Claims) { c["scope"] = "accounts:readonly" }, AuthErrCodeRequiredClaim},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := valid()
+			tt.modify(c)
+			_, err := auth.Authenticate(testContext(), issueTestJWTWithClaims(t, c))
+			requireAuthErrCode(t, tt.code, err)
+		})
+	}
+
+	// Expired tokens are accepted within the leeway.
+	c := valid()
+	c["exp"] = jwt.NewNumericDate(now.Add(-30 * time.Second))
+	_, err = auth.Authenticate(testContext(), issueTestJWTWithClaims(t, c))
+	require.NoError(t, err)
+}
+
+func TestClaimsValidationFromIssuerConfigInvalid(t *testing.T) {
+	for name, rc := range map[string]RequiredClaimConfig{
+		"no name":           {Value: "a"},
+		"value and pattern": {Name: "a", Value: "a", Pattern: "a"},
+		"invalid pattern":   {Name: "a", Pattern: "("},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ClaimsValidationFromIssuerConfig(IssuerConfig{RequiredClaims: []RequiredClaimConfig{rc}})
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ParseLevel returns the level with the given name: error, warn, info or debug.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "error":
+		return ErrorLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "debug":
+		return DebugLevel, nil
+	default:
+		return 0, fmt.Errorf("unknown log level: %s", name)
+	}
+}
+
+// AtomicLevel is a level that can be safely changed while it is in use, see NewAtomicLevelLogger.
+type AtomicLevel struct {
+	level int32
+}
+
+// NewAtomicLevel returns an AtomicLevel set to the given level.
+func NewAtomicLevel(level Level) *AtomicLevel {
+	return &AtomicLevel{int32(level)}
+}
+
+// Level returns the current level.
+func (a *AtomicLevel) Level() Level {
+	return Level(atomic.LoadInt32(&a.level))
+}
+
+// SetLevel changes the level.
+func (a *AtomicLevel) SetLevel(level Level) {
+	atomic.StoreInt32(&a.level, int32(level))
+}
+
+// NewAtomicLevelLogger returns a Logger that logs at the level of the given AtomicLevel, such that
+// changes to the level apply to all the loggers derived from the returned logger.
+//
+// The given logger is set to the debug level with the returned logger filtering the messages. Calls to
+// WithLevel on the returned logger fix the level of the derived logger, ignoring the AtomicLevel.
+func NewAtomicLevelLogger(logger Logger, level *AtomicLevel) Logger {
+	return &atomicLevelLogger{logger: logger.WithLevel(DebugLevel), level: level}
+}
+
+type atomicLevelLogger struct {
+	logger   Logger
+	level    *AtomicLevel
+	override Level
+}
+
+func (l *atomicLevelLogger) enabled(level Level) bool {
+	if l.override != 0 {
+		return l.override >= level
+	}
+	return l.level.Level() >= level
+}
+
+func (l *atomicLevelLogger) Error(err error, message string) {
+	if l.enabled(ErrorLevel) {
+		l.logger.Error(err, message)
+	}
+}
+
+func (l *atomicLevelLogger) Warn(message string) {
+	if l.enabled(WarnLevel) {
+		l.logger.Warn(message)
+	}
+}
+
+func (l *atomicLevelLogger) Info(message string) {
+	if l.enabled(InfoLevel) {
+		l.logger.Info(message)
+	}
+}
+
+func (l *atomicLevelLogger) Debug(message string) {
+	if l.enabled(DebugLevel) {
+		l.logger.Debug(message)
+	}
+}
+
+func (l *atomicLevelLogger) WithStr(key string, value string) Logger {
+	return l.wrap(l.logger.WithStr(key, value))
+}
+
+func (l *atomicLevelLogger) WithInt(key string, value int) Logger {
+	return l.wrap(l.logger.WithInt(key, value))
+}
+
+func (l *atomicLevelLogger) WithDuration(key string, value time.Duration) Logger {
+	return l.wrap(l.logger.WithDuration(key, value))
+}
+
+func (l *atomicLevelLogger) WithBool(key string, value bool) Logger {
+	return l.wrap(l.logger.WithBool(key, value))
+}
+
+func (l *atomicLevelLogger) WithFloat(key string, value float64) Logger {
+	return l.wrap(l.logger.WithFloat(key, value))
+}
+
+func (l *atomicLevelLogger) WithTime(key string, value time.Time) Logger {
+	return l.wrap(l.logger.WithTime(key, value))
+}
+
+func (l *atomicLevelLogger) WithError(err error) Logger {
+	return l.wrap(l.logger.WithError(err))
+}
+
+func (l *atomicLevelLogger) WithAny(key string, value interface{}) Logger {
+	return l.wrap(l.logger.WithAny(key, value))
+}
+
+func (l *atomicLevelLogger) WithFields(fields map[string]interface{}) Logger {
+	return l.wrap(l.logger.WithFields(fields))
+}
+
+func (l *atomicLevelLogger) WithLevel(level Level) Logger {
+	return &atomicLevelLogger{l.logger, l.level, level}
+}
+
+func (l *atomicLevelLogger) Inject(ctx context.Context) (context.Context, func(ctx context.Context) Logger) {
+	ctx, restore := l.logger.Inject(ctx)
+	return ctx, func(ctx context.Context) Logger { return l.wrap(restore(ctx)) }
+}
+
+func (l *atomicLevelLogger) wrap(logger Logger) Logger {
+	return &atomicLevelLogger{logger, l.level, l.override}
+}
@@ -0,0 +1,45 @@
+package log_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]log.Level{
+		"error": log.ErrorLevel, "WARN": log.WarnLevel, "warning": log.WarnLevel, "info": log.InfoLevel, "debug": log.DebugLevel,
+	} {
+		level, err := log.ParseLevel(name)
+		require.NoError(t, err)
+		require.Equal(t, expected, level)
+	}
+	_, err := log.ParseLevel("verbose")
+	require.Error(t, err)
+}
+
+func TestAtomicLevelLogger(t *testing.T) {
+	logger := testutil.NewTestLogger()
+	level := log.NewAtomicLevel(log.InfoLevel)
+	ctx := log.PutLogger(context.Background(), log.NewAtomicLevelLogger(logger, level))
+	derived := log.WithStr(ctx, "key", "value")
+
+	log.Debug(derived, "hidden")
+	require.Equal(t, 0, logger.EntryCount())
+
+	level.SetLevel(log.DebugLevel)
+	log.Debug(derived, "shown")
+	require.Equal(t, 1, logger.EntryCount())
+	require.Equal(t, "value", logger.LastEntry().Fields["key"])
+
+	level.SetLevel(log.ErrorLevel)
+	log.Info(ctx, "hidden")
+	require.Equal(t, 1, logger.EntryCount())
+
+	log.Debug(log.WithLevel(ctx, log.DebugLevel), "override")
+	require.Equal(t, 2, logger.EntryCount())
+}
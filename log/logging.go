@@ -2,10 +2,12 @@ package log
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	zero "github.com/anz-bank/pkg/logging"
+	"github.com/rs/zerolog"
 
 	pkg "github.com/anz-bank/pkg/log"
 	"github.com/sirupsen/logrus"
@@ -13,9 +15,18 @@ import (
 
 type loggerKey struct{}
 
+const (
+	// ErrorKey is the key of the field set by WithError.
+	ErrorKey = "error"
+
+	// warningKey is the key of the field that marks warnings logged by loggers without a warn level.
+	warningKey = "warning"
+)
+
 // Logger is a component used to perform logging.
 type Logger interface {
 	Error(err error, message string)
+	Warn(message string)
 	Info(message string)
 	Debug(message string)
 
@@ -28,6 +39,24 @@ type Logger interface {
 	// WithDuration returns a new logger that persists the given key/value.
 	WithDuration(key string, value time.Duration) Logger
 
+	// WithBool returns a new logger that persists the given key/value.
+	WithBool(key string, value bool) Logger
+
+	// WithFloat returns a new logger that persists the given key/value.
+	WithFloat(key string, value float64) Logger
+
+	// WithTime returns a new logger that persists the given key/value.
+	WithTime(key string, value time.Time) Logger
+
+	// WithError returns a new logger that persists the given error under ErrorKey.
+	WithError(err error) Logger
+
+	// WithAny returns a new logger that persists the given key and the JSON representation of the value.
+	WithAny(key string, value interface{}) Logger
+
+	// WithFields returns a new logger that persists the given key/values.
+	WithFields(fields map[string]interface{}) Logger
+
 	// WithLevel returns a new logger that logs the given level or below.
 	WithLevel(level Level) Logger
 
@@ -39,12 +68,14 @@ type Logger interface {
 // Level represents the level at which a logger will log.
 // The currently supported values are:
 // 2 - Error
+// 3 - Warn
 // 4 - Info
 // 5 - Debug.
 type Level int
 
 const (
 	ErrorLevel = Level(logrus.ErrorLevel) // 2
+	WarnLevel  = Level(logrus.WarnLevel)  // 3
 	InfoLevel  = Level(logrus.InfoLevel)  // 4
 	DebugLevel = Level(logrus.DebugLevel) // 5
 )
@@ -53,6 +84,8 @@ func (l Level) String() string {
 	switch l {
 	case ErrorLevel:
 		return "error"
+	case WarnLevel:
+		return "warn"
 	case InfoLevel:
 		return "info"
 	default:
@@ -70,6 +103,16 @@ func Errorf(ctx context.Context, err error, format string, args ...interface{})
 	GetLogger(ctx).Error(err, fmt.Sprintf(format, args...))
 }
 
+// Warn logs the given message against the context found in the logger.
+func Warn(ctx context.Context, message string) {
+	GetLogger(ctx).Warn(message)
+}
+
+// Warnf logs the given message against the context found in the logger.
+func Warnf(ctx context.Context, format string, args ...interface{}) {
+	GetLogger(ctx).Warn(fmt.Sprintf(format, args...))
+}
+
 // Info logs the given message against the context found in the logger.
 func Info(ctx context.Context, message string) {
 	GetLogger(ctx).Info(message)
@@ -105,6 +148,37 @@ func WithDuration(ctx context.Context, key string, value time.Duration) context.
 	return PutLogger(ctx, GetLogger(ctx).WithDuration(key, value))
 }
 
+// WithBool returns the given context with a logger that persists the given key/value.
+func WithBool(ctx context.Context, key string, value bool) context.Context {
+	return PutLogger(ctx, GetLogger(ctx).WithBool(key, value))
+}
+
+// WithFloat returns the given context with a logger that persists the given key/value.
+func WithFloat(ctx context.Context, key string, value float64) context.Context {
+	return PutLogger(ctx, GetLogger(ctx).WithFloat(key, value))
+}
+
+// WithTime returns the given context with a logger that persists the given key/value.
+func WithTime(ctx context.Context, key string, value time.Time) context.Context {
+	return PutLogger(ctx, GetLogger(ctx).WithTime(key, value))
+}
+
+// WithError returns the given context with a logger that persists the given error.
+func WithError(ctx context.Context, err error) context.Context {
+	return PutLogger(ctx, GetLogger(ctx).WithError(err))
+}
+
+// WithAny returns the given context with a logger that persists the given key and the JSON
+// representation of the value.
+func WithAny(ctx context.Context, key string, value interface{}) context.Context {
+	return PutLogger(ctx, GetLogger(ctx).WithAny(key, value))
+}
+
+// WithFields returns the given context with a logger that persists the given key/values.
+func WithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	return PutLogger(ctx, GetLogger(ctx).WithFields(fields))
+}
+
 // WithLevel returns the given context with a logger that logs at the given level.
 func WithLevel(ctx context.Context, level Level) context.Context {
 	return PutLogger(ctx, GetLogger(ctx).WithLevel(level))
@@ -145,6 +219,11 @@ func (l *pkgLogger) Error(err error, message string) { l.logger().Error(err, mes
 func (l *pkgLogger) Info(message string)             { l.logger().Info(message) }
 func (l *pkgLogger) Debug(message string)            { l.logger().Debug(message) }
 
+// Warn logs the message at the info level marked as a warning, pkg/log having no warn level.
+func (l *pkgLogger) Warn(message string) {
+	l.fields.With(warningKey, true).From(context.Background()).Info(message)
+}
+
 func (l *pkgLogger) WithStr(key string, value string) Logger {
 	return &pkgLogger{l.fields.With(key, value)}
 }
@@ -157,6 +236,37 @@ func (l *pkgLogger) WithDuration(key string, value time.Duration) Logger {
 	return &pkgLogger{l.fields.With(key, value)}
 }
 
+func (l *pkgLogger) WithBool(key string, value bool) Logger {
+	return &pkgLogger{l.fields.With(key, value)}
+}
+
+func (l *pkgLogger) WithFloat(key string, value float64) Logger {
+	return &pkgLogger{l.fields.With(key, value)}
+}
+
+func (l *pkgLogger) WithTime(key string, value time.Time) Logger {
+	return &pkgLogger{l.fields.With(key, value)}
+}
+
+func (l *pkgLogger) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+	return &pkgLogger{l.fields.With(ErrorKey, err.Error())}
+}
+
+func (l *pkgLogger) WithAny(key string, value interface{}) Logger {
+	return &pkgLogger{l.fields.With(key, jsonValue(value))}
+}
+
+func (l *pkgLogger) WithFields(fields map[string]interface{}) Logger {
+	f := l.fields
+	for key, value := range fields {
+		f = f.With(key, value)
+	}
+	return &pkgLogger{f}
+}
+
 func (l *pkgLogger) WithLevel(level Level) Logger {
 	return &pkgLogger{l.fields.WithConfigs(pkg.SetVerboseMode(level == DebugLevel))}
 }
@@ -181,6 +291,9 @@ func (l *zeroPkgLogger) Error(err error, message string) { l.logger.Error(err).M
 func (l *zeroPkgLogger) Info(message string)             { l.logger.Info().Msg(message) }
 func (l *zeroPkgLogger) Debug(message string)            { l.logger.Debug().Msg(message) }
 
+// Warn logs the message at the info level marked as a warning, pkg/logging having no warn level.
+func (l *zeroPkgLogger) Warn(message string) { l.logger.Info().Bool(warningKey, true).Msg(message) }
+
 func (l *zeroPkgLogger) WithStr(key string, value string) Logger {
 	return &zeroPkgLogger{l.logger.WithStr(key, value)}
 }
@@ -193,12 +306,52 @@ func (l *zeroPkgLogger) WithDuration(key string, value time.Duration) Logger {
 	return &zeroPkgLogger{l.logger.WithDur(key, value)}
 }
 
+func (l *zeroPkgLogger) WithBool(key string, value bool) Logger {
+	return &zeroPkgLogger{l.logger.WithBool(key, value)}
+}
+
+func (l *zeroPkgLogger) WithFloat(key string, value float64) Logger {
+	return l.with([]string{key}, func(c zerolog.Context) zerolog.Context { return c.Float64(key, value) })
+}
+
+func (l *zeroPkgLogger) WithTime(key string, value time.Time) Logger {
+	return &zeroPkgLogger{l.logger.WithTime(key, value)}
+}
+
+func (l *zeroPkgLogger) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+	return l.with([]string{ErrorKey}, func(c zerolog.Context) zerolog.Context { return c.AnErr(ErrorKey, err) })
+}
+
+func (l *zeroPkgLogger) WithAny(key string, value interface{}) Logger {
+	return l.with([]string{key}, func(c zerolog.Context) zerolog.Context { return c.Interface(key, value) })
+}
+
+func (l *zeroPkgLogger) WithFields(fields map[string]interface{}) Logger {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	return l.with(keys, func(c zerolog.Context) zerolog.Context { return c.Fields(fields) })
+}
+
+// with returns a logger with the fields added by fn, pkg/logging only providing helpers for some field types.
+func (l *zeroPkgLogger) with(keys []string, fn func(zerolog.Context) zerolog.Context) Logger {
+	logCtx := l.logger.With(zero.ContextFunc{
+		Keys:     keys,
+		Function: func(_ context.Context, c zerolog.Context) zerolog.Context { return fn(c) },
+	})
+	return &zeroPkgLogger{logCtx.FromContext(context.Background())}
+}
+
 func (l *zeroPkgLogger) WithLevel(level Level) Logger {
 	var lvl zero.Level
 	switch level {
 	case ErrorLevel:
 		lvl = zero.ErrorLevel
-	case InfoLevel:
+	case WarnLevel, InfoLevel:
 		lvl = zero.InfoLevel
 	case DebugLevel:
 		lvl = zero.DebugLevel
@@ -226,6 +379,7 @@ type logrusLogger struct {
 func (l *logrusLogger) entry() *logrus.Entry { return l.logger.WithFields(l.fields) }
 
 func (l *logrusLogger) Error(err error, message string) { l.entry().WithError(err).Error(message) }
+func (l *logrusLogger) Warn(message string)             { l.entry().Warn(message) }
 func (l *logrusLogger) Info(message string)             { l.entry().Info(message) }
 func (l *logrusLogger) Debug(message string)            { l.entry().Debug(message) }
 
@@ -234,6 +388,30 @@ func (l *logrusLogger) WithInt(key string, value int) Logger    { return l.withF
 func (l *logrusLogger) WithDuration(key string, value time.Duration) Logger {
 	return l.withField(key, value)
 }
+func (l *logrusLogger) WithBool(key string, value bool) Logger      { return l.withField(key, value) }
+func (l *logrusLogger) WithFloat(key string, value float64) Logger  { return l.withField(key, value) }
+func (l *logrusLogger) WithTime(key string, value time.Time) Logger { return l.withField(key, value) }
+func (l *logrusLogger) WithAny(key string, value interface{}) Logger {
+	return l.withField(key, jsonValue(value))
+}
+
+func (l *logrusLogger) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+	return l.withField(ErrorKey, err)
+}
+
+func (l *logrusLogger) WithFields(fields map[string]interface{}) Logger {
+	result := make(map[string]interface{})
+	for key, value := range l.fields {
+		result[key] = value
+	}
+	for key, value := range fields {
+		result[key] = value
+	}
+	return &logrusLogger{l.logger, result}
+}
 
 func (l *logrusLogger) withField(key string, value interface{}) Logger {
 	fields := make(map[string]interface{})
@@ -251,6 +429,8 @@ func (l *logrusLogger) WithLevel(level Level) Logger {
 	switch level {
 	case ErrorLevel:
 		lvl = logrus.ErrorLevel
+	case WarnLevel:
+		lvl = logrus.WarnLevel
 	case InfoLevel:
 		lvl = logrus.InfoLevel
 	case DebugLevel:
@@ -269,6 +449,20 @@ func (l *logrusLogger) Inject(ctx context.Context) (context.Context, func(ctx co
 	return LogrusLoggerToContext(ctx, l.logger, GetLogrusLogEntryFromContext(ctx)), func(ctx context.Context) Logger { return l }
 }
 
+// jsonValue returns the value as it would be unmarshalled from its JSON representation, such that
+// structs are logged as objects. The error is returned when the value cannot be marshalled.
+func jsonValue(value interface{}) interface{} {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err.Error()
+	}
+	var result interface{}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return err.Error()
+	}
+	return result
+}
+
 type logrusRequestContextKey struct{}
 
 type logrusRequestContext struct {
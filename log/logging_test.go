@@ -66,6 +66,10 @@ func testLoggerEvents(t *testing.T, newLogger func(*bytes.Buffer) Logger) {
 	// Verify that a debug level log is logged
 	Debug(ctx, "debug")
 	require.Contains(t, buf.String(), "debug")
+
+	// Verify that a warn level log is logged
+	Warnf(ctx, "warn %d", 1)
+	require.Contains(t, buf.String(), "warn 1")
 }
 
 // Test that a logger persists fields between calls.
@@ -93,6 +97,21 @@ func testLoggerPersistence(t *testing.T, newLogger func(*bytes.Buffer) Logger) {
 	require.Contains(t, buf.String(), "duration_event")
 	require.Contains(t, buf.String(), "duration_key")
 	require.True(t, strings.Contains(buf.String(), "3600000") || strings.Contains(buf.String(), "1h0m0s"))
+
+	// Verify that the remaining field types are persisted within the context
+	ctx = WithBool(ctx, "bool_key", true)
+	ctx = WithFloat(ctx, "float_key", 1.5)
+	ctx = WithTime(ctx, "time_key", time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC))
+	ctx = WithError(ctx, errors.New("error_value"))
+	ctx = WithAny(ctx, "any_key", struct {
+		Name string `json:"name"`
+	}{"any_value"})
+	ctx = WithFields(ctx, map[string]interface{}{"fields_key": "fields_value"})
+	Info(ctx, "typed_event")
+	for _, s := range []string{"typed_event", "bool_key", "true", "float_key", "1.5", "time_key", "2021-03-04",
+		"error_value", "any_key", "any_value", "fields_key", "fields_value"} {
+		require.Contains(t, buf.String(), s)
+	}
 }
 
 // Test that a logger logs, or ignores, log levels appropriately.
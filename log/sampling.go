@@ -0,0 +1,205 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const defaultSamplingInterval = time.Second
+
+// maxSamplingKeys caps the number of distinct messages counted per interval. Messages beyond the cap
+// (typically formatted per request) are counted together, by level.
+const maxSamplingKeys = 1000
+
+// SamplingConfig configures the sampling and rate limiting of log messages, see NewSamplingLogger.
+type SamplingConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Interval is the period over which messages are counted, defaulting to one second.
+	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
+
+	// Initial is the number of messages with the same level and message logged per interval before
+	// sampling begins. Messages are not sampled when zero.
+	Initial int `yaml:"initial" mapstructure:"initial" validate:"min=0"`
+
+	// Thereafter is the sampling rate after Initial messages have been logged, every Thereafter-th
+	// message being logged. All further messages are dropped when zero.
+	Thereafter int `yaml:"thereafter" mapstructure:"thereafter" validate:"min=0"`
+
+	// MaxPerSecond caps the number of messages logged per second, unlimited when zero.
+	MaxPerSecond int `yaml:"maxPerSecond" mapstructure:"maxPerSecond" validate:"min=0"`
+}
+
+// SamplingStats holds the number of messages dropped by a SamplingLogger.
+type SamplingStats struct {
+	// Sampled is the number of messages dropped by sampling.
+	Sampled uint64
+
+	// RateLimited is the number of messages dropped by the rate cap.
+	RateLimited uint64
+}
+
+// SamplingLogger is a Logger that samples and rate limits the messages of another Logger.
+//
+// Messages are only counted once enabled: either by the level set through WithLevel, or by a
+// level filtering logger (such as NewAtomicLevelLogger) that wraps the SamplingLogger. The level
+// of the given logger is not known to the SamplingLogger, so disabled messages would otherwise be
+// counted, and take the place of enabled messages.
+type SamplingLogger struct {
+	logger  Logger
+	sampler *sampler
+	level   Level // zero when not set through WithLevel
+}
+
+// NewSamplingLogger returns a Logger that samples and rate limits the messages logged through the
+// given logger. Loggers derived from the returned logger (e.g. through WithStr) share its counters.
+func NewSamplingLogger(logger Logger, cfg SamplingConfig) *SamplingLogger {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultSamplingInterval
+	}
+	s := &sampler{cfg: cfg, counts: map[samplingKey]int{}}
+	if cfg.MaxPerSecond > 0 {
+		s.limiter = rate.NewLimiter(rate.Limit(cfg.MaxPerSecond), cfg.MaxPerSecond)
+	}
+	return &SamplingLogger{logger: logger, sampler: s}
+}
+
+// Stats returns the number of messages dropped.
+func (l *SamplingLogger) Stats() SamplingStats {
+	return SamplingStats{
+		Sampled:     atomic.LoadUint64(&l.sampler.sampled),
+		RateLimited: atomic.LoadUint64(&l.sampler.rateLimited),
+	}
+}
+
+func (l *SamplingLogger) Error(err error, message string) {
+	if l.enabled(ErrorLevel) && l.sampler.allow(ErrorLevel, message) {
+		l.logger.Error(err, message)
+	}
+}
+
+func (l *SamplingLogger) Warn(message string) {
+	if l.enabled(WarnLevel) && l.sampler.allow(WarnLevel, message) {
+		l.logger.Warn(message)
+	}
+}
+
+func (l *SamplingLogger) Info(message string) {
+	if l.enabled(InfoLevel) && l.sampler.allow(InfoLevel, message) {
+		l.logger.Info(message)
+	}
+}
+
+func (l *SamplingLogger) Debug(message string) {
+	if l.enabled(DebugLevel) && l.sampler.allow(DebugLevel, message) {
+		l.logger.Debug(message)
+	}
+}
+
+func (l *SamplingLogger) WithStr(key string, value string) Logger {
+	return l.wrap(l.logger.WithStr(key, value))
+}
+
+func (l *SamplingLogger) WithInt(key string, value int) Logger {
+	return l.wrap(l.logger.WithInt(key, value))
+}
+
+func (l *SamplingLogger) WithDuration(key string, value time.Duration) Logger {
+	return l.wrap(l.logger.WithDuration(key, value))
+}
+
+func (l *SamplingLogger) WithBool(key string, value bool) Logger {
+	return l.wrap(l.logger.WithBool(key, value))
+}
+
+func (l *SamplingLogger) WithFloat(key string, value float64) Logger {
+	return l.wrap(l.logger.WithFloat(key, value))
+}
+
+func (l *SamplingLogger) WithTime(key string, value time.Time) Logger {
+	return l.wrap(l.logger.WithTime(key, value))
+}
+
+func (l *SamplingLogger) WithError(err error) Logger {
+	return l.wrap(l.logger.WithError(err))
+}
+
+func (l *SamplingLogger) WithAny(key string, value interface{}) Logger {
+	return l.wrap(l.logger.WithAny(key, value))
+}
+
+func (l *SamplingLogger) WithFields(fields map[string]interface{}) Logger {
+	return l.wrap(l.logger.WithFields(fields))
+}
+
+func (l *SamplingLogger) WithLevel(level Level) Logger {
+	return &SamplingLogger{l.logger.WithLevel(level), l.sampler, level}
+}
+
+func (l *SamplingLogger) Inject(ctx context.Context) (context.Context, func(ctx context.Context) Logger) {
+	ctx, restore := l.logger.Inject(ctx)
+	return ctx, func(ctx context.Context) Logger { return l.wrap(restore(ctx)) }
+}
+
+func (l *SamplingLogger) wrap(logger Logger) Logger {
+	return &SamplingLogger{logger, l.sampler, l.level}
+}
+
+func (l *SamplingLogger) enabled(level Level) bool {
+	return l.level == 0 || l.level >= level
+}
+
+type samplingKey struct {
+	level   Level
+	message string
+}
+
+// sampler counts the messages of each level and message over an interval.
+type sampler struct {
+	cfg     SamplingConfig
+	limiter *rate.Limiter
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[samplingKey]int
+
+	sampled, rateLimited uint64
+}
+
+func (s *sampler) allow(level Level, message string) bool {
+	if !s.sample(level, message) {
+		atomic.AddUint64(&s.sampled, 1)
+		return false
+	}
+	if s.limiter != nil && !s.limiter.Allow() {
+		atomic.AddUint64(&s.rateLimited, 1)
+		return false
+	}
+	return true
+}
+
+func (s *sampler) sample(level Level, message string) bool {
+	if s.cfg.Initial <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := time.Now(); now.Sub(s.start) >= s.cfg.Interval {
+		s.start = now
+		s.counts = map[samplingKey]int{}
+	}
+	key := samplingKey{level, message}
+	if _, has := s.counts[key]; !has && len(s.counts) >= maxSamplingKeys {
+		key.message = ""
+	}
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.cfg.Initial {
+		return true
+	}
+	return s.cfg.Thereafter > 0 && (n-s.cfg.Initial)%s.cfg.Thereafter == 0
+}
@@ -0,0 +1,85 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func newSamplingTestLogger(cfg SamplingConfig) (*SamplingLogger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	lrs := logrus.New()
+	lrs.Out = buf
+	return NewSamplingLogger(NewLogrusLogger(lrs), cfg), buf
+}
+
+func TestSamplingLogger(t *testing.T) {
+	logger, buf := newSamplingTestLogger(SamplingConfig{Initial: 2, Thereafter: 3, Interval: time.Hour})
+	ctx := PutLogger(context.Background(), logger)
+
+	for i := 0; i < 10; i++ {
+		Info(WithInt(ctx, "i", i), "repeated")
+	}
+	Info(ctx, "other")
+
+	// The first 2 messages are logged, then every 3rd: the 5th and 8th.
+	require.Equal(t, 4, strings.Count(buf.String(), "repeated"))
+	for _, i := range []string{"i=0", "i=1", "i=4", "i=7"} {
+		require.Contains(t, buf.String(), i)
+	}
+	require.Contains(t, buf.String(), "other")
+	require.Equal(t, SamplingStats{Sampled: 6}, logger.Stats())
+}
+
+func TestSamplingLogger_Interval(t *testing.T) {
+	logger, buf := newSamplingTestLogger(SamplingConfig{Initial: 1, Interval: time.Millisecond})
+	logger.Info("repeated")
+	logger.Info("repeated")
+	time.Sleep(2 * time.Millisecond)
+	logger.Info("repeated")
+	require.Equal(t, 2, strings.Count(buf.String(), "repeated"))
+}
+
+func TestSamplingLogger_RateLimit(t *testing.T) {
+	logger, buf := newSamplingTestLogger(SamplingConfig{MaxPerSecond: 3})
+	for i := 0; i < 10; i++ {
+		logger.WithLevel(DebugLevel).Debug("message")
+	}
+	require.Equal(t, 3, strings.Count(buf.String(), "message"))
+	require.Equal(t, SamplingStats{RateLimited: 7}, logger.Stats())
+}
+
+func TestSamplingLogger_Level(t *testing.T) {
+	logger, buf := newSamplingTestLogger(SamplingConfig{MaxPerSecond: 1})
+	info := logger.WithLevel(InfoLevel)
+	for i := 0; i < 10; i++ {
+		info.Debug("disabled")
+	}
+	info.Info("enabled")
+	require.Contains(t, buf.String(), "enabled")
+	require.Equal(t, SamplingStats{}, logger.Stats())
+
+	// Messages filtered by a level logger wrapping the sampler are not counted either.
+	atomic := NewAtomicLevelLogger(logger, NewAtomicLevel(ErrorLevel))
+	for i := 0; i < 10; i++ {
+		atomic.Info("disabled")
+	}
+	require.Equal(t, SamplingStats{}, logger.Stats())
+}
+
+func TestSamplingLogger_MaxKeys(t *testing.T) {
+	logger, buf := newSamplingTestLogger(SamplingConfig{Initial: 1, Interval: time.Hour})
+	for i := 0; i < maxSamplingKeys+10; i++ {
+		logger.Info(fmt.Sprintf("message %d", i))
+	}
+	require.Len(t, logger.sampler.counts, maxSamplingKeys+1)
+	// Messages beyond the cap are sampled together.
+	require.Equal(t, maxSamplingKeys+1, strings.Count(buf.String(), "message"))
+	require.Equal(t, SamplingStats{Sampled: 9}, logger.Stats())
+}
@@ -0,0 +1,225 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// NewSlogLogger returns an implementation of Logger that uses the log/slog logger.
+//
+// WithLevel filters the records passed to the handler of the logger, it cannot enable levels that
+// are disabled by the handler itself.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l *slogLogger) Error(err error, message string) {
+	l.logger.Error(message, slog.Any(ErrorKey, err))
+}
+func (l *slogLogger) Warn(message string)  { l.logger.Warn(message) }
+func (l *slogLogger) Info(message string)  { l.logger.Info(message) }
+func (l *slogLogger) Debug(message string) { l.logger.Debug(message) }
+
+func (l *slogLogger) WithStr(key string, value string) Logger { return l.with(slog.String(key, value)) }
+func (l *slogLogger) WithInt(key string, value int) Logger    { return l.with(slog.Int(key, value)) }
+func (l *slogLogger) WithDuration(key string, value time.Duration) Logger {
+	return l.with(slog.Duration(key, value))
+}
+func (l *slogLogger) WithBool(key string, value bool) Logger { return l.with(slog.Bool(key, value)) }
+func (l *slogLogger) WithFloat(key string, value float64) Logger {
+	return l.with(slog.Float64(key, value))
+}
+func (l *slogLogger) WithTime(key string, value time.Time) Logger {
+	return l.with(slog.Time(key, value))
+}
+func (l *slogLogger) WithAny(key string, value interface{}) Logger {
+	return l.with(slog.Any(key, jsonValue(value)))
+}
+
+func (l *slogLogger) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+	return l.with(slog.Any(ErrorKey, err))
+}
+
+func (l *slogLogger) WithFields(fields map[string]interface{}) Logger {
+	attrs := make([]any, 0, len(fields))
+	for key, value := range fields {
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	return &slogLogger{l.logger.With(attrs...)}
+}
+
+func (l *slogLogger) with(attr slog.Attr) Logger {
+	return &slogLogger{l.logger.With(attr)}
+}
+
+func (l *slogLogger) WithLevel(level Level) Logger {
+	handler := l.logger.Handler()
+	if h, ok := handler.(*slogLevelHandler); ok {
+		handler = h.Handler
+	}
+	return &slogLogger{slog.New(&slogLevelHandler{handler, slogLevel(level)})}
+}
+
+func (l *slogLogger) Inject(ctx context.Context) (context.Context, func(ctx context.Context) Logger) {
+	// Note: slog does not provide a native ability to add itself to the context.
+	return ctx, func(ctx context.Context) Logger { return l }
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case ErrorLevel:
+		return slog.LevelError
+	case WarnLevel:
+		return slog.LevelWarn
+	case InfoLevel:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
+// slogLevelHandler filters the records below the given level.
+type slogLevelHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+func (h *slogLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.Handler.Enabled(ctx, level)
+}
+
+func (h *slogLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &slogLevelHandler{h.Handler.WithAttrs(attrs), h.level}
+}
+
+func (h *slogLevelHandler) WithGroup(name string) slog.Handler {
+	return &slogLevelHandler{h.Handler.WithGroup(name), h.level}
+}
+
+// NewSlogHandler returns a slog.Handler that writes records through the Logger found in the
+// context passed to the slog methods (for example slog.InfoContext), such that libraries using
+// slog log through the same logger, with the same fields, as the rest of the application.
+// The fallback logger is used when the context holds no logger, records are dropped when nil.
+//
+// Grouped attributes are logged with keys prefixed by the group names (for example group.key).
+func NewSlogHandler(fallback Logger) slog.Handler {
+	return &slogHandler{fallback: fallback}
+}
+
+type slogHandler struct {
+	fallback Logger
+	attrs    []slog.Attr
+	group    string
+}
+
+// Enabled returns true, leaving the filtering of levels to the Logger.
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	logger := h.fallback
+	if ctx != nil {
+		if l := GetLogger(ctx); l != nil {
+			logger = l
+		}
+	}
+	if logger == nil {
+		return nil
+	}
+
+	fields := map[string]interface{}{}
+	var err error
+	add := func(prefix string) func(a slog.Attr) bool {
+		return func(a slog.Attr) bool {
+			if e := addSlogAttr(fields, prefix, a); e != nil && err == nil {
+				err = e
+			}
+			return true
+		}
+	}
+	for _, a := range h.attrs {
+		add("")(a)
+	}
+	record.Attrs(add(h.group))
+	if len(fields) > 0 {
+		logger = logger.WithFields(fields)
+	}
+
+	switch {
+	case record.Level >= slog.LevelError:
+		logger.Error(err, record.Message)
+	case record.Level >= slog.LevelWarn:
+		logger.Warn(record.Message)
+	case record.Level >= slog.LevelInfo:
+		logger.Info(record.Message)
+	default:
+		logger.Debug(record.Message)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	// Attributes are qualified by the current group when added, later groups do not apply to them.
+	qualified := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	qualified = append(qualified, h.attrs...)
+	for _, a := range attrs {
+		if h.group != "" {
+			a = slog.Group(h.group, a)
+		}
+		qualified = append(qualified, a)
+	}
+	return &slogHandler{h.fallback, qualified, ""}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{h.fallback, h.attrs, joinSlogKey(h.group, name)}
+}
+
+// addSlogAttr adds the attribute to fields, returning the value when it is an error.
+func addSlogAttr(fields map[string]interface{}, prefix string, a slog.Attr) error {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return nil
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		var err error
+		for _, ga := range a.Value.Group() {
+			if e := addSlogAttr(fields, joinSlogKey(prefix, a.Key), ga); e != nil && err == nil {
+				err = e
+			}
+		}
+		return err
+	}
+	key := joinSlogKey(prefix, a.Key)
+	if err, ok := a.Value.Any().(error); ok {
+		fields[key] = err.Error()
+		return err
+	}
+	fields[key] = a.Value.Any()
+	return nil
+}
+
+func joinSlogKey(prefix, key string) string {
+	switch {
+	case prefix == "":
+		return key
+	case key == "":
+		return prefix
+	default:
+		return prefix + "." + key
+	}
+}
@@ -0,0 +1,54 @@
+//go:build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	newLogger := func(buf *bytes.Buffer) Logger {
+		return NewSlogLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	}
+	testLoggerEvents(t, newLogger)
+	testLoggerLevel(t, newLogger)
+	testLoggerPersistence(t, newLogger)
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := bytes.Buffer{}
+	lrs := logrus.New()
+	lrs.Out = &buf
+	lrs.Formatter = &logrus.JSONFormatter{}
+	ctx := WithStr(PutLogger(context.Background(), NewLogrusLogger(lrs)), "traceid", "abc")
+
+	logger := slog.New(NewSlogHandler(nil)).With("lib", "x").WithGroup("req")
+	logger.InfoContext(ctx, "info", "path", "/", slog.Group("user", "id", 1))
+	require.Contains(t, buf.String(), `"traceid":"abc"`)
+	require.Contains(t, buf.String(), `"lib":"x"`)
+	require.Contains(t, buf.String(), `"req.path":"/"`)
+	require.Contains(t, buf.String(), `"req.user.id":1`)
+	require.Contains(t, buf.String(), `"level":"info"`)
+
+	buf.Reset()
+	logger.ErrorContext(ctx, "failed", "err", errors.New("boom"))
+	require.Contains(t, buf.String(), `"level":"error"`)
+	require.Contains(t, buf.String(), `"error":"boom"`)
+
+	buf.Reset()
+	logger.WarnContext(ctx, "careful")
+	require.Contains(t, buf.String(), `"level":"warning"`)
+
+	// Records are dropped without a logger, or written through the fallback.
+	slog.New(NewSlogHandler(nil)).Info("dropped")
+	require.NotContains(t, buf.String(), "dropped")
+	slog.New(NewSlogHandler(NewLogrusLogger(lrs))).Info("fallback")
+	require.Contains(t, buf.String(), "fallback")
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AuthorizationMetrics counts the authorization decisions made for the endpoints of a service.
+// A nil *AuthorizationMetrics records nothing.
+type AuthorizationMetrics struct {
+	decisions *prometheus.CounterVec
+}
+
+// NewAuthorizationMetrics registers the authorization metrics with the given registry.
+func NewAuthorizationMetrics(registry *prometheus.Registry) *AuthorizationMetrics {
+	return &AuthorizationMetrics{
+		decisions: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "authorization_decisions_total",
+			Help: "Authorization decisions made for requests, by endpoint, decision and failure reason",
+		}, []string{"endpoint", "decision", "reason"})),
+	}
+}
+
+// RecordDecision records an authorization decision for the given endpoint, with an empty reason for
+// the requests that are allowed.
+func (m *AuthorizationMetrics) RecordDecision(endpoint, decision, reason string) {
+	if m == nil {
+		return
+	}
+	m.decisions.WithLabelValues(endpoint, decision, reason).Inc()
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizationMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewAuthorizationMetrics(registry)
+	m.RecordDecision("GetAccount", "allow", "")
+	m.RecordDecision("GetAccount", "allow", "")
+	NewAuthorizationMetrics(registry).RecordDecision("GetAccount", "deny", "expired_jwt")
+
+	require.Equal(t, 2.0, testutil.ToFloat64(m.decisions.WithLabelValues("GetAccount", "allow", "")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.decisions.WithLabelValues("GetAccount", "deny", "expired_jwt")))
+
+	var none *AuthorizationMetrics
+	none.RecordDecision("GetAccount", "allow", "")
+}
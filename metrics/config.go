@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metric types supported by HTTPServerConfig.Type.
+const (
+	MetricTypeSummary         = "summary"
+	MetricTypeHistogram       = "histogram"
+	MetricTypeNativeHistogram = "nativeHistogram"
+)
+
+const (
+	defaultNativeHistogramBucketFactor = 1.1
+	defaultLabelMaxValues              = 100
+	otherLabelValue                    = "other"
+)
+
+var defaultSizeBuckets = prometheus.ExponentialBuckets(64, 4, 8)
+
+// labelNamePattern matches valid Prometheus label names.
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedLabelNames are the names of the labels that every HTTP server metric records.
+var reservedLabelNames = map[string]bool{"code": true, "method": true, "path": true, "service": true}
+
+// Config struct.
+type Config struct {
+	HTTPServer HTTPServerConfig `yaml:"httpServer" mapstructure:"httpServer"`
+}
+
+// HTTPServerConfig configures the metrics recorded for requests handled by the HTTP servers.
+type HTTPServerConfig struct {
+	// Type selects how durations and sizes are recorded: summary (the default), histogram or
+	// nativeHistogram. Unlike summaries, histograms can be aggregated across replicas.
+	Type string `yaml:"type" mapstructure:"type" validate:"omitempty,oneof=summary histogram nativeHistogram"`
+
+	// Buckets are the bucket boundaries of the duration histogram, defaulting to prometheus.DefBuckets.
+	// When using nativeHistogram, classic buckets are only exposed if provided.
+	Buckets []float64 `yaml:"buckets" mapstructure:"buckets"`
+
+	// SizeBuckets are the bucket boundaries of the request and response size histograms.
+	SizeBuckets []float64 `yaml:"sizeBuckets" mapstructure:"sizeBuckets"`
+
+	// NativeHistogramBucketFactor is the growth factor between native histogram buckets, defaulting to 1.1.
+	NativeHistogramBucketFactor float64 `yaml:"nativeHistogramBucketFactor" mapstructure:"nativeHistogramBucketFactor"`
+
+	// Labels are additional labels extracted from each request.
+	Labels []LabelConfig `yaml:"labels" mapstructure:"labels" validate:"dive"`
+}
+
+// LabelConfig configures a label whose value is extracted from a request header or JWT claim.
+// To bound the cardinality of the metrics, once MaxValues distinct values have been recorded any
+// further values are recorded as "other". Requests without the header or claim record an empty value.
+type LabelConfig struct {
+	Name      string `yaml:"name" mapstructure:"name" validate:"required"`
+	Header    string `yaml:"header" mapstructure:"header" validate:"required_without=Claim"`
+	Claim     string `yaml:"claim" mapstructure:"claim" validate:"required_without=Header"`
+	MaxValues int    `yaml:"maxValues" mapstructure:"maxValues"`
+}
+
+// Validate returns an error when the configured labels cannot be registered: when a label name is
+// not a valid Prometheus label name, is reserved or is used more than once.
+func (c *HTTPServerConfig) Validate() error {
+	names := make(map[string]bool, len(c.Labels))
+	for _, l := range c.Labels {
+		switch {
+		case !labelNamePattern.MatchString(l.Name) || strings.HasPrefix(l.Name, "__"):
+			return fmt.Errorf("metrics label name %q is not a valid Prometheus label name", l.Name)
+		case reservedLabelNames[l.Name]:
+			return fmt.Errorf("metrics label name %q is reserved", l.Name)
+		case names[l.Name]:
+			return fmt.Errorf("metrics label name %q is configured more than once", l.Name)
+		}
+		names[l.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type registryKey struct{}
+
+// GetRegistry retrieves the Prometheus registry exposed by the admin server from the context.
+// Returns nil when metrics are not collected (i.e. when the admin server is disabled).
+func GetRegistry(ctx context.Context) *prometheus.Registry {
+	r, _ := ctx.Value(registryKey{}).(*prometheus.Registry)
+	return r
+}
+
+// PutRegistry puts the Prometheus registry into the given context, returning the new context.
+func PutRegistry(ctx context.Context, registry *prometheus.Registry) context.Context {
+	return context.WithValue(ctx, registryKey{}, registry)
+}
@@ -0,0 +1,298 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	grpcTypeUnary        = "unary"
+	grpcTypeClientStream = "client_stream"
+	grpcTypeServerStream = "server_stream"
+	grpcTypeBidiStream   = "bidi_stream"
+)
+
+// grpcMetrics holds the collectors shared by the gRPC server and client metrics.
+type grpcMetrics struct {
+	started     *prometheus.CounterVec
+	handled     *prometheus.CounterVec
+	latency     *prometheus.HistogramVec
+	inFlight    *prometheus.GaugeVec
+	msgReceived *prometheus.CounterVec
+	msgSent     *prometheus.CounterVec
+}
+
+func newGRPCMetrics(registry *prometheus.Registry, prefix, serviceName string) grpcMetrics {
+	constLabels := prometheus.Labels{"service": serviceName}
+	labels := []string{"grpc_type", "grpc_service", "grpc_method"}
+	codeLabels := append(labels[:len(labels):len(labels)], "grpc_code")
+	return grpcMetrics{
+		started: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        prefix + "_started_total",
+			Help:        "RPCs started, by type, gRPC service and method",
+			ConstLabels: constLabels,
+		}, labels)),
+		handled: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        prefix + "_handled_total",
+			Help:        "RPCs completed, by type, gRPC service, method and status code",
+			ConstLabels: constLabels,
+		}, codeLabels)),
+		latency: registerCollector(registry, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        prefix + "_handling_seconds",
+			Help:        "Duration of the completed RPC, by type, gRPC service, method and status code",
+			ConstLabels: constLabels,
+			Buckets:     prometheus.DefBuckets,
+		}, codeLabels)),
+		inFlight: registerCollector(registry, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        prefix + "_in_flight_requests",
+			Help:        "RPCs currently in progress, by type, gRPC service and method",
+			ConstLabels: constLabels,
+		}, labels)),
+		msgReceived: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        prefix + "_msg_received_total",
+			Help:        "Messages received, by type, gRPC service and method",
+			ConstLabels: constLabels,
+		}, labels)),
+		msgSent: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        prefix + "_msg_sent_total",
+			Help:        "Messages sent, by type, gRPC service and method",
+			ConstLabels: constLabels,
+		}, labels)),
+	}
+}
+
+// registerCollector registers the collector with the registry, returning the previously
+// registered collector when an identical one has already been registered.
+func registerCollector[T prometheus.Collector](registry *prometheus.Registry, c T) T {
+	if err := registry.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+// grpcCall records the metrics of a single RPC.
+type grpcCall struct {
+	m                     *grpcMetrics
+	grpcType, svc, method string
+	start                 time.Time
+	once                  sync.Once
+	done                  chan struct{}
+}
+
+func (m *grpcMetrics) begin(grpcType, fullMethod string) *grpcCall {
+	svc, method := splitGRPCMethodName(fullMethod)
+	m.started.WithLabelValues(grpcType, svc, method).Inc()
+	m.inFlight.WithLabelValues(grpcType, svc, method).Inc()
+	return &grpcCall{m: m, grpcType: grpcType, svc: svc, method: method, start: time.Now(), done: make(chan struct{})}
+}
+
+func (c *grpcCall) received() {
+	c.m.msgReceived.WithLabelValues(c.grpcType, c.svc, c.method).Inc()
+}
+
+func (c *grpcCall) sent() {
+	c.m.msgSent.WithLabelValues(c.grpcType, c.svc, c.method).Inc()
+}
+
+// end records the completion of the call. Only the first call to end is recorded.
+func (c *grpcCall) end(err error) {
+	c.once.Do(func() {
+		code := status.Code(err).String()
+		c.m.inFlight.WithLabelValues(c.grpcType, c.svc, c.method).Dec()
+		c.m.handled.WithLabelValues(c.grpcType, c.svc, c.method, code).Inc()
+		c.m.latency.WithLabelValues(c.grpcType, c.svc, c.method, code).Observe(time.Since(c.start).Seconds())
+		close(c.done)
+	})
+}
+
+// endOnDone ends the call when the context is done before the call has otherwise ended, so that
+// streams abandoned before they complete are not counted as in flight forever.
+func (c *grpcCall) endOnDone(ctx context.Context) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.end(status.FromContextError(ctx.Err()).Err())
+		case <-c.done:
+		}
+	}()
+}
+
+// splitGRPCMethodName splits a full method name of the form /package.Service/Method.
+func splitGRPCMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", "unknown"
+}
+
+func grpcStreamType(clientStreams, serverStreams bool) string {
+	switch {
+	case clientStreams && serverStreams:
+		return grpcTypeBidiStream
+	case clientStreams:
+		return grpcTypeClientStream
+	case serverStreams:
+		return grpcTypeServerStream
+	default:
+		return grpcTypeUnary
+	}
+}
+
+// GRPCServerMetrics records the requests handled by a gRPC server. Metrics are partitioned by
+// type, gRPC service, method and (once handled) status code.
+type GRPCServerMetrics struct {
+	grpcMetrics
+}
+
+// NewGRPCServerMetrics registers the gRPC server metrics with the given registry.
+func NewGRPCServerMetrics(registry *prometheus.Registry, serviceName string) *GRPCServerMetrics {
+	return &GRPCServerMetrics{newGRPCMetrics(registry, "grpc_server", serviceName)}
+}
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that records the metrics of unary RPCs.
+func (m *GRPCServerMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		call := m.begin(grpcTypeUnary, info.FullMethod)
+		call.received()
+		resp, err := handler(ctx, req)
+		if err == nil {
+			call.sent()
+		}
+		call.end(err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor that records the metrics of streaming RPCs.
+func (m *GRPCServerMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		call := m.begin(grpcStreamType(info.IsClientStream, info.IsServerStream), info.FullMethod)
+		err := handler(srv, &monitoredServerStream{ServerStream: ss, call: call})
+		call.end(err)
+		return err
+	}
+}
+
+// ServerOptions returns the server options used to install the interceptors.
+func (m *GRPCServerMetrics) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(m.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(m.StreamServerInterceptor()),
+	}
+}
+
+type monitoredServerStream struct {
+	grpc.ServerStream
+	call *grpcCall
+}
+
+func (s *monitoredServerStream) SendMsg(msg interface{}) error {
+	err := s.ServerStream.SendMsg(msg)
+	if err == nil {
+		s.call.sent()
+	}
+	return err
+}
+
+func (s *monitoredServerStream) RecvMsg(msg interface{}) error {
+	err := s.ServerStream.RecvMsg(msg)
+	if err == nil {
+		s.call.received()
+	}
+	return err
+}
+
+// GRPCClientMetrics records the requests made by a gRPC client. Metrics are partitioned by
+// type, gRPC service, method and (once handled) status code.
+type GRPCClientMetrics struct {
+	grpcMetrics
+}
+
+// NewGRPCClientMetrics registers the gRPC client metrics for calls made to the downstream
+// service with the given name with the given registry.
+func NewGRPCClientMetrics(registry *prometheus.Registry, serviceName string) *GRPCClientMetrics {
+	return &GRPCClientMetrics{newGRPCMetrics(registry, "grpc_client", serviceName)}
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor that records the metrics of unary RPCs.
+func (m *GRPCClientMetrics) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		call := m.begin(grpcTypeUnary, method)
+		call.sent()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			call.received()
+		}
+		call.end(err)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a grpc.StreamClientInterceptor that records the metrics of streaming RPCs.
+func (m *GRPCClientMetrics) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		call := m.begin(grpcStreamType(desc.ClientStreams, desc.ServerStreams), method)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			call.end(err)
+			return nil, err
+		}
+		call.endOnDone(ctx)
+		return &monitoredClientStream{ClientStream: cs, call: call, serverStreams: desc.ServerStreams}, nil
+	}
+}
+
+// DialOptions returns the dial options used to install the interceptors.
+func (m *GRPCClientMetrics) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(m.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(m.StreamClientInterceptor()),
+	}
+}
+
+type monitoredClientStream struct {
+	grpc.ClientStream
+	call          *grpcCall
+	serverStreams bool
+}
+
+func (s *monitoredClientStream) SendMsg(msg interface{}) error {
+	err := s.ClientStream.SendMsg(msg)
+	if err == nil {
+		s.call.sent()
+	}
+	return err
+}
+
+// RecvMsg records the received message, ending the call once the stream has completed.
+func (s *monitoredClientStream) RecvMsg(msg interface{}) error {
+	err := s.ClientStream.RecvMsg(msg)
+	switch {
+	case err == nil:
+		s.call.received()
+		if !s.serverStreams {
+			// Only a single response is expected so the call is complete.
+			s.call.end(nil)
+		}
+	case errors.Is(err, io.EOF):
+		s.call.end(nil)
+	default:
+		s.call.end(err)
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCServerMetrics_Unary(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewGRPCServerMetrics(registry, "app")
+	interceptor := m.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.TestService/Test"}
+
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		require.Equal(t, 1.0, testutil.ToFloat64(m.inFlight.WithLabelValues("unary", "test.TestService", "Test")))
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	_, err = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "missing")
+	})
+	require.Error(t, err)
+
+	require.Equal(t, 2.0, testutil.ToFloat64(m.started.WithLabelValues("unary", "test.TestService", "Test")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.handled.WithLabelValues("unary", "test.TestService", "Test", "OK")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.handled.WithLabelValues("unary", "test.TestService", "Test", "NotFound")))
+	require.Equal(t, 0.0, testutil.ToFloat64(m.inFlight.WithLabelValues("unary", "test.TestService", "Test")))
+	require.Equal(t, 2.0, testutil.ToFloat64(m.msgReceived.WithLabelValues("unary", "test.TestService", "Test")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.msgSent.WithLabelValues("unary", "test.TestService", "Test")))
+	require.Equal(t, 2, testutil.CollectAndCount(m.latency))
+}
+
+func TestGRPCClientMetrics_SharedRegistration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	a := NewGRPCClientMetrics(registry, "downstream")
+	b := NewGRPCClientMetrics(registry, "downstream")
+	c := NewGRPCClientMetrics(registry, "other")
+	require.Same(t, a.handled, b.handled)
+	require.NotSame(t, a.handled, c.handled)
+
+	err := b.UnaryClientInterceptor()(context.Background(), "/test.TestService/Test", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return status.Error(codes.Unavailable, "down")
+		})
+	require.Error(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(a.handled.WithLabelValues("unary", "test.TestService", "Test", "Unavailable")))
+	require.Equal(t, 0, testutil.CollectAndCount(c.handled))
+}
+
+// fakeClientStream returns the given errors from successive calls to RecvMsg.
+type fakeClientStream struct {
+	grpc.ClientStream
+	recv []error
+}
+
+func (s *fakeClientStream) RecvMsg(interface{}) error {
+	err := s.recv[0]
+	s.recv = s.recv[1:]
+	return err
+}
+
+func TestGRPCClientMetrics_Stream(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewGRPCClientMetrics(registry, "downstream")
+	interceptor := m.StreamClientInterceptor()
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, recv ...error) grpc.ClientStream {
+		cs, err := interceptor(ctx, desc, nil, "/test.TestService/Test",
+			func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &fakeClientStream{recv: recv}, nil
+			})
+		require.NoError(t, err)
+		return cs
+	}
+
+	// The call of a client stream ends with its single response, not again at the end of the stream.
+	cs := stream(context.Background(), &grpc.StreamDesc{ClientStreams: true}, nil, io.EOF)
+	require.NoError(t, cs.RecvMsg(nil))
+	require.ErrorIs(t, cs.RecvMsg(nil), io.EOF)
+	require.Equal(t, 1.0, testutil.ToFloat64(m.handled.WithLabelValues("client_stream", "test.TestService", "Test", "OK")))
+	require.Equal(t, 0.0, testutil.ToFloat64(m.inFlight.WithLabelValues("client_stream", "test.TestService", "Test")))
+
+	// Streams abandoned before they complete end when their context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cs = stream(ctx, &grpc.StreamDesc{ServerStreams: true}, nil)
+	require.NoError(t, cs.RecvMsg(nil))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.inFlight.WithLabelValues("server_stream", "test.TestService", "Test")))
+	cancel()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(m.handled.WithLabelValues("server_stream", "test.TestService", "Test", "Canceled")) == 1
+	}, time.Second, time.Millisecond)
+	require.Equal(t, 0.0, testutil.ToFloat64(m.inFlight.WithLabelValues("server_stream", "test.TestService", "Test")))
+}
+
+func TestSplitGRPCMethodName(t *testing.T) {
+	svc, method := splitGRPCMethodName("/pkg.Service/Method")
+	require.Equal(t, "pkg.Service", svc)
+	require.Equal(t, "Method", method)
+
+	svc, method = splitGRPCMethodName("bad")
+	require.Equal(t, "unknown", svc)
+	require.Equal(t, "unknown", method)
+}
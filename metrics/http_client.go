@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type httpClientPathPatternKey struct{}
+
+// PutHTTPClientPathPattern puts the templated path of an outgoing request (e.g. /accounts/{id})
+// into the given context, returning the new context. Requests made with the context are recorded
+// against the pattern by the HTTP client metrics.
+func PutHTTPClientPathPattern(ctx context.Context, pattern string) context.Context {
+	return context.WithValue(ctx, httpClientPathPatternKey{}, pattern)
+}
+
+// GetHTTPClientPathPattern retrieves the templated path of an outgoing request from the context.
+// Returns "unknown" when no pattern has been set, to avoid partitioning metrics by raw paths.
+func GetHTTPClientPathPattern(ctx context.Context) string {
+	if p, ok := ctx.Value(httpClientPathPatternKey{}).(string); ok && p != "" {
+		return p
+	}
+	return "unknown"
+}
+
+// HTTPClientMetrics records the requests made to a downstream service. Requests and latency are
+// partitioned by code, method and path, using the same label names as the HTTP server metrics.
+// Requests that fail without a response are recorded with the code "error".
+type HTTPClientMetrics struct {
+	requests    *prometheus.CounterVec
+	latency     *prometheus.HistogramVec
+	connections *prometheus.CounterVec
+}
+
+// NewHTTPClientMetrics registers the HTTP client metrics for requests made to the downstream
+// service with the given name with the given registry.
+func NewHTTPClientMetrics(registry *prometheus.Registry, serviceName string) *HTTPClientMetrics {
+	constLabels := prometheus.Labels{"service": serviceName}
+	return &HTTPClientMetrics{
+		requests: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "http_client_requests_total",
+			Help:        "HTTP requests made to downstream services, by status code, method and HTTP path",
+			ConstLabels: constLabels,
+		}, []string{"code", "method", "path"})),
+		latency: registerCollector(registry, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "http_client_request_duration_seconds",
+			Help:        "Duration of the requests made to downstream services, by status code, method and HTTP path",
+			ConstLabels: constLabels,
+			Buckets:     prometheus.DefBuckets,
+		}, []string{"code", "method", "path"})),
+		connections: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "http_client_connections_total",
+			Help:        "Connections obtained for requests made to downstream services, by whether the connection was reused",
+			ConstLabels: constLabels,
+		}, []string{"reused"})),
+	}
+}
+
+// RoundTripper returns a http.RoundTripper that records the metrics of requests made through base.
+func (m *HTTPClientMetrics) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &metricsRoundTripper{metrics: m, base: base}
+}
+
+type metricsRoundTripper struct {
+	metrics *HTTPClientMetrics
+	base    http.RoundTripper
+}
+
+func (t *metricsRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.metrics.connections.WithLabelValues(strconv.FormatBool(info.Reused)).Inc()
+		},
+	}
+	r = r.WithContext(httptrace.WithClientTrace(r.Context(), trace))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(r)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	path := GetHTTPClientPathPattern(r.Context())
+	t.metrics.requests.WithLabelValues(code, r.Method, path).Inc()
+	t.metrics.latency.WithLabelValues(code, r.Method, path).Observe(time.Since(start).Seconds())
+	return resp, err
+}
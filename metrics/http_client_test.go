@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	registry := prometheus.NewRegistry()
+	m := NewHTTPClientMetrics(registry, "downstream")
+	client := &http.Client{Transport: m.RoundTripper(nil)}
+
+	ctx := PutHTTPClientPathPattern(context.Background(), "/things/{id}")
+	for _, path := range []string{"/things/1", "/things/2"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:0/unreachable", nil)
+	require.NoError(t, err)
+	_, err = client.Do(req) //nolint:bodyclose
+	require.Error(t, err)
+
+	require.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("418", "GET", "/things/{id}")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("error", "GET", "unknown")))
+	require.Equal(t, 2, testutil.CollectAndCount(m.latency))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.connections.WithLabelValues("true")))
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// JWKSMetrics records the state of the JWKS cache of a remote token issuer. A nil *JWKSMetrics
+// records nothing.
+type JWKSMetrics struct {
+	refreshes   *prometheus.CounterVec
+	kidMisses   *prometheus.CounterVec
+	staleServed prometheus.Counter
+	keys        prometheus.Gauge
+	lastRefresh prometheus.Gauge
+}
+
+// NewJWKSMetrics registers the JWKS cache metrics of the issuer with the given name with the given registry.
+func NewJWKSMetrics(registry *prometheus.Registry, issuer string) *JWKSMetrics {
+	constLabels := prometheus.Labels{"issuer": issuer}
+	return &JWKSMetrics{
+		refreshes: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "jwks_refreshes_total",
+			Help:        "Fetches of the JWKS of token issuers, by result",
+			ConstLabels: constLabels,
+		}, []string{"result"})),
+		kidMisses: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "jwks_kid_misses_total",
+			Help:        "Tokens with a key id missing from the cached JWKS, by whether a refresh was made or throttled",
+			ConstLabels: constLabels,
+		}, []string{"result"})),
+		staleServed: registerCollector(registry, prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "jwks_stale_served_total",
+			Help:        "Tokens verified with the keys of an expired JWKS cache",
+			ConstLabels: constLabels,
+		})),
+		keys: registerCollector(registry, prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "jwks_keys",
+			Help:        "Number of keys in the cached JWKS",
+			ConstLabels: constLabels,
+		})),
+		lastRefresh: registerCollector(registry, prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "jwks_last_refresh_timestamp_seconds",
+			Help:        "Time of the last successful fetch of the JWKS, in seconds since the epoch",
+			ConstLabels: constLabels,
+		})),
+	}
+}
+
+// RefreshSucceeded records a successful fetch of a JWKS holding the given number of keys.
+func (m *JWKSMetrics) RefreshSucceeded(keys int) {
+	if m == nil {
+		return
+	}
+	m.refreshes.WithLabelValues("success").Inc()
+	m.keys.Set(float64(keys))
+	m.lastRefresh.SetToCurrentTime()
+}
+
+// RefreshFailed records a failed fetch of the JWKS.
+func (m *JWKSMetrics) RefreshFailed() {
+	if m == nil {
+		return
+	}
+	m.refreshes.WithLabelValues("failure").Inc()
+}
+
+// KidMiss records a token with an unknown key id, by whether it caused a refresh of the JWKS.
+func (m *JWKSMetrics) KidMiss(refreshed bool) {
+	if m == nil {
+		return
+	}
+	result := "throttled"
+	if refreshed {
+		result = "refreshed"
+	}
+	m.kidMisses.WithLabelValues(result).Inc()
+}
+
+// StaleServed records a token verified with the keys of an expired JWKS cache.
+func (m *JWKSMetrics) StaleServed() {
+	if m == nil {
+		return
+	}
+	m.staleServed.Inc()
+}
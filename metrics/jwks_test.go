@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKSMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewJWKSMetrics(registry, "issuer")
+	m.RefreshSucceeded(3)
+	m.RefreshFailed()
+	m.KidMiss(true)
+	m.KidMiss(false)
+	m.KidMiss(false)
+	m.StaleServed()
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.refreshes.WithLabelValues("success")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.refreshes.WithLabelValues("failure")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.kidMisses.WithLabelValues("refreshed")))
+	require.Equal(t, 2.0, testutil.ToFloat64(m.kidMisses.WithLabelValues("throttled")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.staleServed))
+	require.Equal(t, 3.0, testutil.ToFloat64(m.keys))
+	require.NotZero(t, testutil.ToFloat64(m.lastRefresh))
+
+	// The metrics of another issuer are registered alongside.
+	NewJWKSMetrics(registry, "other").RefreshFailed()
+	require.Equal(t, 3, testutil.CollectAndCount(registry, "jwks_refreshes_total"))
+
+	var none *JWKSMetrics
+	none.RefreshSucceeded(1)
+	none.RefreshFailed()
+	none.KidMiss(true)
+	none.StaleServed()
+}
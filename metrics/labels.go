@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// requestLabel extracts the value of a configured label from requests, limiting the
+// number of distinct values recorded.
+type requestLabel struct {
+	cfg       LabelConfig
+	maxValues int
+	mu        sync.Mutex
+	seen      map[string]struct{}
+}
+
+func newRequestLabel(cfg LabelConfig) *requestLabel {
+	maxValues := cfg.MaxValues
+	if maxValues <= 0 {
+		maxValues = defaultLabelMaxValues
+	}
+	return &requestLabel{cfg: cfg, maxValues: maxValues, seen: map[string]struct{}{}}
+}
+
+// limit returns the value, or "other" once the maximum number of distinct values has been reached.
+func (l *requestLabel) limit(value string) string {
+	if value == "" {
+		return value
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, has := l.seen[value]; has {
+		return value
+	}
+	if len(l.seen) >= l.maxValues {
+		return otherLabelValue
+	}
+	l.seen[value] = struct{}{}
+	return value
+}
+
+type requestLabelValuesKey struct{}
+
+// requestLabelValues holds the label values of a single request. Values sourced from claims
+// are set once the request has been authenticated, see RecordClaims.
+type requestLabelValues struct {
+	labels []*requestLabel
+	mu     sync.Mutex
+	values []string
+}
+
+func newRequestLabelValues(labels []*requestLabel, r *http.Request) *requestLabelValues {
+	values := make([]string, len(labels))
+	for i, l := range labels {
+		if l.cfg.Header != "" {
+			values[i] = r.Header.Get(l.cfg.Header)
+		}
+	}
+	return &requestLabelValues{labels: labels, values: values}
+}
+
+func (v *requestLabelValues) get() []string {
+	if v == nil {
+		return nil
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	result := make([]string, len(v.values))
+	for i, l := range v.labels {
+		result[i] = l.limit(v.values[i])
+	}
+	return result
+}
+
+// RecordClaims sets the values of the labels sourced from JWT claims for the request associated
+// with the context. It is called once a request has been authenticated and is a no-op when no
+// such labels have been configured.
+func RecordClaims(ctx context.Context, claims map[string]interface{}) {
+	v, ok := ctx.Value(requestLabelValuesKey{}).(*requestLabelValues)
+	if !ok {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, l := range v.labels {
+		if l.cfg.Claim == "" {
+			continue
+		}
+		if claim, has := claims[l.cfg.Claim]; has && claim != nil {
+			v.values[i] = fmt.Sprint(claim)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -18,39 +19,75 @@ import (
 // getPathPattern allows you to pass a function which will group paths by
 // specified patterns. If not value is assigned, the requests and latency will
 // be partitioned by the standalone path on the incoming request.
+// Additional labels can be configured through HTTPServerConfig.
 type Middleware struct {
 	requests       *prometheus.CounterVec
-	latency        *prometheus.SummaryVec
+	latency        prometheus.ObserverVec
+	requestSize    prometheus.ObserverVec
+	responseSize   prometheus.ObserverVec
+	inFlight       prometheus.Gauge
+	labels         []*requestLabel
 	getPathPattern func(ctx context.Context) string
 }
 
 // NewHTTPServerMetricsMiddleware returns a new Prometheus Middleware handler.
 func NewHTTPServerMetricsMiddleware(registry *prometheus.Registry, serviceName string,
 	getPathPattern func(ctx context.Context) string) func(next http.Handler) http.Handler {
+	return NewHTTPServerMetricsMiddlewareWithConfig(registry, serviceName, getPathPattern, nil)
+}
+
+// NewHTTPServerMetricsMiddlewareWithConfig returns a new Prometheus Middleware handler
+// configured by cfg. A nil cfg is equivalent to the zero configuration. The cfg must be valid
+// (see HTTPServerConfig.Validate), otherwise registering the metrics panics.
+func NewHTTPServerMetricsMiddlewareWithConfig(registry *prometheus.Registry, serviceName string,
+	getPathPattern func(ctx context.Context) string, cfg *HTTPServerConfig) func(next http.Handler) http.Handler {
+	if cfg == nil {
+		cfg = &HTTPServerConfig{}
+	}
+	constLabels := prometheus.Labels{"service": serviceName}
+
+	labels := make([]*requestLabel, 0, len(cfg.Labels))
+	labelNames := []string{"code", "method", "path"}
+	for _, l := range cfg.Labels {
+		labels = append(labels, newRequestLabel(l))
+		labelNames = append(labelNames, l.Name)
+	}
+
 	requestCounterVec := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name:        "http_server_requests_total",
 			Help:        "HTTP requests processed, by status code, method and HTTP path",
-			ConstLabels: prometheus.Labels{"service": serviceName},
+			ConstLabels: constLabels,
 		},
-		[]string{"code", "method", "path"},
+		labelNames,
 	)
 	registry.MustRegister(requestCounterVec)
 
-	latencySummary := prometheus.NewSummaryVec(
-		prometheus.SummaryOpts{
-			Name:        "http_server_request_duration_seconds",
-			Help:        "Duration of the processed request, by status code, method and HTTP path",
-			ConstLabels: prometheus.Labels{"service": serviceName},
-			Objectives:  map[float64]float64{0.50: 0.01, 0.90: 0.001, 0.95: 0.001, 0.99: 0.0001},
-		},
-		[]string{"code", "method", "path"},
-	)
-	registry.MustRegister(latencySummary)
+	inFlight := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "http_server_requests_in_flight",
+		Help:        "HTTP requests currently being processed",
+		ConstLabels: constLabels,
+	})
+	registry.MustRegister(inFlight)
+
+	sizeBuckets := cfg.SizeBuckets
+	if len(sizeBuckets) == 0 && cfg.Type == MetricTypeHistogram {
+		sizeBuckets = defaultSizeBuckets
+	}
 
 	m := Middleware{
-		requests:       requestCounterVec,
-		latency:        latencySummary,
+		requests: requestCounterVec,
+		latency: newObserverVec(registry, cfg, "http_server_request_duration_seconds",
+			"Duration of the processed request, by status code, method and HTTP path",
+			constLabels, labelNames, cfg.Buckets),
+		requestSize: newObserverVec(registry, cfg, "http_server_request_size_bytes",
+			"Size of the request body, by status code, method and HTTP path",
+			constLabels, labelNames, sizeBuckets),
+		responseSize: newObserverVec(registry, cfg, "http_server_response_size_bytes",
+			"Size of the response body, by status code, method and HTTP path",
+			constLabels, labelNames, sizeBuckets),
+		inFlight:       inFlight,
+		labels:         labels,
 		getPathPattern: getPathPattern,
 	}
 
@@ -62,22 +99,84 @@ func NewHTTPServerMetricsMiddleware(registry *prometheus.Registry, serviceName s
 func (m *Middleware) MonitorMetrics(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		requestStart := time.Now()
-		ww := NewStatusResponseWriter(w)
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		var values *requestLabelValues
+		if len(m.labels) > 0 {
+			values = newRequestLabelValues(m.labels, r)
+			r = r.WithContext(context.WithValue(r.Context(), requestLabelValuesKey{}, values))
+		}
+		body := &countingReadCloser{ReadCloser: r.Body}
+		if r.Body != nil {
+			r.Body = body
+		}
+
+		ww := &StatusResponseWriter{ResponseWriter: w}
 		next.ServeHTTP(ww, r)
 		pathPattern := r.URL.Path
 		if m.getPathPattern != nil {
 			pathPattern = m.getPathPattern(r.Context())
 		}
-		m.updateMetrics(ww.Status(), r.Method, pathPattern, requestStart)
+		labelValues := append([]string{strconv.Itoa(ww.Status()), r.Method, pathPattern}, values.get()...)
+		m.updateMetrics(labelValues, requestStart, body.n, ww.BytesWritten())
 	}
 	return http.HandlerFunc(fn)
 }
 
-func (m *Middleware) updateMetrics(status int, method, path string, requestStart time.Time) {
-	statusString := strconv.Itoa(status)
+func (m *Middleware) updateMetrics(labelValues []string, requestStart time.Time, requestSize, responseSize int) {
 	durationSecs := time.Since(requestStart).Seconds()
-	m.requests.WithLabelValues(statusString, method, path).Inc()
-	m.latency.WithLabelValues(statusString, method, path).Observe(durationSecs)
+	m.requests.WithLabelValues(labelValues...).Inc()
+	m.latency.WithLabelValues(labelValues...).Observe(durationSecs)
+	m.requestSize.WithLabelValues(labelValues...).Observe(float64(requestSize))
+	m.responseSize.WithLabelValues(labelValues...).Observe(float64(responseSize))
+}
+
+// newObserverVec registers a summary or histogram as selected by cfg.Type.
+func newObserverVec(registry *prometheus.Registry, cfg *HTTPServerConfig, name, help string,
+	constLabels prometheus.Labels, labelNames []string, buckets []float64) prometheus.ObserverVec {
+	switch cfg.Type {
+	case MetricTypeHistogram, MetricTypeNativeHistogram:
+		opts := prometheus.HistogramOpts{
+			Name:        name,
+			Help:        help,
+			ConstLabels: constLabels,
+			Buckets:     buckets,
+		}
+		if cfg.Type == MetricTypeNativeHistogram {
+			opts.NativeHistogramBucketFactor = cfg.NativeHistogramBucketFactor
+			if opts.NativeHistogramBucketFactor <= 1 {
+				opts.NativeHistogramBucketFactor = defaultNativeHistogramBucketFactor
+			}
+		}
+		h := prometheus.NewHistogramVec(opts, labelNames)
+		registry.MustRegister(h)
+		return h
+	default:
+		s := prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Name:        name,
+				Help:        help,
+				ConstLabels: constLabels,
+				Objectives:  map[float64]float64{0.50: 0.01, 0.90: 0.001, 0.95: 0.001, 0.99: 0.0001},
+			},
+			labelNames,
+		)
+		registry.MustRegister(s)
+		return s
+	}
+}
+
+// countingReadCloser counts the bytes read from the request body.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += n
+	return n, err
 }
 
 func Handler(registry *prometheus.Registry) http.Handler {
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, middleware func(http.Handler) http.Handler, handler http.HandlerFunc, req *http.Request) {
+	t.Helper()
+	middleware(handler).ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestHTTPServerMetrics_Histogram(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	mw := NewHTTPServerMetricsMiddlewareWithConfig(registry, "app", nil, &HTTPServerConfig{Type: MetricTypeHistogram})
+
+	serve(t, mw, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(body))
+		_, _ = w.Write([]byte("hello world"))
+	}, httptest.NewRequest(http.MethodPost, "/hello", strings.NewReader("hello")))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	sums := map[string]float64{}
+	for _, f := range families {
+		if h := f.GetMetric()[0].GetHistogram(); h != nil {
+			sums[f.GetName()] = h.GetSampleSum()
+		}
+	}
+	require.Equal(t, 5.0, sums["http_server_request_size_bytes"])
+	require.Equal(t, 11.0, sums["http_server_response_size_bytes"])
+	require.Contains(t, sums, "http_server_request_duration_seconds")
+}
+
+func TestHTTPServerMetrics_SummaryByDefault(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	mw := NewHTTPServerMetricsMiddleware(registry, "app", nil)
+	serve(t, mw, func(w http.ResponseWriter, r *http.Request) {}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() == "http_server_request_duration_seconds" {
+			require.NotNil(t, f.GetMetric()[0].GetSummary())
+			return
+		}
+	}
+	require.Fail(t, "duration metric not found")
+}
+
+func TestHTTPServerMetrics_Labels(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	mw := NewHTTPServerMetricsMiddlewareWithConfig(registry, "app", nil, &HTTPServerConfig{
+		Labels: []LabelConfig{
+			{Name: "tenant", Header: "X-Tenant", MaxValues: 2},
+			{Name: "subject", Claim: "sub"},
+		},
+	})
+
+	inFlight := 0.0
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		inFlight = testutil.ToFloat64(mustGauge(t, registry))
+		RecordClaims(r.Context(), map[string]interface{}{"sub": "alice"})
+		w.WriteHeader(http.StatusOK)
+	}
+	for _, tenant := range []string{"a", "b", "c", "a"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant", tenant)
+		serve(t, mw, handler, req)
+	}
+	serve(t, mw, func(w http.ResponseWriter, r *http.Request) {}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, 1.0, inFlight)
+	require.Equal(t, 0.0, testutil.ToFloat64(mustGauge(t, registry)))
+
+	counter := mustCounter(t, registry)
+	require.Equal(t, 2.0, testutil.ToFloat64(counter.WithLabelValues("200", "GET", "/", "a", "alice")))
+	require.Equal(t, 1.0, testutil.ToFloat64(counter.WithLabelValues("200", "GET", "/", "b", "alice")))
+	require.Equal(t, 1.0, testutil.ToFloat64(counter.WithLabelValues("200", "GET", "/", "other", "alice")))
+	require.Equal(t, 1.0, testutil.ToFloat64(counter.WithLabelValues("0", "GET", "/", "", "")))
+}
+
+func TestRecordClaims_NoLabels(t *testing.T) {
+	require.NotPanics(t, func() { RecordClaims(context.Background(), map[string]interface{}{"sub": "alice"}) })
+}
+
+func mustGauge(t *testing.T, registry *prometheus.Registry) prometheus.Gauge {
+	t.Helper()
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "http_server_requests_in_flight",
+		Help:        "HTTP requests currently being processed",
+		ConstLabels: prometheus.Labels{"service": "app"},
+	})
+	return existing(t, registry, g)
+}
+
+func mustCounter(t *testing.T, registry *prometheus.Registry) *prometheus.CounterVec {
+	t.Helper()
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "http_server_requests_total",
+		Help:        "HTTP requests processed, by status code, method and HTTP path",
+		ConstLabels: prometheus.Labels{"service": "app"},
+	}, []string{"code", "method", "path", "tenant", "subject"})
+	return existing(t, registry, c)
+}
+
+func existing[T prometheus.Collector](t *testing.T, registry *prometheus.Registry, c T) T {
+	t.Helper()
+	err := registry.Register(c)
+	var are prometheus.AlreadyRegisteredError
+	require.ErrorAs(t, err, &are)
+	return are.ExistingCollector.(T)
+}
+
+func TestHTTPServerConfigValidate(t *testing.T) {
+	require.NoError(t, (&HTTPServerConfig{Labels: []LabelConfig{{Name: "tenant", Header: "X-Tenant"}}}).Validate())
+
+	for _, name := range []string{"code", "path", "service", "x-tenant", "1tenant", "__tenant", "tenant"} {
+		cfg := &HTTPServerConfig{Labels: []LabelConfig{{Name: "tenant", Header: "X-Tenant"}, {Name: name, Claim: "sub"}}}
+		require.Error(t, cfg.Validate(), name)
+	}
+}
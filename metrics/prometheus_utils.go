@@ -20,6 +20,7 @@ type StatusResponseWriter struct {
 	http.ResponseWriter
 	code        int  // Response status
 	wroteHeader bool // Check if header has been assigned
+	written     int  // Number of bytes written
 }
 
 // WriteHeader captures the assigned response code for access at a later time,
@@ -38,7 +39,9 @@ func (w *StatusResponseWriter) Write(data []byte) (int, error) {
 	if !w.wroteHeader {
 		w.WriteHeader(http.StatusOK)
 	}
-	return w.ResponseWriter.Write(data)
+	n, err := w.ResponseWriter.Write(data)
+	w.written += n
+	return n, err
 }
 
 // Status returns the status assigned to the outgoing http response.
@@ -46,6 +49,11 @@ func (w *StatusResponseWriter) Status() int {
 	return w.code
 }
 
+// BytesWritten returns the number of bytes written to the outgoing http response body.
+func (w *StatusResponseWriter) BytesWritten() int {
+	return w.written
+}
+
 // NewStatusResponseWriter returns a ProxyResponseWriter which allows us to
 // access the outgoing response status.
 func NewStatusResponseWriter(w http.ResponseWriter) ProxyResponseWriter {
@@ -15,6 +15,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/anz-bank/sysl-go/common"
+	"github.com/anz-bank/sysl-go/metrics"
 )
 
 // HTTPResult is the result return by the library.
@@ -121,6 +122,10 @@ type HTTPRequest struct {
 	Required          []string
 	Responses         func(int) any
 	ExtraHeaders      map[string][]string
+
+	// PathTemplate is the templated path of the endpoint (e.g. /accounts/{id}), used to partition
+	// the downstream request metrics.
+	PathTemplate string
 }
 
 // DoHTTPRequest returns HTTPResult.
@@ -157,12 +162,19 @@ func DoHTTPRequest(ctx context.Context, config *HTTPRequest) (*HTTPResult, error
 		}
 	}
 
+	if config.PathTemplate != "" {
+		ctx = metrics.PutHTTPClientPathPattern(ctx, config.PathTemplate)
+	}
+
 	httpRequest, err := http.NewRequestWithContext(ctx, config.Method, config.URLString, reader)
 	if err != nil {
 		return nil, err
 	}
 
-	httpRequest.Header = headers
+	if headers != nil {
+		httpRequest.Header = headers
+	}
+	common.PropagateTraceHeaders(ctx, httpRequest.Header)
 
 	httpResponse, err := config.Client.Do(httpRequest)
 	if err != nil {
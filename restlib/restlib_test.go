@@ -53,6 +53,7 @@ func testDoHTTPRequest(ctx context.Context,
 			}
 		},
 		nil,
+		"",
 	})
 }
 
@@ -697,3 +698,18 @@ func TestConcurrentRequestRace(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestDoHTTPRequestPropagatesTraceID(t *testing.T) {
+	var received string
+	srv := common.NewHTTPTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("RequestID")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(okJSON))
+	}))
+	defer srv.Close()
+
+	ctx := common.AddTraceIDStringToContext(context.Background(), "abc-123", true)
+	_, err := testDoHTTPRequest(ctx, srv.Client(), "GET", srv.URL, nil, make([]string, 0), &OkType{}, &ErrorType{})
+	require.NoError(t, err)
+	require.Equal(t, "abc-123", received)
+}
@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/anz-bank/sysl-go/config"
@@ -41,6 +42,7 @@ func (l *TestLogger) EntryCount() int {
 }
 
 func (l *TestLogger) Error(err error, message string) { l.log(log.ErrorLevel, err, message) }
+func (l *TestLogger) Warn(message string)             { l.log(log.WarnLevel, nil, message) }
 func (l *TestLogger) Info(message string)             { l.log(log.InfoLevel, nil, message) }
 func (l *TestLogger) Debug(message string)            { l.log(log.DebugLevel, nil, message) }
 
@@ -67,6 +69,39 @@ func (l *TestLogger) WithDuration(key string, value time.Duration) log.Logger {
 	return l.withField(key, value)
 }
 
+func (l *TestLogger) WithBool(key string, value bool) log.Logger {
+	return l.withField(key, value)
+}
+
+func (l *TestLogger) WithFloat(key string, value float64) log.Logger {
+	return l.withField(key, value)
+}
+
+func (l *TestLogger) WithTime(key string, value time.Time) log.Logger {
+	return l.withField(key, value)
+}
+
+func (l *TestLogger) WithError(err error) log.Logger {
+	if err == nil {
+		return l
+	}
+	return l.withField(log.ErrorKey, err)
+}
+
+// WithAny persists the JSON representation of the value, as the log.Logger implementations do, so
+// that tests see the value that is logged in production.
+func (l *TestLogger) WithAny(key string, value interface{}) log.Logger {
+	return l.withField(key, jsonValue(value))
+}
+
+func (l *TestLogger) WithFields(fields map[string]interface{}) log.Logger {
+	result := l.copyFields()
+	for key, value := range fields {
+		result[key] = value
+	}
+	return &TestLogger{l.Level, result, l.entries}
+}
+
 func (l *TestLogger) withField(key string, value interface{}) log.Logger {
 	fields := l.copyFields()
 	fields[key] = value
@@ -81,6 +116,19 @@ func (l *TestLogger) Inject(ctx context.Context) (context.Context, func(ctx cont
 	return ctx, func(_ context.Context) log.Logger { return l } // return single instance
 }
 
+// jsonValue returns the value decoded from its JSON representation, or the encoding error message.
+func jsonValue(value interface{}) interface{} {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err.Error()
+	}
+	var result interface{}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return err.Error()
+	}
+	return result
+}
+
 func (l *TestLogger) copyFields() map[string]interface{} {
 	fields := make(map[string]interface{})
 	for key, value := range l.Fields {
@@ -0,0 +1,17 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestLoggerWithAny(t *testing.T) {
+	logger := NewTestLogger()
+	logger.WithAny("any", struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}{"a", 1}).Info("message")
+
+	require.Equal(t, map[string]interface{}{"name": "a", "count": 1.0}, logger.Entries()[0].Fields["any"])
+}
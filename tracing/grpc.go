@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// GRPCServerOptions returns the server options that record a span for each inbound RPC,
+// continuing any trace propagated by the caller.
+func GRPCServerOptions(tp trace.TracerProvider) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(tp), otelgrpc.WithPropagators(Propagator()))),
+	}
+}
+
+// GRPCServerLogFieldsOptions returns the server options that add the trace and span IDs of each
+// inbound RPC to the logger in the context (see WithLogFields). They must be installed after any
+// interceptor that puts a logger into the context, otherwise that logger replaces the fields.
+func GRPCServerLogFieldsOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(logFieldsUnaryInterceptor),
+		grpc.ChainStreamInterceptor(logFieldsStreamInterceptor),
+	}
+}
+
+// GRPCDialOptions returns the dial options that record a span for each outbound RPC and
+// propagate the trace context to the downstream service.
+func GRPCDialOptions(tp trace.TracerProvider) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithTracerProvider(tp), otelgrpc.WithPropagators(Propagator()))),
+	}
+}
+
+func logFieldsUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(WithLogFields(ctx), req)
+}
+
+func logFieldsStreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{ServerStream: ss, ctx: WithLogFields(ss.Context())})
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
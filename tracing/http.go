@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/anz-bank/sysl-go/metrics"
+)
+
+// NewHTTPServerMiddleware returns a middleware that records a span for each inbound request,
+// continuing any trace propagated by the caller. Spans are named by the method and the chi route
+// pattern matched by the request.
+func NewHTTPServerMiddleware(tp trace.TracerProvider) func(next http.Handler) http.Handler {
+	tracer := tp.Tracer(instrumentationName)
+	propagator := Propagator()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPMethod(r.Method), semconv.URLPath(r.URL.Path)),
+			)
+			defer span.End()
+
+			ww := &metrics.StatusResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if rctx := chi.RouteContext(ctx); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					span.SetName(r.Method + " " + pattern)
+					span.SetAttributes(semconv.HTTPRoute(pattern))
+				}
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
+
+// NewHTTPClientRoundTripper returns a http.RoundTripper that records a span for each request made
+// through base and propagates the trace context to the downstream service. Spans are named by the
+// method and the templated path of the request (see metrics.PutHTTPClientPathPattern).
+func NewHTTPClientRoundTripper(tp trace.TracerProvider, base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithTracerProvider(tp),
+		otelhttp.WithPropagators(Propagator()),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + metrics.GetHTTPClientPathPattern(r.Context())
+		}),
+	)
+}
@@ -0,0 +1,16 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/sdk/contrib/opentelemetry"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// NewTemporalInterceptor returns a Temporal interceptor that records spans for the workflows and
+// activities started by a client, and for their execution by workers created from the client.
+func NewTemporalInterceptor(tp trace.TracerProvider) (interceptor.Interceptor, error) {
+	return opentelemetry.NewTracingInterceptor(opentelemetry.TracerOptions{
+		Tracer:            tp.Tracer(instrumentationName),
+		TextMapPropagator: Propagator(),
+	})
+}
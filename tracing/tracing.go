@@ -0,0 +1,131 @@
+// Package tracing provides the optional OpenTelemetry tracing of sysl-go applications.
+//
+// Tracing is enabled through the library.trace.openTelemetry configuration or by providing a
+// TracerProvider through Hooks.TracerProvider. When enabled, spans are recorded for inbound REST
+// and gRPC requests, outbound requests made through the downstream clients, and Temporal workflow
+// and activity executions. Trace context is propagated using the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/log"
+)
+
+const instrumentationName = "github.com/anz-bank/sysl-go"
+
+type tracerProviderKey struct{}
+
+// GetTracerProvider retrieves the TracerProvider from the context.
+// Returns nil when tracing is disabled.
+func GetTracerProvider(ctx context.Context) trace.TracerProvider {
+	tp, _ := ctx.Value(tracerProviderKey{}).(trace.TracerProvider)
+	return tp
+}
+
+// PutTracerProvider puts the TracerProvider into the given context, returning the new context.
+func PutTracerProvider(ctx context.Context, tp trace.TracerProvider) context.Context {
+	return context.WithValue(ctx, tracerProviderKey{}, tp)
+}
+
+// Propagator returns the propagator used to propagate trace context across process boundaries.
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// NewTracerProvider creates a TracerProvider that exports spans as configured by cfg.
+// Returns nil when tracing is not enabled. The caller is responsible for shutting the
+// provider down to flush any pending spans.
+func NewTracerProvider(ctx context.Context, cfg *config.OpenTelemetryConfig) (*sdktrace.TracerProvider, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+
+	res := resource.Default()
+	if cfg.ServiceName != "" {
+		var err error
+		res, err = resource.Merge(res, resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+	for _, e := range cfg.Exporters {
+		exporter, err := newExporter(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	if len(cfg.Exporters) == 0 {
+		log.Info(ctx, "OpenTelemetry tracing enabled without exporters, spans will not be exported")
+	}
+
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+func newExporter(ctx context.Context, cfg config.TraceExporterConfig) (sdktrace.SpanExporter, error) {
+	headers := make(map[string]string, len(cfg.Headers))
+	for k, v := range cfg.Headers {
+		v := v
+		headers[k] = v.Value()
+	}
+
+	switch cfg.Type {
+	case "otlpgrpc":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case "otlphttp":
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case "stdout":
+		return stdouttrace.New()
+	default:
+		return nil, fmt.Errorf("unknown trace exporter type: %s", cfg.Type)
+	}
+}
+
+// Install sets the TracerProvider and propagator as the global OpenTelemetry defaults so that
+// spans created by application code (through otel.Tracer) join the traces recorded by sysl-go.
+func Install(tp trace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(Propagator())
+}
+
+// WithLogFields adds the trace and span IDs of the current span to the logger in the context,
+// correlating the logs with the trace.
+func WithLogFields(ctx context.Context) context.Context {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ctx
+	}
+	return log.WithStr(log.WithStr(ctx, "trace_id", sc.TraceID().String()), "span_id", sc.SpanID().String())
+}
@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/anz-bank/sysl-go/config"
+	"github.com/anz-bank/sysl-go/log"
+	"github.com/anz-bank/sysl-go/metrics"
+	"github.com/anz-bank/sysl-go/testutil"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func newTestTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+func TestNewHTTPServerMiddleware(t *testing.T) {
+	tp, exporter := newTestTracerProvider()
+
+	r := chi.NewRouter()
+	r.Use(NewHTTPServerMiddleware(tp))
+	r.Get("/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, trace.SpanContextFromContext(r.Context()).IsValid())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/123", nil)
+	req.Header.Set("traceparent", traceparent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	require.Equal(t, "GET /accounts/{id}", span.Name)
+	require.Equal(t, trace.SpanKindServer, span.SpanKind)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	require.Contains(t, span.Attributes, attribute.Int("http.status_code", http.StatusInternalServerError))
+	require.Equal(t, "Error", span.Status.Code.String())
+}
+
+func TestNewHTTPClientRoundTripper(t *testing.T) {
+	tp, exporter := newTestTracerProvider()
+
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewHTTPClientRoundTripper(tp, http.DefaultTransport)}
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	ctx = metrics.PutHTTPClientPathPattern(ctx, "/accounts/{id}")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/accounts/123", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "GET /accounts/{id}", spans[0].Name)
+	require.Equal(t, parent.SpanContext().TraceID(), spans[0].SpanContext.TraceID())
+	require.Contains(t, received, parent.SpanContext().TraceID().String())
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	ctx := testutil.NewTestContext()
+
+	tp, err := NewTracerProvider(ctx, &config.OpenTelemetryConfig{})
+	require.NoError(t, err)
+	require.Nil(t, tp)
+
+	tp, err = NewTracerProvider(ctx, &config.OpenTelemetryConfig{
+		Enabled:     true,
+		ServiceName: "test",
+		SampleRatio: 1,
+		Exporters:   []config.TraceExporterConfig{{Type: "stdout"}, {Type: "otlphttp", Endpoint: "localhost:4318", Insecure: true}},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, tp)
+	require.NoError(t, tp.Shutdown(ctx))
+
+	_, err = NewTracerProvider(ctx, &config.OpenTelemetryConfig{
+		Enabled:   true,
+		Exporters: []config.TraceExporterConfig{{Type: "unknown"}},
+	})
+	require.Error(t, err)
+}
+
+func TestTracerProviderContext(t *testing.T) {
+	require.Nil(t, GetTracerProvider(context.Background()))
+	tp, _ := newTestTracerProvider()
+	require.Equal(t, tp, GetTracerProvider(PutTracerProvider(context.Background(), tp)))
+}
+
+func TestWithLogFields(t *testing.T) {
+	ctx, logger := testutil.NewTestContextWithLogger()
+	require.Equal(t, ctx, WithLogFields(ctx))
+
+	tp, _ := newTestTracerProvider()
+	ctx, span := tp.Tracer("test").Start(ctx, "span")
+	defer span.End()
+	ctx = WithLogFields(ctx)
+	log.Info(ctx, "hello")
+
+	require.Equal(t, span.SpanContext().TraceID().String(), logger.LastEntry().Fields["trace_id"])
+	require.Equal(t, span.SpanContext().SpanID().String(), logger.LastEntry().Fields["span_id"])
+}